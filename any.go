@@ -0,0 +1,76 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// anyElementType returns the Go type of the elements the wildcard any allows,
+// according to its processContents: the elements of lax and strict wildcards
+// are decoded into the types of the global elements they are named after.
+func anyElementType(any *XSDAny) string {
+	switch any.ProcessContents {
+	case "skip":
+		return "soap.AnyElement"
+	case "lax":
+		return "soap.LaxElement"
+	}
+	return "soap.StrictElement"
+}
+
+// decodesWildcards tells whether the complex types of the schemas hold
+// wildcards whose elements are decoded into the types of the global elements
+// they are named after, in which case these elements are registered.
+func (g *GoWSDL) decodesWildcards() bool {
+	var complexType func(ct *XSDComplexType) bool
+	var particle func(p *XSDParticle) bool
+
+	complexType = func(ct *XSDComplexType) bool {
+		for _, content := range []XSDContent{ct.XSDContent, ct.ComplexContent.Extension.XSDContent, ct.ComplexContent.Restriction.XSDContent} {
+			if particle(content.Particle()) {
+				return true
+			}
+		}
+		return false
+	}
+	particle = func(p *XSDParticle) bool {
+		switch {
+		case p == nil:
+		case p.Any != nil:
+			return p.Any.ProcessContents != "skip"
+		case p.Element != nil:
+			elm := p.Element
+			return elm.Type == "" && elm.Ref == "" && elm.ComplexType != nil && complexType(elm.ComplexType)
+		case p.ModelGroup != nil:
+			for _, child := range p.ModelGroup.Particles {
+				if particle(child) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			if complexType(ct) {
+				return true
+			}
+		}
+		for _, elm := range schema.Elements {
+			if elm.Type == "" && elm.ComplexType != nil && complexType(elm.ComplexType) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// registersElement tells whether the global element elm of the schema being
+// generated is registered with soap.RegisterElement: when it belongs to a
+// substitution group, or when wildcards may decode it.
+func (g *GoWSDL) registersElement(elm *XSDElement) bool {
+	if g.substitutable(elm) {
+		return true
+	}
+	return g.registeredElements && !elm.Abstract && (elm.Type != "" || elm.ComplexType != nil || elm.SimpleType != nil)
+}
@@ -0,0 +1,353 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultCacheTTL is how long cached documents are used before being
+// revalidated, by default.
+const DefaultCacheTTL = 24 * time.Hour
+
+// Cache is a persistent cache of the WSDL and XML Schema documents downloaded
+// while generating, keyed by URL. Documents are stored once by the SHA-256 of
+// their content, under the blobs directory, and the URLs they were downloaded
+// from are recorded under the urls directory, along with the validators their
+// server sent.
+type Cache struct {
+	// Dir is the directory holding the cache.
+	Dir string
+	// TTL is how long a cached document is used without asking its server
+	// whether it changed. Documents older than that are revalidated with
+	// their ETag or Last-Modified date, and downloaded again when they
+	// changed.
+	TTL time.Duration
+}
+
+// CacheEntry describes a document of a Cache.
+type CacheEntry struct {
+	URL          string    `json:"url"`
+	SHA256       string    `json:"sha256"`
+	Size         int64     `json:"size"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"lastModified,omitempty"`
+	Validated    time.Time `json:"validated"`
+}
+
+// NewCache returns the cache in dir, whose documents are revalidated once
+// they are older than ttl.
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+// DefaultCacheDir returns the directory of the cache of the command line
+// tool: gowsdl in the cache directory of the user, or gowsdl-cache in the
+// temporary directory when the user has none.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "gowsdl-cache")
+	}
+	return filepath.Join(dir, "gowsdl")
+}
+
+func (c *Cache) entryPath(rawurl string) string {
+	sum := sha256.Sum256([]byte(rawurl))
+	return filepath.Join(c.Dir, "urls", hex.EncodeToString(sum[:])+".json")
+}
+
+func (c *Cache) blobPath(sum string) string {
+	return filepath.Join(c.Dir, "blobs", sum)
+}
+
+// lookup returns the entry of rawurl and its document, or nil when it isn't
+// cached or its document is missing or corrupted.
+func (c *Cache) lookup(rawurl string) (*CacheEntry, []byte) {
+	data, err := ioutil.ReadFile(c.entryPath(rawurl))
+	if err != nil {
+		return nil, nil
+	}
+	entry := new(CacheEntry)
+	if err := json.Unmarshal(data, entry); err != nil || entry.URL != rawurl {
+		return nil, nil
+	}
+
+	doc, err := ioutil.ReadFile(c.blobPath(entry.SHA256))
+	if err != nil {
+		return nil, nil
+	}
+	if sum := sha256.Sum256(doc); hex.EncodeToString(sum[:]) != entry.SHA256 {
+		return nil, nil
+	}
+	return entry, doc
+}
+
+// store records doc as the document of entry.
+func (c *Cache) store(entry *CacheEntry, doc []byte) error {
+	sum := sha256.Sum256(doc)
+	entry.SHA256 = hex.EncodeToString(sum[:])
+	entry.Size = int64(len(doc))
+	if _, err := os.Stat(c.blobPath(entry.SHA256)); err != nil {
+		if err := writeFileAtomic(c.blobPath(entry.SHA256), doc); err != nil {
+			return err
+		}
+	}
+	return c.storeEntry(entry)
+}
+
+func (c *Cache) storeEntry(entry *CacheEntry) error {
+	data, err := json.MarshalIndent(entry, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(c.entryPath(entry.URL), data)
+}
+
+// tempFilePrefix is the prefix of the names of the temporary files
+// writeFileAtomic writes, which Prune leaves alone.
+const tempFilePrefix = ".tmp-"
+
+// writeFileAtomic writes data to the file name through a temporary file, so
+// that readers never see it partly written.
+func writeFileAtomic(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0700); err != nil {
+		return err
+	}
+	f, err := ioutil.TempFile(filepath.Dir(name), tempFilePrefix)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return err
+	}
+	return os.Rename(f.Name(), name)
+}
+
+// fetch returns the document at rawurl from the cache while it is fresher
+// than the TTL, or offline. Otherwise, it is downloaded, or revalidated when
+// cached, and the cache updated. A document which can't be revalidated,
+// because its server can't be reached or fails with a 5xx response, is used
+// as cached.
+func (c *Cache) fetch(rawurl string, ignoreTLS, offline bool) ([]byte, error) {
+	entry, doc := c.lookup(rawurl)
+	if entry != nil && (offline || time.Since(entry.Validated) < c.TTL) {
+		log.Println("Reading", "cached file", rawurl)
+		return doc, nil
+	}
+	if offline {
+		return nil, fmt.Errorf("%s isn't cached, and can't be downloaded offline", rawurl)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawurl, nil)
+	if err != nil {
+		return nil, err
+	}
+	if entry != nil {
+		log.Println("Revalidating", "cached file", rawurl)
+		if entry.ETag != "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	} else {
+		log.Println("Downloading", "file", rawurl)
+	}
+
+	resp, err := newHTTPClient(ignoreTLS).Do(req)
+	if err != nil {
+		if entry != nil {
+			log.Printf("[WARN] Revalidating %s failed, using the cached copy: %v", rawurl, err)
+			return doc, nil
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && entry != nil:
+		entry.Validated = time.Now()
+		if err := c.storeEntry(entry); err != nil {
+			log.Printf("[WARN] Caching %s failed: %v", rawurl, err)
+		}
+		return doc, nil
+	case resp.StatusCode >= http.StatusInternalServerError && entry != nil:
+		log.Printf("[WARN] Revalidating %s failed, using the cached copy: received response code %d", rawurl, resp.StatusCode)
+		return doc, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("Received response code %d", resp.StatusCode)
+	}
+
+	doc, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	entry = &CacheEntry{
+		URL:          rawurl,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Validated:    time.Now(),
+	}
+	if err := c.store(entry, doc); err != nil {
+		log.Printf("[WARN] Caching %s failed: %v", rawurl, err)
+	}
+	return doc, nil
+}
+
+// Entries returns the entries of the cache, sorted by URL.
+func (c *Cache) Entries() ([]*CacheEntry, error) {
+	files, err := ioutil.ReadDir(filepath.Join(c.Dir, "urls"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*CacheEntry
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		data, err := ioutil.ReadFile(filepath.Join(c.Dir, "urls", file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		entry := new(CacheEntry)
+		if err := json.Unmarshal(data, entry); err != nil {
+			return nil, fmt.Errorf("reading cache entry %s: %w", file.Name(), err)
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].URL < entries[j].URL })
+	return entries, nil
+}
+
+// Prune removes the entries of the cache which weren't validated for longer
+// than maxAge, and the documents no entry refers to anymore. It returns the
+// entries removed.
+func (c *Cache) Prune(maxAge time.Duration) ([]*CacheEntry, error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []*CacheEntry
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		if time.Since(entry.Validated) <= maxAge {
+			used[entry.SHA256] = true
+			continue
+		}
+		if err := os.Remove(c.entryPath(entry.URL)); err != nil {
+			return removed, err
+		}
+		removed = append(removed, entry)
+	}
+
+	blobs, err := ioutil.ReadDir(filepath.Join(c.Dir, "blobs"))
+	if err != nil && !os.IsNotExist(err) {
+		return removed, err
+	}
+	for _, blob := range blobs {
+		// Temporary files may be being written by another process.
+		if strings.HasPrefix(blob.Name(), tempFilePrefix) {
+			continue
+		}
+		if !used[blob.Name()] {
+			if err := os.Remove(c.blobPath(blob.Name())); err != nil {
+				return removed, err
+			}
+		}
+	}
+	return removed, nil
+}
+
+// Export copies the documents of the cache to dir, at paths mirroring their
+// URLs, so that the relative locations they refer to each other by still
+// resolve, and writes an OASIS XML catalog mapping the URLs to them to
+// dir/catalog.xml, for generating offline with WithCatalog.
+func (c *Cache) Export(dir string) error {
+	entries, err := c.Entries()
+	if err != nil {
+		return err
+	}
+
+	type catalogURI struct {
+		Name string `xml:"name,attr"`
+		URI  string `xml:"uri,attr"`
+	}
+	var cat struct {
+		XMLName xml.Name     `xml:"urn:oasis:names:tc:entity:xmlns:xml:catalog catalog"`
+		URIs    []catalogURI `xml:"uri"`
+	}
+	for _, entry := range entries {
+		doc, err := ioutil.ReadFile(c.blobPath(entry.SHA256))
+		if err != nil {
+			return err
+		}
+		rel, err := exportPath(entry.URL)
+		if err != nil {
+			return err
+		}
+		if err := writeFileAtomic(filepath.Join(dir, filepath.FromSlash(rel)), doc); err != nil {
+			return err
+		}
+		cat.URIs = append(cat.URIs, catalogURI{Name: entry.URL, URI: rel})
+	}
+
+	data, err := xml.MarshalIndent(cat, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, "catalog.xml"), append([]byte(xml.Header), append(data, '\n')...))
+}
+
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// exportPath returns the slash-separated path a document downloaded from
+// rawurl is exported to: its host followed by its path, with its query.
+func exportPath(rawurl string) (string, error) {
+	u, err := url.Parse(rawurl)
+	if err != nil {
+		return "", err
+	}
+
+	p := path.Clean("/" + u.Path)
+	if strings.HasSuffix(u.Path, "/") || p == "/" {
+		p = path.Join(p, "index")
+	}
+	if u.RawQuery != "" {
+		p += "_" + unsafePathChars.ReplaceAllString(u.RawQuery, "_")
+	}
+
+	segments := strings.Split(strings.TrimPrefix(p, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = unsafePathChars.ReplaceAllString(segment, "_")
+	}
+	return unsafePathChars.ReplaceAllString(u.Host, "_") + "/" + strings.Join(segments, "/"), nil
+}
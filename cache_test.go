@@ -0,0 +1,199 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCache_Fetch(t *testing.T) {
+	var requests, revalidations int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			revalidations++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("<schema/>"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "gowsdl-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewCache(dir, time.Hour)
+	for i := 0; i < 2; i++ {
+		doc, err := cache.fetch(server.URL+"/types.xsd", false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(doc) != "<schema/>" {
+			t.Errorf("got %q", doc)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected fresh documents to be read from the cache, got %d requests", requests)
+	}
+
+	// Stale documents are revalidated.
+	cache.TTL = 0
+	doc, err := cache.fetch(server.URL+"/types.xsd", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(doc) != "<schema/>" || revalidations != 1 {
+		t.Errorf("got %q after %d revalidations", doc, revalidations)
+	}
+
+	// Offline, stale documents are used and missing ones fail.
+	if _, err := cache.fetch(server.URL+"/types.xsd", false, true); err != nil {
+		t.Error(err)
+	}
+	if _, err := cache.fetch(server.URL+"/other.xsd", false, true); err == nil {
+		t.Error("expected a document missing from the cache to fail offline")
+	}
+	if requests != 2 {
+		t.Errorf("expected no request offline, got %d requests", requests)
+	}
+
+	entries, err := cache.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].URL != server.URL+"/types.xsd" || entries[0].ETag != `"v1"` || entries[0].Size != 9 {
+		t.Errorf("unexpected entries %+v", entries)
+	}
+
+	// Temporary files, which may be being written, are left alone.
+	if err := ioutil.WriteFile(filepath.Join(dir, "blobs", ".tmp-x"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := cache.Prune(-time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 {
+		t.Errorf("expected the entry to be pruned, got %+v", removed)
+	}
+	if blobs, _ := ioutil.ReadDir(filepath.Join(dir, "blobs")); len(blobs) != 1 || blobs[0].Name() != ".tmp-x" {
+		t.Errorf("expected the documents of pruned entries to be removed, got %d files", len(blobs))
+	}
+}
+
+func TestCache_ServerError(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if requests > 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("<schema/>"))
+	}))
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "gowsdl-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := NewCache(dir, 0)
+	for i := 0; i < 2; i++ {
+		doc, err := cache.fetch(server.URL+"/types.xsd", false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(doc) != "<schema/>" {
+			t.Errorf("got %q", doc)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("expected the stale document to be revalidated, got %d requests", requests)
+	}
+
+	// Documents which aren't cached fail.
+	if _, err := cache.fetch(server.URL+"/other.xsd", false, false); err == nil {
+		t.Error("expected a server error to fail for a document missing from the cache")
+	}
+}
+
+func TestCache_Offline(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("fixtures")))
+	dir, err := ioutil.TempDir("", "gowsdl-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	wsdl := server.URL + "/wsdlimport/service.wsdl"
+	generate := func(opts ...Option) error {
+		g, err := NewGoWSDL(wsdl, "myservice", false, true, opts...)
+		if err != nil {
+			return err
+		}
+		resp, err := g.Start()
+		if err != nil {
+			return err
+		}
+		_, err = getTypeDeclaration(resp, "GetStockResponse")
+		return err
+	}
+
+	cache := NewCache(filepath.Join(dir, "cache"), time.Hour)
+	if err := generate(WithCache(cache)); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	if err := generate(WithOffline()); err == nil || !strings.Contains(err.Error(), "offline") {
+		t.Errorf("expected generating offline without a cache to fail, got %v", err)
+	}
+	if err := generate(WithCache(cache), WithOffline()); err != nil {
+		t.Error(err)
+	}
+
+	// The exported documents resolve through the exported catalog.
+	if err := cache.Export(filepath.Join(dir, "export")); err != nil {
+		t.Fatal(err)
+	}
+	if err := generate(WithCatalog(filepath.Join(dir, "export", "catalog.xml")), WithOffline()); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExportPath(t *testing.T) {
+	tests := []struct {
+		url      string
+		expected string
+	}{
+		{"http://example.org/schemas/order.xsd", "example.org/schemas/order.xsd"},
+		{"http://example.org:8080/", "example.org_8080/index"},
+		{"http://example.org/Service.svc?xsd=xsd0", "example.org/Service.svc_xsd_xsd0"},
+		{"http://example.org/../a b.xsd", "example.org/a_b.xsd"},
+	}
+	for _, test := range tests {
+		actual, err := exportPath(test.url)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if actual != test.expected {
+			t.Errorf("%s: got %s wanted %s", test.url, actual, test.expected)
+		}
+	}
+}
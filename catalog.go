@@ -0,0 +1,225 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// catalogNamespace is the namespace of OASIS XML catalogs.
+const catalogNamespace = "urn:oasis:names:tc:entity:xmlns:xml:catalog"
+
+// catalog maps the URLs of WSDL and XML Schema documents, and the namespaces
+// of the schemas imported without a schemaLocation, to the locations of local
+// copies.
+type catalog struct {
+	exact    map[string]*Location
+	rewrites []catalogRule
+	suffixes []catalogRule
+}
+
+// catalogRule maps the URIs starting or ending with match: a rewrite replaces
+// match by replacement, and a suffix maps them to replacement. replacement is
+// resolved against base.
+type catalogRule struct {
+	match       string
+	replacement string
+	base        *Location
+}
+
+func newCatalog() *catalog {
+	return &catalog{exact: make(map[string]*Location)}
+}
+
+// resolve returns the location mapped to uri, a URL or a namespace, or nil
+// when c doesn't map it. Exact entries come first, then the rewrite and then
+// the suffix matching the most of uri.
+func (c *catalog) resolve(uri string) (*Location, error) {
+	if loc, ok := c.exact[uri]; ok {
+		return loc, nil
+	}
+
+	var rewrite, suffix *catalogRule
+	for i, rule := range c.rewrites {
+		if strings.HasPrefix(uri, rule.match) && (rewrite == nil || len(rule.match) > len(rewrite.match)) {
+			rewrite = &c.rewrites[i]
+		}
+	}
+	if rewrite != nil {
+		return rewrite.base.Parse(rewrite.replacement + strings.TrimPrefix(uri, rewrite.match))
+	}
+	for i, rule := range c.suffixes {
+		if strings.HasSuffix(uri, rule.match) && (suffix == nil || len(rule.match) > len(suffix.match)) {
+			suffix = &c.suffixes[i]
+		}
+	}
+	if suffix != nil {
+		return suffix.base.Parse(suffix.replacement)
+	}
+	return nil, nil
+}
+
+// catalogs are consulted in order, until one of them maps a URI.
+type catalogs []*catalog
+
+func (cs catalogs) resolve(uri string) (*Location, error) {
+	for _, c := range cs {
+		loc, err := c.resolve(uri)
+		if loc != nil || err != nil {
+			return loc, err
+		}
+	}
+	return nil, nil
+}
+
+// locate returns the location of the local copy of the document at loc, or
+// loc itself when the catalogs don't map it.
+func (cs catalogs) locate(loc *Location) (*Location, error) {
+	mapped, err := cs.resolve(loc.String())
+	if mapped == nil || err != nil {
+		return loc, err
+	}
+	return mapped, nil
+}
+
+// xmlCatalogEntry is an entry of an OASIS XML catalog, or one of its groups.
+type xmlCatalogEntry struct {
+	XMLName             xml.Name
+	Base                string            `xml:"http://www.w3.org/XML/1998/namespace base,attr"`
+	SystemID            string            `xml:"systemId,attr"`
+	Name                string            `xml:"name,attr"`
+	URI                 string            `xml:"uri,attr"`
+	SystemIDStartString string            `xml:"systemIdStartString,attr"`
+	URIStartString      string            `xml:"uriStartString,attr"`
+	RewritePrefix       string            `xml:"rewritePrefix,attr"`
+	SystemIDSuffix      string            `xml:"systemIdSuffix,attr"`
+	URISuffix           string            `xml:"uriSuffix,attr"`
+	Catalog             string            `xml:"catalog,attr"`
+	Entries             []xmlCatalogEntry `xml:",any"`
+}
+
+// loadCatalogs reads the OASIS XML catalog at loc, followed by the catalogs
+// it chains to with nextCatalog, which are consulted after it. The system,
+// uri, rewriteSystem, rewriteURI, systemSuffix and uriSuffix entries are
+// supported, system identifiers and URIs alike, while the public and the
+// delegate ones, which don't apply to schema locations, are ignored.
+func (g *GoWSDL) loadCatalogs(loc *Location, loaded map[string]bool) (catalogs, error) {
+	if loaded[loc.String()] {
+		return nil, nil
+	}
+	loaded[loc.String()] = true
+
+	data, err := g.fetchFile(loc)
+	if err != nil {
+		return nil, err
+	}
+
+	root := new(xmlCatalogEntry)
+	if err := xml.Unmarshal(data, root); err != nil {
+		return nil, fmt.Errorf("reading catalog %s: %w", loc, err)
+	}
+	if root.XMLName != (xml.Name{Space: catalogNamespace, Local: "catalog"}) {
+		return nil, fmt.Errorf("%s is not an XML catalog", loc)
+	}
+
+	c := newCatalog()
+	cs := catalogs{c}
+	var next []*Location
+	var add func(entry *xmlCatalogEntry, base *Location) error
+	add = func(entry *xmlCatalogEntry, base *Location) error {
+		if entry.Base != "" {
+			var err error
+			if base, err = base.Parse(entry.Base); err != nil {
+				return err
+			}
+		}
+		if entry.XMLName.Space != catalogNamespace {
+			return nil
+		}
+
+		switch entry.XMLName.Local {
+		case "catalog", "group":
+			for i := range entry.Entries {
+				if err := add(&entry.Entries[i], base); err != nil {
+					return err
+				}
+			}
+		case "system", "uri":
+			id := entry.SystemID
+			if entry.XMLName.Local == "uri" {
+				id = entry.Name
+			}
+			if _, ok := c.exact[id]; ok {
+				break
+			}
+			target, err := base.Parse(entry.URI)
+			if err != nil {
+				return err
+			}
+			c.exact[id] = target
+		case "rewriteSystem":
+			c.rewrites = append(c.rewrites, catalogRule{match: entry.SystemIDStartString, replacement: entry.RewritePrefix, base: base})
+		case "rewriteURI":
+			c.rewrites = append(c.rewrites, catalogRule{match: entry.URIStartString, replacement: entry.RewritePrefix, base: base})
+		case "systemSuffix":
+			c.suffixes = append(c.suffixes, catalogRule{match: entry.SystemIDSuffix, replacement: entry.URI, base: base})
+		case "uriSuffix":
+			c.suffixes = append(c.suffixes, catalogRule{match: entry.URISuffix, replacement: entry.URI, base: base})
+		case "nextCatalog":
+			target, err := base.Parse(entry.Catalog)
+			if err != nil {
+				return err
+			}
+			next = append(next, target)
+		}
+		return nil
+	}
+	if err := add(root, loc); err != nil {
+		return nil, fmt.Errorf("reading catalog %s: %w", loc, err)
+	}
+
+	for _, target := range next {
+		chained, err := g.loadCatalogs(target, loaded)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, chained...)
+	}
+	return cs, nil
+}
+
+// readCatalogs reads the catalogs mapping documents and namespaces to local
+// copies: the locations given with WithLocation, consulted first, and the XML
+// catalogs given with WithCatalog, in order.
+func (g *GoWSDL) readCatalogs() error {
+	g.catalogs = nil
+	if len(g.locations) > 0 {
+		c := newCatalog()
+		for uri, file := range g.locations {
+			loc, err := ParseLocation(file)
+			if err != nil {
+				return err
+			}
+			c.exact[uri] = loc
+		}
+		g.catalogs = append(g.catalogs, c)
+	}
+
+	loaded := make(map[string]bool)
+	for _, file := range g.catalogFiles {
+		loc, err := ParseLocation(file)
+		if err != nil {
+			return err
+		}
+		cs, err := g.loadCatalogs(loc, loaded)
+		if err != nil {
+			return err
+		}
+		g.catalogs = append(g.catalogs, cs...)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestCatalog_Resolve(t *testing.T) {
+	base, err := ParseLocation("/catalogs/catalog.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newCatalog()
+	c.exact["http://example.org/schemas/exact.xsd"] = &Location{f: "/exact/exact.xsd"}
+	c.rewrites = []catalogRule{
+		{match: "http://example.org/", replacement: "org/", base: base},
+		{match: "http://example.org/schemas/", replacement: "schemas/", base: base},
+	}
+	c.suffixes = []catalogRule{
+		{match: "/common.xsd", replacement: "common.xsd", base: base},
+	}
+
+	tests := []struct {
+		uri      string
+		expected string
+	}{
+		{"http://example.org/schemas/exact.xsd", "/exact/exact.xsd"},
+		{"http://example.org/schemas/types/order.xsd", "/catalogs/schemas/types/order.xsd"},
+		{"http://example.org/other.xsd", "/catalogs/org/other.xsd"},
+		{"http://example.net/v2/common.xsd", "/catalogs/common.xsd"},
+		{"http://example.net/other.xsd", ""},
+	}
+	for _, test := range tests {
+		loc, err := c.resolve(test.uri)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		actual := ""
+		if loc != nil {
+			actual = loc.String()
+		}
+		if actual != filepath.FromSlash(test.expected) {
+			t.Errorf("%s: got %q wanted %q", test.uri, actual, test.expected)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+)
+
+// choiceType describes a choice generated as a type holding exactly one of
+// its branches.
+type choiceType struct {
+	// GoName is the name of the Go type generated for the choice, Owner the
+	// one of the type holding it.
+	GoName string
+	Owner  string
+	// Repeated tells whether the choice may occur more than once.
+	Repeated bool
+	// MinOccurs and MaxOccurs are the occurrences of the choice in its owner.
+	MinOccurs, MaxOccurs string
+	// Branches are the branches of the choice, in document order.
+	Branches []*choiceBranch
+
+	group *XSDModelGroup
+}
+
+// choiceBranch describes a branch of a choice.
+type choiceBranch struct {
+	// Name is the name of the accessors of the branch.
+	Name string
+	// Element is the element of a branch made of a single element, and
+	// ElementName its local name.
+	Element     *XSDElement
+	ElementName string
+	// GoName is the name of the struct holding the elements of the branches
+	// made of a group of elements, which Particles are.
+	GoName    string
+	Particles []*XSDParticle
+}
+
+// choiceMethods are the names of the methods of generated choice types, which
+// branches can't be named after.
+var choiceMethods = map[string]bool{"Branch": true, "MarshalXML": true, "UnmarshalXML": true}
+
+// collectChoiceTypes returns the choices generated as types, keyed by their
+// model group. Since encoding/xml hands the elements a struct doesn't know to
+// a single field, the choices of a type are only generated as types when the
+// type holds one choice, and neither wildcards nor substitution groups, be it
+// itself or through the types it extends.
+func (g *GoWSDL) collectChoiceTypes(uniqueName func(*goPackage, string) string) map[*XSDModelGroup]*choiceType {
+	c := &choiceCollector{
+		g:          g,
+		uniqueName: uniqueName,
+		choices:    make(map[*XSDModelGroup]*choiceType),
+		catchers:   make(map[*XSDComplexType]int),
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			if g.soapArrays[ct] == nil {
+				c.collect(schema, ct, 0)
+			}
+		}
+		for _, elm := range schema.Elements {
+			if elm.Type == "" && elm.ComplexType != nil {
+				c.collect(schema, elm.ComplexType, 0)
+			}
+		}
+		for _, t := range g.schemaHoistedTypes(schema) {
+			if t.ComplexType != nil {
+				c.collect(schema, t.ComplexType, 0)
+			}
+		}
+	}
+
+	return c.choices
+}
+
+type choiceCollector struct {
+	g          *GoWSDL
+	uniqueName func(*goPackage, string) string
+	choices    map[*XSDModelGroup]*choiceType
+	// catchers counts the fields of the complex types catching the elements
+	// they don't know.
+	catchers map[*XSDComplexType]int
+}
+
+// collect generates the choice of the complex type ct of schema as a type if
+// possible, and returns the number of fields of ct catching the elements it
+// doesn't know.
+func (c *choiceCollector) collect(schema *XSDSchema, ct *XSDComplexType, depth uint8) int {
+	if n, ok := c.catchers[ct]; ok {
+		return n
+	}
+	// Types extending themselves are reported by the traverser.
+	c.catchers[ct] = 0
+
+	var catchers int
+	if base := ct.ComplexContent.Extension.Base; base != "" && depth < maxRecursion {
+		if baseSchema, baseType := c.g.findComplexType(resolveQName(base, schema.Xmlns)); baseType != nil {
+			catchers = c.collect(baseSchema, baseType, depth+1)
+		}
+	}
+
+	var candidates []*choiceType
+	leaves := appendParticles(nil, contentParticle(ct), "", "", func(mg *XSDModelGroup, minOccurs, maxOccurs string) bool {
+		choice := c.g.newChoiceType(schema, mg, minOccurs, maxOccurs)
+		if choice != nil {
+			candidates = append(candidates, choice)
+		}
+		return choice != nil
+	})
+	for _, leaf := range leaves {
+		if c.g.catchesElements(schema, leaf) {
+			catchers++
+		}
+	}
+
+	if len(candidates) == 1 && catchers == 0 {
+		c.name(schema, ct, candidates[0])
+		c.choices[candidates[0].group] = candidates[0]
+		catchers++
+	}
+	c.catchers[ct] = catchers
+	return catchers
+}
+
+// name names the choice of the complex type ct of schema, and its branches.
+func (c *choiceCollector) name(schema *XSDSchema, ct *XSDComplexType, choice *choiceType) {
+	kind, local := typeSymbol, ct.Name
+	if local == "" {
+		for _, elm := range schema.Elements {
+			if elm.ComplexType == ct {
+				kind, local = elementSymbol, elm.Name
+			}
+		}
+	}
+	_, owner, _ := c.g.symbols.lookup(kind, xml.Name{Space: schema.TargetNamespace, Local: local})
+	if c.g.hoisted != nil {
+		if goName, ok := c.g.hoisted.names[ct]; ok {
+			owner = goName
+		}
+	}
+
+	pkg := c.g.packageOf(schema.TargetNamespace)
+	choice.Owner = owner
+	choice.GoName = c.uniqueName(pkg, owner+"Choice")
+	for _, branch := range choice.Branches {
+		if branch.Element == nil {
+			branch.GoName = c.uniqueName(pkg, choice.GoName+branch.Name)
+		}
+	}
+}
+
+// newChoiceType returns the description of the choice mg of schema occurring
+// between minOccurs and maxOccurs times, or nil when it can't be generated as a type: when
+// its branches hold wildcards, substitution groups or anonymous types, or
+// share element names. Repeated choices must be made of single elements,
+// since their repetitions are decoded element by element.
+func (g *GoWSDL) newChoiceType(schema *XSDSchema, mg *XSDModelGroup, minOccurs, maxOccurs string) *choiceType {
+	choice := &choiceType{
+		Repeated:  repeated(maxOccurs),
+		MinOccurs: minOccurs,
+		MaxOccurs: maxOccurs,
+		group:     mg,
+	}
+
+	names := make(map[string]bool)
+	for _, p := range mg.Particles {
+		leaves := flattenParticles(p)
+		if len(leaves) == 0 {
+			return nil
+		}
+		for _, leaf := range leaves {
+			if leaf.Element == nil || g.catchesElements(schema, leaf) {
+				return nil
+			}
+			name := elementLocalName(leaf.Element)
+			if names[name] {
+				return nil
+			}
+			names[name] = true
+		}
+
+		branch := &choiceBranch{Name: makePublic(normalize(elementLocalName(leaves[0].Element)))}
+		if choiceMethods[branch.Name] {
+			return nil
+		}
+		if p.Element != nil {
+			if p.Element.Ref == "" && p.Element.Type == "" && p.Element.SimpleType == nil {
+				return nil
+			}
+			branch.Element = p.Element
+			branch.ElementName = elementLocalName(p.Element)
+		} else {
+			if choice.Repeated {
+				return nil
+			}
+			branch.Particles = leaves
+		}
+		choice.Branches = append(choice.Branches, branch)
+	}
+
+	return choice
+}
+
+// catchesElements tells whether the leaf particle of a complex type of
+// schema is generated as a field catching the elements the type doesn't know.
+func (g *GoWSDL) catchesElements(schema *XSDSchema, leaf *XSDParticle) bool {
+	switch {
+	case leaf.Any != nil:
+		return true
+	case leaf.Element != nil:
+		return leaf.Element.Ref != "" && g.substitutionGroupOf(leaf.Element.Ref, schema.Xmlns) != nil
+	}
+	return false
+}
+
+// contentParticle returns the particle of the content of ct, as generated.
+func contentParticle(ct *XSDComplexType) *XSDParticle {
+	switch {
+	case ct.ComplexContent.Extension.Base != "":
+		return ct.ComplexContent.Extension.Particle()
+	case ct.SimpleContent.Extension.Base != "":
+		return nil
+	case ct.ComplexContent.Restriction.Base != "":
+		return ct.ComplexContent.Restriction.Particle()
+	case ct.SimpleContent.Restriction.Base != "":
+		return nil
+	}
+	return ct.Particle()
+}
+
+func elementLocalName(elm *XSDElement) string {
+	if elm.Ref != "" {
+		return removeNS(elm.Ref)
+	}
+	return elm.Name
+}
+
+// particles returns the leaves of the particle p as flattenParticles does,
+// except for the choices generated as types, which are leaves themselves.
+func (g *GoWSDL) particles(p *XSDParticle) []*XSDParticle {
+	return appendParticles(nil, p, "", "", func(mg *XSDModelGroup, _, _ string) bool {
+		return g.choiceTypes[mg] != nil
+	})
+}
+
+// findChoiceType returns the description of the choice mg when it is
+// generated as a type.
+func (g *GoWSDL) findChoiceType(mg *XSDModelGroup) *choiceType {
+	return g.choiceTypes[mg]
+}
+
+// choiceOf returns the description of the choice of ct generated as a type,
+// if any.
+func (g *GoWSDL) choiceOf(ct *XSDComplexType) *choiceType {
+	if len(g.choiceTypes) == 0 {
+		return nil
+	}
+	for _, leaf := range g.particles(contentParticle(ct)) {
+		if leaf.ModelGroup != nil {
+			return g.choiceTypes[leaf.ModelGroup]
+		}
+	}
+	return nil
+}
+
+// branchType returns the Go type of the value of branch, in the schema being
+// generated.
+func (g *GoWSDL) branchType(branch *choiceBranch) string {
+	elm := branch.Element
+	if elm == nil {
+		return branch.GoName
+	}
+
+	xmlns := g.currentSchema.Xmlns
+	var goType string
+	switch {
+	case elm.Ref != "":
+		goType = g.goElementType(elm.Ref, xmlns, elm.Nillable, g.currentImports)
+	case elm.Type != "":
+		goType = g.goFieldType(elm.Type, xmlns, elm.Nillable, g.currentImports)
+	case g.inlineType(elm.SimpleType) != "":
+		goType = g.inlineType(elm.SimpleType)
+	default:
+		return g.goType(elm.SimpleType.Restriction.Base, xmlns, false, g.currentImports)
+	}
+	if repeated(elm.MaxOccurs) {
+		return "[]" + goType
+	}
+	return goType
+}
@@ -0,0 +1,83 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	gen "github.com/hooklift/gowsdl"
+)
+
+const cacheUsage = `Usage: %[1]s cache [options] list
+       %[1]s cache [options] prune
+       %[1]s cache [options] export directory
+
+list    lists the documents of the download cache
+prune   removes the documents not validated for longer than -older-than
+export  copies the documents to directory, along with an XML catalog mapping
+        their URLs to them, for generating offline with -catalog
+
+`
+
+// runCache runs the cache subcommand, managing the download cache.
+func runCache(args []string) {
+	fs := flag.NewFlagSet("cache", flag.ExitOnError)
+	dir := fs.String("dir", gen.DefaultCacheDir(), "Directory of the download cache")
+	olderThan := fs.Duration("older-than", 30*24*time.Hour, "Age of the documents prune removes")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, cacheUsage, os.Args[0])
+		fs.PrintDefaults()
+	}
+
+	// Options may come before and after the command.
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	command := fs.Arg(0)
+	fs.Parse(fs.Args()[1:])
+
+	cache := gen.NewCache(*dir, gen.DefaultCacheTTL)
+	switch command {
+	case "list":
+		entries, err := cache.Entries()
+		if err != nil {
+			log.Fatalln(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "URL\tSIZE\tVALIDATED\tSHA256")
+		for _, entry := range entries {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%.12s\n", entry.URL, entry.Size, entry.Validated.Format(time.RFC3339), entry.SHA256)
+		}
+		w.Flush()
+	case "prune":
+		removed, err := cache.Prune(*olderThan)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		for _, entry := range removed {
+			log.Println("Removed", "file", entry.URL)
+		}
+	case "export":
+		if fs.NArg() != 1 {
+			fs.Usage()
+			os.Exit(2)
+		}
+		if err := cache.Export(fs.Arg(0)); err != nil {
+			log.Fatalln(err)
+		}
+		log.Println("Exported", "catalog", filepath.Join(fs.Arg(0), "catalog.xml"))
+	default:
+		fs.Usage()
+		os.Exit(2)
+	}
+}
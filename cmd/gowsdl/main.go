@@ -16,16 +16,38 @@ Usage: gowsdl [options] myservice.wsdl
 
 Features
 
-Supports only Document/Literal wrapped services, which are WS-I (http://ws-i.org/) compliant.
+Supports Document/Literal wrapped services, which are WS-I (http://ws-i.org/) compliant, as well as RPC/Literal and RPC/Encoded (SOAP encoding) services.
 
 Attempts to generate idiomatic Go code as much as possible.
 
-Supports WSDL 1.1, XML Schema 1.0, SOAP 1.1.
+Supports WSDL 1.1, XML Schema 1.0, SOAP 1.1 and SOAP 1.2.
 
 Resolves external XML Schemas
 
+Reads remote WSDL and XML Schema documents from local copies mapped by OASIS XML catalogs with -catalog, or by URL and namespace with -location.
+
+Generates the types of each XML namespace in their own package with -package-per-namespace.
+
+Decodes derived types according to xsi:type, and substitution group members according to their element name, with -polymorphic.
+
+Generates choices as types holding exactly one of their branches with -choices.
+
+Rejects values not enumerated by the schema when decoding enumerations with -strict-enums.
+
+Generates the anonymous types of local elements and attributes as named types with -hoist-types.
+
+Reports the types referring to themselves, and the references they do it through, with -verbose.
+
+Gives unique Go names to the types, elements and attributes whose names collide, and reports them with -naming-report.
+
 Supports providing WSDL HTTP URL as well as a local WSDL file.
 
+Caches the downloaded documents, revalidating them once older than -cache-ttl, and generates from the cache alone with -offline. The cache command lists, prunes and exports the cache:
+
+	gowsdl cache list
+	gowsdl cache prune -older-than 720h
+	gowsdl cache export ./schemas
+
 Not supported
 
 UDDI.
@@ -54,6 +76,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	gen "github.com/hooklift/gowsdl"
 )
@@ -70,16 +93,70 @@ var outFile = flag.String("o", "myservice.go", "File where the generated code wi
 var dir = flag.String("d", "./", "Directory under which package directory will be created")
 var insecure = flag.Bool("i", false, "Skips TLS Verification")
 var makePublic = flag.Bool("make-public", true, "Make the generated types public/exported")
+var importPath = flag.String("package-per-namespace", "", "Generate the types of each namespace in their own package, below the main package whose `import path` is given")
+var choices = flag.Bool("choices", false, "Generate choices as types holding exactly one of their branches, instead of optional fields")
+var strictEnums = flag.Bool("strict-enums", false, "Generate enumerations whose decoding fails on values not enumerated by the schema")
+var hoistTypes = flag.Bool("hoist-types", false, "Generate the anonymous types of local elements and attributes as named types, named after the path of their element")
+var polymorphic = flag.Bool("polymorphic", false, "Generate interfaces for abstract and extended types and holders for substitution groups, decoded according to xsi:type and element names")
+var verbose = flag.Bool("verbose", false, "Log the analyses of the schemas, such as the types referring to themselves")
+var initialisms = flag.Bool("initialisms", false, "Spell the common initialisms of the generated names in upper case, such as OrderID for orderId")
+var namingReport = flag.String("naming-report", "", "Write the Go names given to the types, elements and attributes of the schemas, and the ones renamed to avoid collisions, to `file`")
+var cacheDir = flag.String("cache-dir", gen.DefaultCacheDir(), "Directory of the cache of the downloaded documents, managed with the cache command")
+var cacheTTL = flag.Duration("cache-ttl", gen.DefaultCacheTTL, "How long cached documents are used before asking their server whether they changed")
+var noCache = flag.Bool("no-cache", false, "Download the documents without caching them")
+var offline = flag.Bool("offline", false, "Fail instead of downloading the documents which aren't cached")
+var nsPrefixes = make(namespaceValues)
+var nsPackages = make(namespaceValues)
+var locations = make(namespaceValues)
+var catalogFiles catalogValues
+
+// namespaceValues collects repeated namespace=value flags.
+type namespaceValues map[string]string
+
+func (p namespaceValues) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p namespaceValues) Set(value string) error {
+	i := strings.LastIndex(value, "=")
+	if i <= 0 || i == len(value)-1 {
+		return fmt.Errorf("expected namespace=value, got %q", value)
+	}
+	p[value[:i]] = value[i+1:]
+	return nil
+}
+
+// catalogValues collects repeated catalog flags.
+type catalogValues []string
+
+func (c *catalogValues) String() string {
+	return strings.Join(*c, ",")
+}
+
+func (c *catalogValues) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
 
 func init() {
+	flag.Var(nsPrefixes, "ns-prefix", "Use `namespace=Prefix` for the Go names of a namespace colliding with another one (repeatable)")
+	flag.Var(nsPackages, "ns-package", "Use `namespace=importPath` as the package of a namespace with -package-per-namespace (repeatable)")
+	flag.Var(&catalogFiles, "catalog", "Read the WSDL and XML Schema documents, and the schemas of namespaces imported without a location, from the local copies the OASIS XML catalog `file` maps them to (repeatable)")
+	flag.Var(locations, "location", "Read the document at the URL, or the schema of the namespace imported without a location, given by `uri=file` from file (repeatable)")
+
 	log.SetFlags(0)
 	log.SetOutput(os.Stdout)
 	log.SetPrefix("🍀  ")
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "cache" {
+		runCache(os.Args[2:])
+		return
+	}
+
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options] myservice.wsdl\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] myservice.wsdl\n       %s cache [options] list|prune|export\n", os.Args[0], os.Args[0])
 		flag.PrintDefaults()
 	}
 
@@ -103,7 +180,56 @@ func main() {
 	}
 
 	// load wsdl
-	gowsdl, err := gen.NewGoWSDL(wsdlPath, *pkg, *insecure, *makePublic)
+	var opts []gen.Option
+	for ns, prefix := range nsPrefixes {
+		opts = append(opts, gen.WithNamespacePrefix(ns, prefix))
+	}
+	if *importPath != "" {
+		opts = append(opts, gen.WithPackagePerNamespace(*importPath))
+	}
+	for ns, importPath := range nsPackages {
+		opts = append(opts, gen.WithNamespacePackage(ns, importPath))
+	}
+	if *polymorphic {
+		opts = append(opts, gen.WithPolymorphicTypes())
+	}
+	if *choices {
+		opts = append(opts, gen.WithChoiceTypes())
+	}
+	if *strictEnums {
+		opts = append(opts, gen.WithStrictEnums())
+	}
+	if *hoistTypes {
+		opts = append(opts, gen.WithHoistedTypes())
+	}
+	if *verbose {
+		opts = append(opts, gen.WithVerbose())
+	}
+	for _, file := range catalogFiles {
+		opts = append(opts, gen.WithCatalog(file))
+	}
+	for uri, file := range locations {
+		opts = append(opts, gen.WithLocation(uri, file))
+	}
+	if !*noCache {
+		opts = append(opts, gen.WithCache(gen.NewCache(*cacheDir, *cacheTTL)))
+	}
+	if *offline {
+		opts = append(opts, gen.WithOffline())
+	}
+	if *initialisms {
+		opts = append(opts, gen.WithInitialisms())
+	}
+	if *namingReport != "" {
+		report, err := os.Create(*namingReport)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer report.Close()
+		opts = append(opts, gen.WithNamingReport(report))
+	}
+
+	gowsdl, err := gen.NewGoWSDL(wsdlPath, *pkg, *insecure, *makePublic, opts...)
 	if err != nil {
 		log.Fatalln(err)
 	}
@@ -157,5 +283,35 @@ func main() {
 	}
 	serverFile.Write(serverSource)
 
+	// namespace packages
+	for key, code := range gocode {
+		if !strings.HasPrefix(key, "package:") {
+			continue
+		}
+		pkgDir := filepath.Join(pkg, filepath.FromSlash(strings.TrimPrefix(key, "package:")))
+		writePackage(pkgDir, filepath.Base(pkgDir)+".go", code)
+	}
+
 	log.Println("Done 👍")
 }
+
+// writePackage formats and writes the code of a namespace package.
+func writePackage(dir, name string, code []byte) {
+	err := os.MkdirAll(dir, 0744)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	file, err := os.Create(filepath.Join(dir, name))
+	if err != nil {
+		log.Fatalln(err)
+	}
+	defer file.Close()
+
+	source, err := format.Source(code)
+	if err != nil {
+		file.Write(code)
+		log.Fatalln(err)
+	}
+	file.Write(source)
+}
@@ -0,0 +1,99 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strings"
+)
+
+const soapEncNamespace = "http://schemas.xmlsoap.org/soap/encoding/"
+
+// soapArray describes a complex type restricting soapenc:Array, which is
+// generated as a slice of its items.
+type soapArray struct {
+	ItemType      string
+	ItemNamespace string
+	ItemName      string
+}
+
+// usesSOAPEncoding reports whether any binding operation follows the SOAP
+// encoding rules.
+func (g *GoWSDL) usesSOAPEncoding() bool {
+	for _, binding := range g.wsdl.Binding {
+		for _, op := range binding.Operations {
+			if isEncodedOperation(op) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// collectSOAPArrays returns the SOAP-ENC array types of all schemas.
+func (g *GoWSDL) collectSOAPArrays() map[*XSDComplexType]*soapArray {
+	arrays := make(map[*XSDComplexType]*soapArray)
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			if a := g.soapArrayOf(schema, ct); a != nil {
+				arrays[ct] = a
+			}
+		}
+	}
+
+	return arrays
+}
+
+func (g *GoWSDL) soapArrayOf(schema *XSDSchema, ct *XSDComplexType) *soapArray {
+	restriction := ct.ComplexContent.Restriction
+	prefix, local := splitQName(restriction.Base)
+	if local != "Array" || schema.Xmlns[prefix] != soapEncNamespace {
+		return nil
+	}
+
+	// The item type is given by wsdl:arrayType="xsd:string[]", or by the
+	// element of the sequence the restriction declares.
+	var itemType string
+	for _, attr := range restriction.Attributes {
+		if attr.ArrayType != "" {
+			itemType = attr.ArrayType
+			if i := strings.Index(itemType, "["); i >= 0 {
+				itemType = itemType[:i]
+			}
+		}
+	}
+	if elements := particleElements(restriction.Particle()); itemType == "" && len(elements) == 1 {
+		itemType = elements[0].Type
+	}
+	if itemType == "" {
+		return &soapArray{
+			ItemType:      "AnyType",
+			ItemNamespace: xmlschema11,
+			ItemName:      "anyType",
+		}
+	}
+
+	prefix, local = splitQName(itemType)
+	ns := schema.TargetNamespace
+	if prefix != "" {
+		ns = schema.Xmlns[prefix]
+	}
+	return &soapArray{
+		ItemType:      g.goType(itemType, schema.Xmlns, false, g.packageOf(schema.TargetNamespace).imports),
+		ItemNamespace: ns,
+		ItemName:      local,
+	}
+}
+
+func (g *GoWSDL) findSOAPArray(ct *XSDComplexType) *soapArray {
+	return g.soapArrays[ct]
+}
+
+func splitQName(name string) (prefix, local string) {
+	if i := strings.Index(name, ":"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
@@ -0,0 +1,241 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"log"
+	"regexp"
+	"strings"
+)
+
+// enumType describes a simple type restricted by enumerations, generated as
+// a type with a constant for each of its values, or a variable when values of
+// its type can't be constants.
+type enumType struct {
+	GoName string
+	// BaseType is the Go type the values are of, once the simple types
+	// restricted are resolved.
+	BaseType string
+	// Constant tells whether the values are constants.
+	Constant bool
+	Values   []*enumValue
+	// Strict tells whether decoding unknown values fails.
+	Strict bool
+}
+
+// enumValue describes an enumerated value.
+type enumValue struct {
+	// Name is the name of the constant of the value, and Literal the Go
+	// expression of the value.
+	Name    string
+	Literal string
+	Doc     string
+}
+
+// enumOf returns the description of the simple type st of the schema being
+// generated, whose Go type is name, when it is restricted by enumerations.
+// Enumerations of types whose values Go can't spell, such as dates, are left
+// aside.
+func (g *GoWSDL) enumOf(name string, st *XSDSimpleType) *enumType {
+	if len(st.Restriction.Enumeration) == 0 {
+		return nil
+	}
+
+	base := g.baseGoType(st.Restriction.Base, g.currentSchema.Xmlns, 0)
+	enum := g.newEnumType(name, name, base, st.Restriction.Enumeration)
+	if enum != nil {
+		enum.Strict = g.strictEnums
+	}
+	return enum
+}
+
+// contentEnumOf returns the description of the enumerations restricting the
+// simple content of the complex type name, whose values are of the type of
+// the content.
+func (g *GoWSDL) contentEnumOf(name string, res XSDRestriction) *enumType {
+	if len(res.Enumeration) == 0 {
+		return nil
+	}
+
+	base := g.baseGoType(g.simpleContentBase(res.Base), g.currentSchema.Xmlns, 0)
+	return g.newEnumType(g.simpleContentType(res.Base), name, base, res.Enumeration)
+}
+
+// newEnumType returns the description of the enumerations of the Go type
+// goName, whose values are of the Go type base and named after prefix, or nil
+// when values of base can't be written in Go.
+func (g *GoWSDL) newEnumType(goName, prefix, base string, enumerations []XSDRestrictionValue) *enumType {
+	if !enumerable(base, enumerations) {
+		log.Printf("[WARN] Enumerations of type %s are not generated: values of type %s can't be written in Go", goName, base)
+		return nil
+	}
+
+	enum := &enumType{GoName: goName, BaseType: base, Constant: constantType(base)}
+	// The values are named once, the first time their type is generated,
+	// unique in its package.
+	names, named := g.names.enums[prefix]
+	for i, value := range enumerations {
+		literal, _ := valueLiteral(value.Value, base)
+		if !enum.Constant && goName != base {
+			literal = goName + "(" + literal + ")"
+		}
+
+		name := prefix + g.makePublicFn(replaceReservedWords(value.Value))
+		if named {
+			name = names[i]
+		} else {
+			name = g.names.declareType(g.currentImports.pkg, "enumeration", prefix+"="+value.Value, name)
+			names = append(names, name)
+		}
+		enum.Values = append(enum.Values, &enumValue{
+			Name:    name,
+			Literal: literal,
+			Doc:     value.Doc,
+		})
+	}
+	g.names.enums[prefix] = names
+	return enum
+}
+
+// baseGoType returns the Go type of the values of the simple type xsdType,
+// whose prefix is declared by xmlns, once the simple types it restricts are
+// resolved. Lists and unions are the types generated for them.
+func (g *GoWSDL) baseGoType(xsdType string, xmlns map[string]string, depth uint8) string {
+	goType := g.goType(xsdType, xmlns, false, g.currentImports)
+	if !strings.HasPrefix(goType, "*") {
+		return goType
+	}
+
+	schema, st := g.findSimpleType(resolveQName(xsdType, xmlns))
+	switch {
+	case st == nil || depth >= maxRecursion:
+		return goType
+	case isList(st) || isUnion(st):
+		return removePointerFromType(goType)
+	}
+	return g.baseGoType(st.Restriction.Base, schema.Xmlns, depth+1)
+}
+
+// constantType tells whether Go constants may be of the Go type goType.
+func constantType(goType string) bool {
+	switch goType {
+	case "string", "bool", "byte", "int", "int8", "int16", "int32", "int64",
+		"uint", "uint8", "uint16", "uint32", "uint64", "float32", "float64",
+		"AnyURI", "NCName":
+		return true
+	}
+	return false
+}
+
+var (
+	integerLiteral = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	decimalLiteral = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+)
+
+// valueLiteral returns the Go expression of the lexical value of a value of
+// the Go type goType, and false when values of goType can't be written in Go
+// or value is not one.
+func valueLiteral(value, goType string) (string, bool) {
+	text := strings.TrimSpace(value)
+	switch {
+	case constantType(goType):
+		return goLiteral(value, goType), true
+	case goType == "soap.Integer" && integerLiteral.MatchString(text):
+		return `soap.MustParseInteger("` + text + `")`, true
+	case goType == "soap.Decimal" && decimalLiteral.MatchString(text):
+		return `soap.MustParseDecimal("` + text + `")`, true
+	}
+	return "", false
+}
+
+// enumerable tells whether the enumerated values of the Go type goType can
+// all be written in Go.
+func enumerable(goType string, enumerations []XSDRestrictionValue) bool {
+	for _, value := range enumerations {
+		if _, ok := valueLiteral(value.Value, goType); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// encodingMethods describes the encoding methods a type declared as a type
+// of the soap package, or as a type derived from one, forwards to the type it
+// is declared as: Go types don't inherit the methods of the types they are
+// declared as.
+type encodingMethods struct {
+	// GoName is the name of the type, and Type the type it is declared as.
+	GoName, Type string
+	// Text, JSON and XML tell whether the type has the methods of
+	// encoding.TextMarshaler, json.Marshaler or xml.Marshaler and
+	// xml.MarshalerAttr, and their Unmarshaler counterparts, and String
+	// whether it has a String method.
+	Text, JSON, XML, String bool
+	// Strict tells whether the type is a strict enumeration, whose decoding
+	// methods are generated along with its values instead.
+	Strict bool
+}
+
+// soapEncodingMethods are the encoding methods of the types of the soap
+// package built-in types are generated as.
+var soapEncodingMethods = map[string]encodingMethods{
+	"soap.Decimal":     {Text: true, JSON: true, String: true},
+	"soap.Integer":     {Text: true, JSON: true, String: true},
+	"soap.Duration":    {Text: true, String: true},
+	"soap.GYear":       {Text: true, String: true},
+	"soap.GYearMonth":  {Text: true, String: true},
+	"soap.GMonthDay":   {Text: true, String: true},
+	"soap.GDay":        {Text: true, String: true},
+	"soap.GMonth":      {Text: true, String: true},
+	"soap.QName":       {XML: true, String: true},
+	"soap.XSDDateTime": {XML: true},
+	"soap.XSDDate":     {XML: true},
+	"soap.XSDTime":     {XML: true},
+}
+
+// encodingMethodsOf returns the encoding methods the type name, declared as
+// the Go type of the simple type xsdType of the schema being generated,
+// forwards, or nil when xsdType is not a type of the soap package nor a union,
+// nor derived from one.
+func (g *GoWSDL) encodingMethodsOf(name, xsdType string) *encodingMethods {
+	methods, ok := soapEncodingMethods[g.baseGoType(xsdType, g.currentSchema.Xmlns, 0)]
+	switch {
+	case ok:
+	case g.restrictsUnion(xsdType, g.currentSchema.Xmlns, 0):
+		// Union types are written as text.
+		methods = encodingMethods{Text: true}
+	default:
+		return nil
+	}
+	methods.GoName = name
+	methods.Type = removePointerFromType(g.goType(xsdType, g.currentSchema.Xmlns, false, g.currentImports))
+	return &methods
+}
+
+// simpleTypeEncodingMethods returns the encoding methods the simple type st
+// of the schema being generated, whose Go type is name, forwards to the type
+// it restricts, as encodingMethodsOf does.
+func (g *GoWSDL) simpleTypeEncodingMethods(name string, st *XSDSimpleType) *encodingMethods {
+	methods := g.encodingMethodsOf(name, st.Restriction.Base)
+	if methods != nil && g.strictEnums && len(st.Restriction.Enumeration) > 0 {
+		methods.Strict = enumerable(g.baseGoType(st.Restriction.Base, g.currentSchema.Xmlns, 0), st.Restriction.Enumeration)
+	}
+	return methods
+}
+
+// attributeType returns the Go type of the field of the attribute attr of
+// the schema being generated. Optional attributes of the types of the soap
+// package whose zero value is a value are pointers, omitted when nil, and
+// required attributes of declared types are held by value.
+func (g *GoWSDL) attributeType(attr *XSDAttribute) string {
+	goType := g.goType(attr.Type, g.currentSchema.Xmlns, false, g.currentImports)
+	if attr.Use == "required" {
+		return strings.TrimPrefix(goType, "*")
+	}
+	if methods, ok := soapEncodingMethods[goType]; ok && methods.Text {
+		return "*" + goType
+	}
+	return goType
+}
@@ -122,7 +122,7 @@ type SOAPEnvelopeRequest struct {
 type SOAPBodyRequest struct {
 	XMLName xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Body"`
 
-	GetInfo *GetInfo `xml:,omitempty`
+	GetInfo *GetInfo `xml:",omitempty"`
 }
 
 type SOAPEnvelopeResponse struct {
@@ -10,13 +10,14 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"net"
 	"net/http"
-	"os"
-	"path/filepath"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"text/template"
@@ -35,14 +36,51 @@ type GoWSDL struct {
 	makePublicFn          func(string) string
 	wsdl                  *WSDL
 	resolvedXSDExternals  map[string]bool
+	resolvedWSDLImports   map[string]bool
 	currentRecursionLevel uint8
 	currentNamespace      string
+	currentSchema         *XSDSchema
+	currentImports        *importSet
+	serverImports         *importSet
+	symbols               *symbolTable
+	nsPrefixes            map[string]string
+	importPath            string
+	nsImportPaths         map[string]string
+	packages              []*goPackage
+	nsPackages            map[string]*goPackage
+	rpcWrappers           map[string]*rpcWrapper
+	soapArrays            map[*XSDComplexType]*soapArray
+	soapEncoded           bool
+	polymorphic           bool
+	polymorphicTypes      map[*XSDComplexType]*polymorphicType
+	substitutionGroups    map[xml.Name]*substitutionGroup
+	choices               bool
+	choiceTypes           map[*XSDModelGroup]*choiceType
+	inlineLists           *inlineLists
+	unionTypes            map[*XSDSimpleType]*unionType
+	inlineUnions          map[*goPackage][]*simpleTypeDecl
+	strictEnums           bool
+	registeredElements    bool
+	hoist                 bool
+	hoisted               *hoistedTypes
+	verbose               bool
+	names                 *namer
+	initialisms           bool
+	namingReport          io.Writer
+	catalogFiles          []string
+	locations             map[string]string
+	catalogs              catalogs
+	namespaceImports      []string
+	cache                 *Cache
+	offline               bool
 }
 
-// Method setNS sets (and returns) the currently active XML namespace.
-func (g *GoWSDL) setNS(ns string) string {
-	g.currentNamespace = ns
-	return ns
+// Method setSchema sets the schema being generated and returns its target
+// namespace, which becomes the currently active XML namespace.
+func (g *GoWSDL) setSchema(schema *XSDSchema) string {
+	g.currentSchema = schema
+	g.currentNamespace = schema.TargetNamespace
+	return schema.TargetNamespace
 }
 
 // Method setNS returns the currently active XML namespace.
@@ -50,32 +88,24 @@ func (g *GoWSDL) getNS() string {
 	return g.currentNamespace
 }
 
-var cacheDir = filepath.Join(os.TempDir(), "gowsdl-cache")
-
-func init() {
-	err := os.MkdirAll(cacheDir, 0700)
-	if err != nil {
-		log.Println("Create cache directory", "error", err)
-		os.Exit(1)
-	}
-}
-
 var timeout = time.Duration(30 * time.Second)
 
 func dialTimeout(network, addr string) (net.Conn, error) {
 	return net.DialTimeout(network, addr, timeout)
 }
 
-func downloadFile(url string, ignoreTLS bool) ([]byte, error) {
+func newHTTPClient(ignoreTLS bool) *http.Client {
 	tr := &http.Transport{
 		TLSClientConfig: &tls.Config{
 			InsecureSkipVerify: ignoreTLS,
 		},
 		Dial: dialTimeout,
 	}
-	client := &http.Client{Transport: tr}
+	return &http.Client{Transport: tr}
+}
 
-	resp, err := client.Get(url)
+func downloadFile(url string, ignoreTLS bool) ([]byte, error) {
+	resp, err := newHTTPClient(ignoreTLS).Get(url)
 	if err != nil {
 		return nil, err
 	}
@@ -93,8 +123,145 @@ func downloadFile(url string, ignoreTLS bool) ([]byte, error) {
 	return data, nil
 }
 
+// Option configures the WSDL generator.
+type Option func(*GoWSDL)
+
+// WithNamespacePrefix sets the prefix given to the Go names of the types and
+// elements of namespace ns when their names collide with the ones of another
+// namespace. By default the prefix is derived from the namespace.
+func WithNamespacePrefix(ns, prefix string) Option {
+	return func(g *GoWSDL) {
+		g.nsPrefixes[ns] = prefix
+	}
+}
+
+// WithPackagePerNamespace generates the types of each XSD target namespace in
+// a package of its own, in a subdirectory of the main package, whose import
+// path is importPath. The main package, holding the operations, imports them.
+func WithPackagePerNamespace(importPath string) Option {
+	return func(g *GoWSDL) {
+		g.importPath = strings.TrimSuffix(importPath, "/")
+	}
+}
+
+// WithNamespacePackage sets the import path of the package the types of
+// namespace ns are generated in, when generating a package per namespace.
+// Import paths outside of the main package refer to existing packages, which
+// aren't generated.
+func WithNamespacePackage(ns, importPath string) Option {
+	return func(g *GoWSDL) {
+		g.nsImportPaths[ns] = importPath
+	}
+}
+
+// WithPolymorphicTypes generates an interface for each abstract or extended
+// complex type, implemented by the types derived from it. Fields of these
+// types hold any of them, decoded and encoded according to xsi:type.
+// References to the head of a substitution group likewise hold any element
+// of the group, chosen by its name.
+func WithPolymorphicTypes() Option {
+	return func(g *GoWSDL) {
+		g.polymorphic = true
+	}
+}
+
+// WithChoiceTypes generates the choices of complex types as types holding
+// exactly one of their branches, set and read through accessors, instead of
+// optional fields. Encoding a choice holding no branch fails, and so does
+// decoding elements of several branches of a choice.
+func WithChoiceTypes() Option {
+	return func(g *GoWSDL) {
+		g.choices = true
+	}
+}
+
+// WithStrictEnums generates decoding methods for enumerated simple types
+// which fail on values not enumerated by their schema, instead of keeping
+// them.
+func WithStrictEnums() Option {
+	return func(g *GoWSDL) {
+		g.strictEnums = true
+	}
+}
+
+// WithHoistedTypes generates the anonymous types of local elements and
+// attributes as named types, instead of anonymous structs and fields of their
+// base type, so that their values can be built and carry methods. They are
+// named after the path of their element, such as OrderLineItem for the
+// lineItem element of Order.
+func WithHoistedTypes() Option {
+	return func(g *GoWSDL) {
+		g.hoist = true
+	}
+}
+
+// WithVerbose logs the analyses of the schemas made while generating, such as
+// the types found to refer to themselves and the references they do it
+// through.
+func WithVerbose() Option {
+	return func(g *GoWSDL) {
+		g.verbose = true
+	}
+}
+
+// WithInitialisms spells the common initialisms of the names of the generated
+// types and fields in upper case, as Go names do, such as OrderID for the
+// orderId element.
+func WithInitialisms() Option {
+	return func(g *GoWSDL) {
+		g.initialisms = true
+	}
+}
+
+// WithNamingReport writes a report of the Go names given to the types,
+// elements and attributes of the schemas to w, once the code is generated:
+// a line for each of them, telling the name it would have had when it was
+// renamed to avoid a collision.
+func WithNamingReport(w io.Writer) Option {
+	return func(g *GoWSDL) {
+		g.namingReport = w
+	}
+}
+
+// WithCatalog reads the WSDL and XML Schema documents mapped by the OASIS XML
+// catalog file from the local copies it maps them to, such as when the
+// generator can't reach the network. The schemas imported without a
+// schemaLocation are looked up by namespace in the catalog. Catalogs are
+// consulted in the order they are given.
+func WithCatalog(file string) Option {
+	return func(g *GoWSDL) {
+		g.catalogFiles = append(g.catalogFiles, file)
+	}
+}
+
+// WithLocation reads the document at the URL uri, or the schema of the
+// namespace uri when it is imported without a schemaLocation, from file. The
+// locations given this way take precedence over the catalogs.
+func WithLocation(uri, file string) Option {
+	return func(g *GoWSDL) {
+		g.locations[uri] = file
+	}
+}
+
+// WithCache keeps the documents downloaded in cache, and reads them from it
+// while they are fresh, so that generating again doesn't download them again.
+func WithCache(cache *Cache) Option {
+	return func(g *GoWSDL) {
+		g.cache = cache
+	}
+}
+
+// WithOffline fails to read the documents which would have to be downloaded:
+// only local files, and the documents of the cache given with WithCache,
+// however old, are read.
+func WithOffline() Option {
+	return func(g *GoWSDL) {
+		g.offline = true
+	}
+}
+
 // NewGoWSDL initializes WSDL generator.
-func NewGoWSDL(file, pkg string, ignoreTLS bool, exportAllTypes bool) (*GoWSDL, error) {
+func NewGoWSDL(file, pkg string, ignoreTLS bool, exportAllTypes bool, opts ...Option) (*GoWSDL, error) {
 	file = strings.TrimSpace(file)
 	if file == "" {
 		return nil, errors.New("WSDL file is required to generate Go proxy")
@@ -114,16 +281,28 @@ func NewGoWSDL(file, pkg string, ignoreTLS bool, exportAllTypes bool) (*GoWSDL,
 		return nil, err
 	}
 
-	return &GoWSDL{
-		loc:          r,
-		pkg:          pkg,
-		ignoreTLS:    ignoreTLS,
-		makePublicFn: makePublicFn,
-	}, nil
+	g := &GoWSDL{
+		loc:           r,
+		pkg:           pkg,
+		ignoreTLS:     ignoreTLS,
+		makePublicFn:  makePublicFn,
+		nsPrefixes:    make(map[string]string),
+		nsImportPaths: make(map[string]string),
+		locations:     make(map[string]string),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+
+	return g, nil
 }
 
 // Start initiaties the code generation process by starting two goroutines: one
 // to generate types and another one to generate operations.
+//
+// When generating a package per namespace, the code of each namespace package
+// is returned under the key "package:" followed by its directory, relative to
+// the main package.
 func (g *GoWSDL) Start() (map[string][]byte, error) {
 	gocode := make(map[string][]byte)
 
@@ -136,8 +315,35 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 	for _, schema := range g.wsdl.Types.Schemas {
 		newTraverser(schema, g.wsdl.Types.Schemas).traverse()
 	}
+	graph := newTypeGraph(g.wsdl.Types.Schemas)
+	graph.breakDerivationCycles()
+	if g.verbose {
+		graph.reportRecursiveTypes()
+	}
+	g.assignPackages()
+	g.names = newNamer(g.initialisms)
+	g.reserveOperationNames()
+	g.symbols = g.buildSymbolTable()
+	g.rpcWrappers = g.collectRPCWrappers()
+	g.soapArrays = g.collectSOAPArrays()
+	g.soapEncoded = g.usesSOAPEncoding()
+	uniqueName := g.uniqueTypeNames()
+	if g.hoist {
+		g.hoisted = g.collectHoistedTypes(uniqueName)
+	}
+	g.inlineLists = g.collectInlineLists(uniqueName)
+	g.unionTypes, g.inlineUnions = g.collectUnionTypes(uniqueName)
+	if g.polymorphic {
+		g.polymorphicTypes = g.collectPolymorphicTypes(uniqueName)
+		g.substitutionGroups = g.collectSubstitutionGroups(uniqueName)
+	}
+	if g.choices {
+		g.choiceTypes = g.collectChoiceTypes(uniqueName)
+	}
+	g.registeredElements = g.decodesWildcards()
 
 	var wg sync.WaitGroup
+	packages := make(map[string][]byte)
 
 	wg.Add(1)
 	go func() {
@@ -148,6 +354,16 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 		if err != nil {
 			log.Println("genTypes", "error", err)
 		}
+
+		for _, pkg := range g.packages[1:] {
+			if pkg.external {
+				continue
+			}
+			packages[pkg.dir], err = g.genPackage(pkg)
+			if err != nil {
+				log.Println("genPackage", pkg.path, "error", err)
+			}
+		}
 	}()
 
 	wg.Add(1)
@@ -186,6 +402,16 @@ func (g *GoWSDL) Start() (map[string][]byte, error) {
 
 	gocode["server_wsdl"] = []byte("var wsdl = `" + string(g.rawWSDL) + "`")
 
+	if g.namingReport != nil {
+		if err := g.names.writeNamingReport(g.namingReport); err != nil {
+			return nil, err
+		}
+	}
+
+	for dir, code := range packages {
+		gocode["package:"+dir] = code
+	}
+
 	return gocode, nil
 }
 
@@ -194,13 +420,33 @@ func (g *GoWSDL) fetchFile(loc *Location) (data []byte, err error) {
 		log.Println("Reading", "file", loc.f)
 		data, err = ioutil.ReadFile(loc.f)
 	} else {
-		log.Println("Downloading", "file", loc.u.String())
-		data, err = downloadFile(loc.u.String(), g.ignoreTLS)
+		data, err = g.download(loc.u.String())
 	}
 	return
 }
 
+// download returns the document at url, through the cache when there is one.
+func (g *GoWSDL) download(url string) ([]byte, error) {
+	if g.cache != nil {
+		return g.cache.fetch(url, g.ignoreTLS, g.offline)
+	}
+	if g.offline {
+		return nil, fmt.Errorf("%s can't be downloaded offline", url)
+	}
+	log.Println("Downloading", "file", url)
+	return downloadFile(url, g.ignoreTLS)
+}
+
 func (g *GoWSDL) unmarshal() error {
+	if err := g.readCatalogs(); err != nil {
+		return err
+	}
+	loc, err := g.catalogs.locate(g.loc)
+	if err != nil {
+		return err
+	}
+	g.loc = loc
+
 	data, err := g.fetchFile(g.loc)
 	if err != nil {
 		return err
@@ -220,55 +466,193 @@ func (g *GoWSDL) unmarshal() error {
 		}
 	}
 
-	return nil
+	g.resolvedWSDLImports = map[string]bool{g.loc.String(): true}
+	if err := g.resolveWSDLImports(g.wsdl, g.loc); err != nil {
+		return err
+	}
+	return g.resolveNamespaceImports()
 }
 
-func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
-	download := func(base *Location, ref string) error {
-		location, err := base.Parse(ref)
+// resolveWSDLImports fetches the documents imported by wsdl and merges their
+// definitions into g.wsdl. Imports are followed recursively; a document
+// imported more than once, including through an import cycle, is merged once.
+func (g *GoWSDL) resolveWSDLImports(wsdl *WSDL, loc *Location) error {
+	for _, impt := range wsdl.Imports {
+		var location *Location
+		var err error
+		if impt.Location == "" {
+			location, err = g.catalogs.resolve(impt.Namespace)
+			if location == nil && err == nil {
+				log.Printf("[WARN] Don't know where to find WSDL for %s", impt.Namespace)
+				continue
+			}
+		} else if location, err = loc.Parse(impt.Location); err == nil {
+			location, err = g.catalogs.locate(location)
+		}
+		if err != nil {
+			return err
+		}
+		if g.resolvedWSDLImports[location.String()] {
+			continue
+		}
+		g.resolvedWSDLImports[location.String()] = true
+
+		data, err := g.fetchFile(location)
 		if err != nil {
 			return err
 		}
-		schemaKey := location.String()
-		if g.resolvedXSDExternals[location.String()] {
-			return nil
+
+		root, err := rootElementName(data)
+		if err != nil {
+			return fmt.Errorf("importing %s: %w", location, err)
 		}
-		if g.resolvedXSDExternals == nil {
-			g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
+
+		// wsdl:import may also point at a plain XML schema.
+		if root.Local == "schema" {
+			schema := new(XSDSchema)
+			if err := xml.Unmarshal(data, schema); err != nil {
+				return err
+			}
+			if err := g.resolveXSDExternals(schema, location); err != nil {
+				return err
+			}
+			g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, schema)
+			continue
 		}
-		g.resolvedXSDExternals[schemaKey] = true
 
-		var data []byte
-		if data, err = g.fetchFile(location); err != nil {
+		imported := new(WSDL)
+		if err := xml.Unmarshal(data, imported); err != nil {
 			return err
 		}
 
-		newschema := new(XSDSchema)
+		for _, schema := range imported.Types.Schemas {
+			if err := g.resolveXSDExternals(schema, location); err != nil {
+				return err
+			}
+		}
+		g.mergeWSDL(imported)
 
-		err = xml.Unmarshal(data, newschema)
-		if err != nil {
+		if err := g.resolveWSDLImports(imported, location); err != nil {
 			return err
 		}
+	}
 
-		if (len(newschema.Includes) > 0 || len(newschema.Imports) > 0) &&
-			maxRecursion > g.currentRecursionLevel {
-			g.currentRecursionLevel++
+	return nil
+}
 
-			err = g.resolveXSDExternals(newschema, location)
-			if err != nil {
-				return err
+// mergeWSDL adds the definitions of an imported WSDL to g.wsdl. The QNames
+// the imported definitions refer to each other and to the types by are
+// rewritten with the prefixes of g.wsdl.
+func (g *GoWSDL) mergeWSDL(imported *WSDL) {
+	requalify := func(name *string) {
+		*name = g.requalify(*name, imported.Xmlns)
+	}
+	requalifyHeaders := func(headers []*WSDLSOAPHeader) {
+		for _, header := range headers {
+			requalify(&header.Message)
+			for _, fault := range header.HeadersFault {
+				requalify(&fault.Message)
 			}
 		}
+	}
+	requalifyOperations := func(ops []*WSDLOperation) {
+		for _, op := range ops {
+			requalify(&op.Input.Message)
+			requalifyHeaders(op.Input.SOAPHeader)
+			requalifyHeaders(op.Input.SOAP12Header)
+			requalify(&op.Output.Message)
+			requalifyHeaders(op.Output.SOAPHeader)
+			requalifyHeaders(op.Output.SOAP12Header)
+			for _, fault := range op.Faults {
+				requalify(&fault.Message)
+			}
+		}
+	}
+
+	for _, msg := range imported.Messages {
+		for _, part := range msg.Parts {
+			requalify(&part.Type)
+			requalify(&part.Element)
+		}
+	}
+	for _, portType := range imported.PortTypes {
+		requalifyOperations(portType.Operations)
+	}
+	for _, binding := range imported.Binding {
+		requalify(&binding.Type)
+		requalifyOperations(binding.Operations)
+	}
+	for _, service := range imported.Service {
+		for _, port := range service.Ports {
+			requalify(&port.Binding)
+		}
+	}
 
-		g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newschema)
+	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, imported.Types.Schemas...)
+	g.wsdl.Messages = append(g.wsdl.Messages, imported.Messages...)
+	g.wsdl.PortTypes = append(g.wsdl.PortTypes, imported.PortTypes...)
+	g.wsdl.Binding = append(g.wsdl.Binding, imported.Binding...)
+	g.wsdl.Service = append(g.wsdl.Service, imported.Service...)
+}
 
-		return nil
+// requalify returns name, whose prefix is declared by xmlns, with a prefix
+// bound to the same namespace in g.wsdl, declaring one if needed.
+func (g *GoWSDL) requalify(name string, xmlns map[string]string) string {
+	if name == "" {
+		return name
+	}
+	qname := resolveQName(name, xmlns)
+	if qname.Space == "" {
+		return name
+	}
+
+	prefixes := make([]string, 0, len(g.wsdl.Xmlns))
+	for prefix := range g.wsdl.Xmlns {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	for _, prefix := range prefixes {
+		if prefix != "" && g.wsdl.Xmlns[prefix] == qname.Space {
+			return prefix + ":" + qname.Local
+		}
+	}
+
+	prefix, _ := splitQName(name)
+	for i := 1; prefix == "" || g.wsdl.Xmlns[prefix] != ""; i++ {
+		prefix = "ns" + strconv.Itoa(i)
+	}
+	g.wsdl.Xmlns[prefix] = qname.Space
+	return prefix + ":" + qname.Local
+}
+
+// rootElementName returns the name of the root element of an XML document.
+func rootElementName(data []byte) (xml.Name, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return xml.Name{}, err
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
+	download := func(base *Location, ref string) error {
+		location, err := base.Parse(ref)
+		if err != nil {
+			return err
+		}
+		return g.readSchema(location)
 	}
 
 	for _, impts := range schema.Imports {
-		// Download the file only if we have a hint in the form of schemaLocation.
+		// Imports without a schemaLocation hint are resolved by namespace
+		// once all the documents are read.
 		if impts.SchemaLocation == "" {
-			log.Printf("[WARN] Don't know where to find XSD for %s", impts.Namespace)
+			g.namespaceImports = append(g.namespaceImports, impts.Namespace)
 			continue
 		}
 
@@ -286,9 +670,149 @@ func (g *GoWSDL) resolveXSDExternals(schema *XSDSchema, loc *Location) error {
 	return nil
 }
 
+// readSchema reads the schema at location, or at the location the catalogs
+// map it to, along with the schemas it includes and imports, unless it was
+// read already.
+func (g *GoWSDL) readSchema(location *Location) error {
+	location, err := g.catalogs.locate(location)
+	if err != nil {
+		return err
+	}
+
+	schemaKey := location.String()
+	if g.resolvedXSDExternals[schemaKey] {
+		return nil
+	}
+	if g.resolvedXSDExternals == nil {
+		g.resolvedXSDExternals = make(map[string]bool, maxRecursion)
+	}
+	g.resolvedXSDExternals[schemaKey] = true
+
+	data, err := g.fetchFile(location)
+	if err != nil {
+		return err
+	}
+
+	newschema := new(XSDSchema)
+
+	err = xml.Unmarshal(data, newschema)
+	if err != nil {
+		return err
+	}
+
+	if (len(newschema.Includes) > 0 || len(newschema.Imports) > 0) &&
+		maxRecursion > g.currentRecursionLevel {
+		g.currentRecursionLevel++
+
+		err = g.resolveXSDExternals(newschema, location)
+		if err != nil {
+			return err
+		}
+	}
+
+	g.wsdl.Types.Schemas = append(g.wsdl.Types.Schemas, newschema)
+
+	return nil
+}
+
+// resolveNamespaceImports reads the schemas of the namespaces imported without
+// a schemaLocation which none of the schemas read so far declares, such as the
+// ones of other inline schemas of the WSDL, from the locations the catalogs
+// map the namespaces to.
+func (g *GoWSDL) resolveNamespaceImports() error {
+	warned := make(map[string]bool)
+	for len(g.namespaceImports) > 0 {
+		ns := g.namespaceImports[0]
+		g.namespaceImports = g.namespaceImports[1:]
+		if g.declaresNamespace(ns) || warned[ns] {
+			continue
+		}
+
+		location, err := g.catalogs.resolve(ns)
+		if err != nil {
+			return err
+		}
+		if location == nil {
+			log.Printf("[WARN] Don't know where to find XSD for %s", ns)
+			warned[ns] = true
+			continue
+		}
+		if err := g.readSchema(location); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// declaresNamespace tells whether one of the schemas read declares ns.
+func (g *GoWSDL) declaresNamespace(ns string) bool {
+	for _, schema := range g.wsdl.Types.Schemas {
+		if schema.TargetNamespace == ns {
+			return true
+		}
+	}
+	return false
+}
+
 func (g *GoWSDL) genTypes() ([]byte, error) {
-	funcMap := template.FuncMap{
-		"toGoType":                 toGoType,
+	data := new(bytes.Buffer)
+	err := g.genSchemaTypes(data, g.packages[0])
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl := template.Must(template.New("rpc").Funcs(g.typesFuncMap()).Parse(rpcTmpl))
+	err = tmpl.Execute(data, g.sortedRPCWrappers())
+	if err != nil {
+		return nil, err
+	}
+
+	return data.Bytes(), nil
+}
+
+// genPackage generates the code of a namespace package, header included.
+func (g *GoWSDL) genPackage(pkg *goPackage) ([]byte, error) {
+	types := new(bytes.Buffer)
+	err := g.genSchemaTypes(types, pkg)
+	if err != nil {
+		return nil, err
+	}
+
+	data := new(bytes.Buffer)
+	tmpl := template.Must(template.New("header").Parse(headerTmpl))
+	err = tmpl.Execute(data, headerData{Package: pkg.name, Imports: pkg.imports.list(), TypesOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	data.Write(types.Bytes())
+
+	return data.Bytes(), nil
+}
+
+// genSchemaTypes generates the types of the schemas of pkg.
+func (g *GoWSDL) genSchemaTypes(data *bytes.Buffer, pkg *goPackage) error {
+	g.currentImports = pkg.imports
+
+	tmpl := template.Must(template.New("types").Funcs(g.typesFuncMap()).Parse(typesTmpl))
+	return tmpl.Execute(data, &WSDLType{Schemas: pkg.schemas})
+}
+
+func (g *GoWSDL) typesFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"toGoType": func(xsdType string, nillable bool) string {
+			return g.goType(xsdType, g.currentSchema.Xmlns, nillable, g.currentImports)
+		},
+		"elementFieldType":   g.elementFieldType,
+		"attributeOmitEmpty": attributeOmitEmpty,
+		"toGoElementType": func(ref string, nillable bool) string {
+			return g.goElementType(ref, g.currentSchema.Xmlns, nillable, g.currentImports)
+		},
+		"typeName": func(name string) string {
+			return g.goTypeName(typeSymbol, name)
+		},
+		"elementName": func(name string) string {
+			return g.goTypeName(elementSymbol, name)
+		},
 		"stripns":                  stripns,
 		"replaceReservedWords":     replaceReservedWords,
 		"replaceAttrReservedWords": replaceAttrReservedWords,
@@ -300,18 +824,57 @@ func (g *GoWSDL) genTypes() ([]byte, error) {
 		"goString":                 goString,
 		"findNameByType":           g.findNameByType,
 		"removePointerFromType":    removePointerFromType,
-		"setNS":                    g.setNS,
+		"setSchema":                g.setSchema,
 		"getNS":                    g.getNS,
+		"findSOAPArray":            g.findSOAPArray,
+		"soapEncoded":              func() bool { return g.soapEncoded },
+		"restrictionAttributes":    g.restrictionAttributes,
+		"simpleContentType":        g.simpleContentType,
+		"facetsDoc":                facetsDoc,
+		"particles":                g.particles,
+		"isList":                   isList,
+		"listItemType":             func(st *XSDSimpleType) string { return g.listItemType(st, g.currentSchema.Xmlns, 0) },
+		"listOf":                   g.listOf,
+		"inlineType":               g.inlineType,
+		"inlineLists":              g.packageInlineLists,
+		"isUnion":                  isUnion,
+		"isMixed":                  g.isMixed,
+		"unionOf":                  g.unionOf,
+		"inlineUnions":             g.packageInlineUnions,
+		"simpleTypeDecl": func(goName string, st *XSDSimpleType) *simpleTypeDecl {
+			return &simpleTypeDecl{GoName: goName, XSDSimpleType: st}
+		},
+		"repeated":            repeated,
+		"occursType":          occursType,
+		"omitEmpty":           omitEmpty,
+		"findPolymorphicType": g.findPolymorphicType,
+		"ancestorMethods":     g.ancestorMethods,
+		"toGoGroupType": func(ref string) string {
+			return g.goGroupType(ref, g.currentSchema.Xmlns, g.currentImports)
+		},
+		"findSubstitutionGroup":     g.findSubstitutionGroup,
+		"registersElement":          g.registersElement,
+		"anyElementType":            anyElementType,
+		"hoistedType":               g.hoistedType,
+		"hoistedTypes":              g.schemaHoistedTypes,
+		"findChoiceType":            g.findChoiceType,
+		"choiceOf":                  g.choiceOf,
+		"branchType":                g.branchType,
+		"validateType":              g.validateType,
+		"validateBranch":            g.validateBranch,
+		"validateSimpleType":        g.validateSimpleType,
+		"validateElementType":       g.validateElementType,
+		"enumOf":                    g.enumOf,
+		"contentEnumOf":             g.contentEnumOf,
+		"encodingMethods":           g.encodingMethodsOf,
+		"simpleTypeEncodingMethods": g.simpleTypeEncodingMethods,
+		"attributeType":             g.attributeType,
+		"fieldName":                 g.fieldName,
+		"jsonName":                  g.jsonName,
+		"fieldPath":                 g.fieldPath,
+		"beginStruct":               g.beginStruct,
+		"endStruct":                 g.endStruct,
 	}
-
-	data := new(bytes.Buffer)
-	tmpl := template.Must(template.New("types").Funcs(funcMap).Parse(typesTmpl))
-	err := tmpl.Execute(data, g.wsdl.Types)
-	if err != nil {
-		return nil, err
-	}
-
-	return data.Bytes(), nil
 }
 
 func (g *GoWSDL) genOperations() ([]byte, error) {
@@ -323,7 +886,9 @@ func (g *GoWSDL) genOperations() ([]byte, error) {
 		"makePublic":           g.makePublicFn,
 		"makePrivate":          makePrivate,
 		"findType":             g.findType,
+		"qualify":              g.qualifyFn(g.packages[0].imports),
 		"findSOAPAction":       g.findSOAPAction,
+		"findCallOptions":      g.findCallOptions,
 		"findServiceAddress":   g.findServiceAddress,
 	}
 
@@ -338,12 +903,14 @@ func (g *GoWSDL) genOperations() ([]byte, error) {
 }
 
 func (g *GoWSDL) genServer() ([]byte, error) {
+	g.serverImports = newImportSet(g.packages[0])
 	funcMap := template.FuncMap{
 		"toGoType":             toGoType,
 		"stripns":              stripns,
 		"replaceReservedWords": replaceReservedWords,
 		"makePublic":           g.makePublicFn,
 		"findType":             g.findType,
+		"qualify":              g.qualifyFn(g.serverImports),
 		"findSOAPAction":       g.findSOAPAction,
 		"findServiceAddress":   g.findServiceAddress,
 	}
@@ -371,7 +938,7 @@ func (g *GoWSDL) genHeader() ([]byte, error) {
 
 	data := new(bytes.Buffer)
 	tmpl := template.Must(template.New("header").Funcs(funcMap).Parse(headerTmpl))
-	err := tmpl.Execute(data, g.pkg)
+	err := tmpl.Execute(data, headerData{Package: g.pkg, Imports: g.packages[0].imports.list()})
 	if err != nil {
 		return nil, err
 	}
@@ -391,7 +958,7 @@ func (g *GoWSDL) genServerHeader() ([]byte, error) {
 
 	data := new(bytes.Buffer)
 	tmpl := template.Must(template.New("server_header").Funcs(funcMap).Parse(serverHeaderTmpl))
-	err := tmpl.Execute(data, g.pkg)
+	err := tmpl.Execute(data, headerData{Package: g.pkg, Imports: g.serverImports.list()})
 	if err != nil {
 		return nil, err
 	}
@@ -493,30 +1060,56 @@ func goString(s string) string {
 	return strings.Replace(s, "\"", "\\\"", -1)
 }
 
+// xsd2GoTypes maps the built-in types of XML Schema 1.0, lower-cased, to the
+// Go types they are generated as. Integers without bounds and decimals are
+// held by the soap package types, which don't lose precision.
 var xsd2GoTypes = map[string]string{
-	"string":        "string",
-	"token":         "string",
-	"float":         "float32",
-	"double":        "float64",
-	"decimal":       "float64",
-	"integer":       "int32",
-	"int":           "int32",
-	"short":         "int16",
-	"byte":          "int8",
-	"long":          "int64",
-	"boolean":       "bool",
-	"datetime":      "soap.XSDDateTime",
-	"date":          "soap.XSDDate",
-	"time":          "soap.XSDTime",
-	"base64binary":  "[]byte",
-	"hexbinary":     "[]byte",
-	"unsignedint":   "uint32",
-	"unsignedshort": "uint16",
-	"unsignedbyte":  "byte",
-	"unsignedlong":  "uint64",
-	"anytype":       "AnyType",
-	"ncname":        "NCName",
-	"anyuri":        "AnyURI",
+	"anytype":            "AnyType",
+	"anysimpletype":      "string",
+	"string":             "string",
+	"normalizedstring":   "string",
+	"token":              "string",
+	"language":           "string",
+	"name":               "string",
+	"ncname":             "NCName",
+	"id":                 "string",
+	"idref":              "string",
+	"idrefs":             "soap.TokenList",
+	"entity":             "string",
+	"entities":           "soap.TokenList",
+	"nmtoken":            "string",
+	"nmtokens":           "soap.TokenList",
+	"notation":           "string",
+	"qname":              "soap.QName",
+	"anyuri":             "AnyURI",
+	"boolean":            "bool",
+	"float":              "float32",
+	"double":             "float64",
+	"decimal":            "soap.Decimal",
+	"integer":            "soap.Integer",
+	"nonpositiveinteger": "soap.Integer",
+	"negativeinteger":    "soap.Integer",
+	"nonnegativeinteger": "soap.Integer",
+	"positiveinteger":    "soap.Integer",
+	"long":               "int64",
+	"int":                "int32",
+	"short":              "int16",
+	"byte":               "int8",
+	"unsignedlong":       "uint64",
+	"unsignedint":        "uint32",
+	"unsignedshort":      "uint16",
+	"unsignedbyte":       "byte",
+	"duration":           "soap.Duration",
+	"datetime":           "soap.XSDDateTime",
+	"date":               "soap.XSDDate",
+	"time":               "soap.XSDTime",
+	"gyear":              "soap.GYear",
+	"gyearmonth":         "soap.GYearMonth",
+	"gmonthday":          "soap.GMonthDay",
+	"gday":               "soap.GDay",
+	"gmonth":             "soap.GMonth",
+	"base64binary":       "[]byte",
+	"hexbinary":          "[]byte",
 }
 
 func removeNS(xsdType string) string {
@@ -562,8 +1155,20 @@ func removePointerFromType(goType string) string {
 // it works for now and performance doesn't
 // seem critical at this point
 func (g *GoWSDL) findType(message string) string {
+	goType, _ := g.messageType(message)
+	return goType
+}
+
+// messageType returns the Go type of a message along with the package it is
+// generated in, which is nil for built-in types.
+func (g *GoWSDL) messageType(message string) (string, *goPackage) {
 	message = stripns(message)
 
+	// RPC style messages are wrapped in an element generated for them.
+	if w, ok := g.rpcWrappers[message]; ok {
+		return w.Name, g.packages[0]
+	}
+
 	for _, msg := range g.wsdl.Messages {
 		if msg.Name != message {
 			continue
@@ -572,31 +1177,70 @@ func (g *GoWSDL) findType(message string) string {
 		// Assumes document/literal wrapped WS-I
 		if len(msg.Parts) == 0 {
 			// Message does not have parts. This could be a Port
-			// with HTTP binding or SOAP 1.2 binding, which are not currently
-			// supported.
+			// with HTTP binding, which is not currently supported.
 			log.Printf("[WARN] %s message doesn't have any parts, ignoring message...", msg.Name)
 			continue
 		}
 
 		part := msg.Parts[0]
 		if part.Type != "" {
-			return stripns(part.Type)
+			return g.declaredType(part.Type, g.wsdl.Xmlns)
 		}
 
-		elRef := stripns(part.Element)
-
-		for _, schema := range g.wsdl.Types.Schemas {
-			for _, el := range schema.Elements {
-				if strings.EqualFold(elRef, el.Name) {
-					if el.Type != "" {
-						return stripns(el.Type)
-					}
-					return el.Name
+		schema, el := g.findElement(resolveQName(part.Element, g.wsdl.Xmlns))
+		if el != nil {
+			if el.Type != "" {
+				if !strings.HasPrefix(g.goType(el.Type, schema.Xmlns, false, nil), "*") {
+					// built-in type
+					return stripns(el.Type), nil
 				}
+				return g.declaredType(el.Type, schema.Xmlns)
 			}
+			return g.symbols.names[symbolKey{kind: elementSymbol, name: xml.Name{Space: schema.TargetNamespace, Local: el.Name}}], g.packageOf(schema.TargetNamespace)
 		}
 	}
-	return ""
+	return "", nil
+}
+
+// declaredType returns the unqualified Go type of a reference to the XSD type
+// name along with the package it is generated in, which is nil for built-in
+// types.
+func (g *GoWSDL) declaredType(name string, xmlns map[string]string) (string, *goPackage) {
+	goType := g.goType(name, xmlns, false, nil)
+	if !strings.HasPrefix(goType, "*") {
+		return goType, nil
+	}
+
+	decl, _, ok := g.symbols.lookup(typeSymbol, resolveQName(name, xmlns))
+	if !ok {
+		return goType[1:], nil
+	}
+	return goType[1:], g.packageOf(decl.Space)
+}
+
+// qualifyFn returns the template function qualifying the Go type of a message,
+// as returned by findType, with the name of its package.
+func (g *GoWSDL) qualifyFn(imports *importSet) func(message, goType string) string {
+	return func(message, goType string) string {
+		_, pkg := g.messageType(message)
+		return imports.qualify(pkg, goType)
+	}
+}
+
+// findElement returns the global element name refers to and the schema
+// declaring it, or nil when no schema of its namespace declares it.
+func (g *GoWSDL) findElement(name xml.Name) (*XSDSchema, *XSDElement) {
+	for _, schema := range g.wsdl.Types.Schemas {
+		if schema.TargetNamespace != name.Space {
+			continue
+		}
+		for _, el := range schema.Elements {
+			if el.Name == name.Local {
+				return schema, el
+			}
+		}
+	}
+	return nil, nil
 }
 
 // Given a type, check if there's an Element with that type, and return its name.
@@ -604,27 +1248,107 @@ func (g *GoWSDL) findNameByType(name string) string {
 	return newTraverser(nil, g.wsdl.Types.Schemas).findNameByType(name)
 }
 
+// Given an operation and the port type it belongs to, finds the binding
+// operation describing it. When the port type is bound to both SOAP 1.1 and
+// SOAP 1.2, the SOAP 1.1 binding is preferred.
+//
 // TODO(c4milo): Add support for namespaces instead of striping them out
 // TODO(c4milo): improve runtime complexity if performance turns out to be an issue.
-func (g *GoWSDL) findSOAPAction(operation, portType string) string {
+func (g *GoWSDL) findBindingOperation(operation, portType string) (*WSDLBinding, *WSDLOperation) {
+	var soap12Binding *WSDLBinding
+	var soap12Op *WSDLOperation
+
 	for _, binding := range g.wsdl.Binding {
 		if strings.ToUpper(stripns(binding.Type)) != strings.ToUpper(portType) {
 			continue
 		}
 
 		for _, soapOp := range binding.Operations {
-			if soapOp.Name == operation {
-				return soapOp.SOAPOperation.SOAPAction
+			if soapOp.Name != operation {
+				continue
+			}
+			if !isSOAP12Binding(binding) {
+				return binding, soapOp
+			}
+			if soap12Binding == nil {
+				soap12Binding, soap12Op = binding, soapOp
 			}
 		}
 	}
-	return ""
+	return soap12Binding, soap12Op
+}
+
+// findOperationStyle returns the style, "document" or "rpc", of a binding
+// operation. The style of the operation takes precedence over the style of the
+// binding, which defaults to "document".
+func findOperationStyle(binding *WSDLBinding, op *WSDLOperation) string {
+	style := op.SOAPOperation.Style
+	if style == "" {
+		style = op.SOAP12Operation.Style
+	}
+	if style == "" {
+		style = binding.SOAPBinding.Style
+	}
+	if style == "" {
+		style = binding.SOAP12Binding.Style
+	}
+	if style == "" {
+		return "document"
+	}
+	return style
+}
+
+// isSOAP12Binding reports whether binding is a SOAP 1.2 binding.
+func isSOAP12Binding(binding *WSDLBinding) bool {
+	return binding.SOAPBinding.Transport == "" && binding.SOAP12Binding.Transport != ""
+}
+
+func (g *GoWSDL) findSOAPAction(operation, portType string) string {
+	binding, soapOp := g.findBindingOperation(operation, portType)
+	if binding == nil {
+		return ""
+	}
+	if isSOAP12Binding(binding) {
+		return soapOp.SOAP12Operation.SOAPAction
+	}
+	return soapOp.SOAPOperation.SOAPAction
+}
+
+// Given an operation, returns the soap.Option expressions its generated
+// client needs to pass along with the call, if any.
+func (g *GoWSDL) findCallOptions(operation, portType string) []string {
+	var opts []string
+
+	binding, op := g.findBindingOperation(operation, portType)
+	if binding != nil && isSOAP12Binding(binding) {
+		opts = append(opts, "soap.WithSOAPVersion(soap.SOAP12)")
+	}
+	if op != nil && isEncodedOperation(op) {
+		opts = append(opts, "soap.WithSOAPEncoding()")
+	}
+	return opts
+}
+
+// isEncodedOperation reports whether the messages of a binding operation, its
+// input or its output, follow the SOAP encoding rules (use="encoded") instead
+// of a schema.
+func isEncodedOperation(op *WSDLOperation) bool {
+	return isEncodedBody(op.Input.SOAPBody, op.Input.SOAP12Body) || isEncodedBody(op.Output.SOAPBody, op.Output.SOAP12Body)
+}
+
+// isEncodedBody reports whether the soap:body of a binding message follows
+// the SOAP encoding rules.
+func isEncodedBody(body, body12 WSDLSOAPBody) bool {
+	return body.Use == "encoded" || body12.Use == "encoded"
 }
 
 func (g *GoWSDL) findServiceAddress(name string) string {
 	for _, service := range g.wsdl.Service {
 		for _, port := range service.Ports {
 			if port.Name == name {
+				if port.SOAPAddress.Location == "" {
+					return port.SOAP12Address.Location
+				}
 				return port.SOAPAddress.Location
 			}
 		}
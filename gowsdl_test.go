@@ -6,6 +6,7 @@ package gowsdl
 
 import (
 	"bytes"
+	"encoding/xml"
 	"errors"
 	"fmt"
 	"go/ast"
@@ -16,6 +17,7 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"strings"
@@ -57,7 +59,7 @@ func TestComplexTypeWithInlineSimpleType(t *testing.T) {
 	expected := `type GetInfo struct {
 	XMLName	xml.Name	` + "`" + `xml:"http://www.mnb.hu/webservices/ GetInfo"` + "`" + `
 
-	Id	string	` + "`" + `xml:"Id,omitempty" json:"Id,omitempty"` + "`" + `
+	Id	string	` + "`" + `xml:"Id" json:"Id"` + "`" + `
 }`
 	if actual != expected {
 		t.Error("got " + actual + " want " + expected)
@@ -84,7 +86,7 @@ func TestAttributeRef(t *testing.T) {
 	Status	[]struct {
 		Value	string  ` + "`" + `xml:",chardata" json:"-,"` + "`" + `
 
-		Code	string	` + "`" + `xml:"http://www.mnb.hu/webservices/ code,attr,omitempty" json:"code,omitempty"` + "`" + `
+		Code	string	` + "`" + `xml:"http://www.mnb.hu/webservices/ code,attr" json:"code"` + "`" + `
 	}	` + "`" + `xml:"status,omitempty" json:"status,omitempty"` + "`" + `
 
 	ResponseCode	string	` + "`" + `xml:"http://www.mnb.hu/webservices/ responseCode,attr,omitempty" json:"responseCode,omitempty"` + "`" + `
@@ -260,27 +262,1996 @@ func TestEnumerationsGeneratedCorrectly(t *testing.T) {
 
 }
 
+func TestBuiltinTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/builtins.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "GetEntry")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type GetEntry struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/ledger GetEntry"` + "`" + `
+
+	Account	AccountNumber	` + "`" + `xml:"account" json:"account"` + "`" + `
+
+	Balance	*Money	` + "`" + `xml:"balance" json:"balance"` + "`" + `
+
+	Fee	*Fee	` + "`" + `xml:"fee,omitempty" json:"fee,omitempty"` + "`" + `
+
+	Sequence	soap.Integer	` + "`" + `xml:"sequence" json:"sequence"` + "`" + `
+
+	Term	soap.Duration	` + "`" + `xml:"term" json:"term"` + "`" + `
+
+	Year	FiscalYear	` + "`" + `xml:"year" json:"year"` + "`" + `
+
+	Month	soap.GYearMonth	` + "`" + `xml:"month" json:"month"` + "`" + `
+
+	Anniversary	soap.GMonthDay	` + "`" + `xml:"anniversary" json:"anniversary"` + "`" + `
+
+	Day	soap.GDay	` + "`" + `xml:"day" json:"day"` + "`" + `
+
+	Closing	soap.GMonth	` + "`" + `xml:"closing" json:"closing"` + "`" + `
+
+	Code	soap.QName	` + "`" + `xml:"code" json:"code"` + "`" + `
+
+	Tags	soap.TokenList	` + "`" + `xml:"tags" json:"tags"` + "`" + `
+
+	Label	string	` + "`" + `xml:"label" json:"label"` + "`" + `
+
+	Note	string	` + "`" + `xml:"note" json:"note"` + "`" + `
+
+	Keywords	*Keywords	` + "`" + `xml:"keywords,omitempty" json:"keywords,omitempty"` + "`" + `
+
+	Id	string	` + "`" + `xml:"http://example.com/ledger id,attr,omitempty" json:"id,omitempty"` + "`" + `
+
+	Ref	string	` + "`" + `xml:"http://example.com/ledger ref,attr,omitempty" json:"ref,omitempty"` + "`" + `
+
+	Refs	soap.TokenList	` + "`" + `xml:"http://example.com/ledger refs,attr,omitempty" json:"refs,omitempty"` + "`" + `
+
+	Total	*soap.Integer	` + "`" + `xml:"http://example.com/ledger total,attr,omitempty" json:"total,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Types derived from the soap types forward their encoding methods, and
+	// the values of their enumerations are variables.
+	for name, expected := range map[string]string{
+		"Money": `type Money struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/ledger balance"` + "`" + `
+
+	Value	Amount	` + "`" + `xml:",chardata" json:"-,"` + "`" + `
+
+	Currency	string	` + "`" + `xml:"http://example.com/ledger currency,attr,omitempty" json:"currency,omitempty"` + "`" + `
+}`,
+		"Fee1_50":      `var Fee1_50 Fee = Fee(soap.MustParseDecimal("1.50"))`,
+		"Rounding0_05": `var Rounding0_05 Amount = Amount(soap.MustParseDecimal("0.05"))`,
+		"AccountNumber12345678901234567890": "var AccountNumber12345678901234567890 AccountNumber = " +
+			`AccountNumber(soap.MustParseInteger("12345678901234567890"))`,
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	actual, err = getFuncDeclaration(resp, "IsValid", "Fee")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (v Fee) IsValid() bool {
+	for _, value := range v.Values() {
+		if soap.Decimal(v).Cmp(soap.Decimal(value)) == 0 {
+			return true
+		}
+	}
+	return false
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	for recv, expected := range map[string]string{
+		"Fee": `func (v *Fee) UnmarshalText(text []byte) error {
+	return (*Amount)(v).UnmarshalText(text)
+}`,
+		"FiscalYear": `func (v *FiscalYear) UnmarshalText(text []byte) error {
+	return (*soap.GYear)(v).UnmarshalText(text)
+}`,
+		"Year": `func (v *Year) UnmarshalText(text []byte) error {
+	return (*FiscalYear)(v).UnmarshalText(text)
+}`,
+		"Term": `func (v *Term) UnmarshalText(text []byte) error {
+	return (*soap.Duration)(v).UnmarshalText(text)
+}`,
+	} {
+		actual, err := getFuncDeclaration(resp, "UnmarshalText", recv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+	if _, err := getFuncDeclaration(resp, "MarshalJSON", "FiscalYear"); err == nil {
+		t.Error("FiscalYear is encoded in JSON as text")
+	}
+
+	// The built-in lists are token lists, restricted as lists.
+	actual, err = getTypeDeclaration(resp, "Keywords")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected = "type Keywords soap.TokenList"; actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "UnmarshalText", "Keywords")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (v *Keywords) UnmarshalText(text []byte) error {
+	return soap.UnmarshalList(text, (*[]string)(v))
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestLists(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/lists.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Inline lists of the same item type share a type named after it.
+	actual, err := getTypeDeclaration(resp, "GetStock")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type GetStock struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/inventory GetStock"` + "`" + `
+
+	Sizes	Sizes	` + "`" + `xml:"sizes" json:"sizes"` + "`" + `
+
+	Colors	ShortPalette	` + "`" + `xml:"colors" json:"colors"` + "`" + `
+
+	Days	Dates	` + "`" + `xml:"days" json:"days"` + "`" + `
+
+	Codes	StringList	` + "`" + `xml:"codes" json:"codes"` + "`" + `
+
+	Weights	*DecimalList	` + "`" + `xml:"weights,omitempty" json:"weights,omitempty"` + "`" + `
+
+	Channels	IntList	` + "`" + `xml:"http://example.com/inventory channels,attr,omitempty" json:"channels,omitempty"` + "`" + `
+
+	Tints	ColorList	` + "`" + `xml:"http://example.com/inventory tints,attr,omitempty" json:"tints,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	for name, expected := range map[string]string{
+		"Palette":      "type Palette []Color",
+		"ShortPalette": "type ShortPalette Palette",
+		"Dates":        "type Dates []soap.XSDDate",
+		"IntList":      "type IntList []int32",
+		"ColorList":    "type ColorList []Color",
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	// Lists, and the types restricting them, are written as text.
+	for recv, expected := range map[string]string{
+		"Sizes": `func (v Sizes) MarshalText() ([]byte, error) {
+	return soap.MarshalList([]int32(v))
+}`,
+		"ShortPalette": `func (v ShortPalette) MarshalText() ([]byte, error) {
+	return soap.MarshalList([]Color(v))
+}`,
+		"IntList": `func (v IntList) MarshalText() ([]byte, error) {
+	return soap.MarshalList([]int32(v))
+}`,
+	} {
+		actual, err := getFuncDeclaration(resp, "MarshalText", recv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	actual, err = getFuncDeclaration(resp, "UnmarshalXMLAttr", "Dates")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (v *Dates) UnmarshalXMLAttr(attr xml.Attr) error {
+	return soap.UnmarshalList([]byte(attr.Value), (*[]soap.XSDDate)(v))
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "Validate", "ColorList")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (v ColorList) Validate() error {
+	var errs soap.Validation
+	errs.Check("", []Color(v))
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestUnions(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/unions.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "Ship")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type Ship struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/shipping Ship"` + "`" + `
+
+	Shipper	Shipper	` + "`" + `xml:"shipper" json:"shipper"` + "`" + `
+
+	Code	*CarrierCode	` + "`" + `xml:"code,omitempty" json:"code,omitempty"` + "`" + `
+
+	Short	*ShortShipper	` + "`" + `xml:"short,omitempty" json:"short,omitempty"` + "`" + `
+
+	Due	DueUnion	` + "`" + `xml:"due" json:"due"` + "`" + `
+
+	Priority	*PriorityUnion	` + "`" + `xml:"http://example.com/shipping priority,attr,omitempty" json:"priority,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Member types are tried in declaration order, and those declared inline
+	// get types of their own.
+	for recv, expected := range map[string]string{
+		"Shipper": `func (Shipper) members() []soap.UnionMember {
+	return []soap.UnionMember{
+		{Name: "Carrier", New: func() interface{} { return new(Carrier) }},
+		{Name: "Int", New: func() interface{} { return new(int32) }},
+	}
+}`,
+		"CarrierCode": `func (CarrierCode) members() []soap.UnionMember {
+	return []soap.UnionMember{
+		{Name: "Member1", New: func() interface{} { return new(CarrierCodeMember1) }},
+		{Name: "Member2", New: func() interface{} { return new(CarrierCodeMember2) }},
+	}
+}`,
+		"PriorityUnion": `func (PriorityUnion) members() []soap.UnionMember {
+	return []soap.UnionMember{
+		{Name: "Int", New: func() interface{} { return new(int32) }},
+		{Name: "Carrier", New: func() interface{} { return new(Carrier) }},
+	}
+}`,
+	} {
+		actual, err := getFuncDeclaration(resp, "members", recv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	for name, expected := range map[string]string{
+		"CarrierCodeMember2": "type CarrierCodeMember2 string",
+		"ShortShipper":       "type ShortShipper Shipper",
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	actual, err = getFuncDeclaration(resp, "Carrier", "Shipper")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (u *Shipper) Carrier() (value Carrier, ok bool) {
+	if u != nil {
+		if v, isMember := u.union.Value(0).(*Carrier); isMember {
+			return *v, true
+		}
+	}
+	return value, false
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "UnmarshalText", "ShortShipper")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (v *ShortShipper) UnmarshalText(text []byte) error {
+	return (*Shipper)(v).UnmarshalText(text)
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestMixedContent(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/mixed.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The attribute wildcard of an attribute group is inlined with its
+	// attributes.
+	actual, err := getTypeDeclaration(resp, "Text")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type Text struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/notes title"` + "`" + `
+
+	B	[]string	` + "`" + `xml:"b,omitempty" json:"b,omitempty"` + "`" + `
+
+	Link	[]*Link	` + "`" + `xml:"link,omitempty" json:"link,omitempty"` + "`" + `
+
+	Id	string	` + "`" + `xml:"http://example.com/notes id,attr,omitempty" json:"id,omitempty"` + "`" + `
+
+	AnyAttrs	soap.AnyAttrs	` + "`" + `xml:",any,attr" json:"anyAttrs,omitempty"` + "`" + `
+
+	Mixed	soap.Mixed	` + "`" + `xml:"-" json:"mixed,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "Link")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type Link struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/notes link"` + "`" + `
+
+	Value	string	` + "`" + `xml:",chardata" json:"-,"` + "`" + `
+
+	AnyAttrs	soap.AnyAttrs	` + "`" + `xml:",any,attr" json:"anyAttrs,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Types extending a mixed type, and global elements of an anonymous mixed
+	// type, keep their text too.
+	for recv, expected := range map[string]string{
+		"Text": `func (t Text) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return soap.MarshalMixed(e, start, &t, t.Mixed)
+}`,
+		"Paragraph": `func (t Paragraph) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return soap.MarshalMixed(e, start, &t, t.Mixed)
+}`,
+		"AddNoteResponse": `func (t AddNoteResponse) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return soap.MarshalMixed(e, start, &t, t.Mixed)
+}`,
+	} {
+		actual, err := getFuncDeclaration(resp, "MarshalXML", recv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	actual, err = getFuncDeclaration(resp, "UnmarshalXML", "Paragraph")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (t *Paragraph) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return soap.UnmarshalMixed(d, start, t, &t.Mixed)
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	if _, err := getFuncDeclaration(resp, "MarshalXML", "AddNote"); err == nil {
+		t.Error("AddNote, which isn't mixed, got a MarshalXML method")
+	}
+}
+
+func TestWildcards(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/any.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The elements wildcards allow are kept whole, and decoded according to
+	// their processContents, strict by default.
+	for name, expected := range map[string]string{
+		"Extensions": `type Extensions struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/orders extensions"` + "`" + `
+
+	Items	[]soap.AnyElement	` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
+}`,
+		"PlaceOrder": `type PlaceOrder struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/orders PlaceOrder"` + "`" + `
+
+	Item	string	` + "`" + `xml:"item" json:"item"` + "`" + `
+
+	Items	[]soap.LaxElement	` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
+
+	Extensions	*Extensions	` + "`" + `xml:"extensions,omitempty" json:"extensions,omitempty"` + "`" + `
+}`,
+		"PlaceOrderResponse": `type PlaceOrderResponse struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/orders PlaceOrderResponse"` + "`" + `
+
+	Id	string	` + "`" + `xml:"id" json:"id"` + "`" + `
+
+	Items	[]soap.StrictElement	` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
+}`,
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	actual, err := getFuncDeclaration(resp, "Validate", "PlaceOrderResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `func (t *PlaceOrderResponse) Validate() error {
+	if t == nil {
+		return nil
+	}
+	var errs soap.Validation
+	errs.Check("Items", t.Items)
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// The global elements are registered for the wildcards to decode them.
+	for _, expected := range []string{
+		`soap.RegisterElement(xml.Name{Space: "http://example.com/orders", Local: "Gift"}, func() interface{} { return new(Gift) })`,
+		`soap.RegisterElement(xml.Name{Space: "http://example.com/orders", Local: "Discount"}, func() interface{} { return new(Discount) })`,
+	} {
+		if !strings.Contains(string(resp["types"]), expected) {
+			t.Errorf("%s is not registered", expected)
+		}
+	}
+}
+
+func TestHoistedTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/hoist.wsdl", "myservice", false, true, WithHoistedTypes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Anonymous types are named after the path of their element, and after
+	// the types already named so when they collide with them.
+	for name, expected := range map[string]string{
+		"Order": `type Order struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/shop Order"` + "`" + `
+
+	Customer	*Customer	` + "`" + `xml:"customer" json:"customer"` + "`" + `
+
+	LineItem	[]*OrderLineItem2	` + "`" + `xml:"lineItem" json:"lineItem"` + "`" + `
+}`,
+		"OrderLineItem2": `type OrderLineItem2 struct {
+	Product	*OrderLineItem2Product	` + "`" + `xml:"product" json:"product"` + "`" + `
+
+	Quantity	*OrderLineItem2Quantity	` + "`" + `xml:"quantity" json:"quantity"` + "`" + `
+
+	Status	*OrderLineItem2Status	` + "`" + `xml:"http://example.com/shop status,attr,omitempty" json:"status,omitempty"` + "`" + `
+}`,
+		"OrderLineItem2Product": `type OrderLineItem2Product struct {
+	Sku string ` + "`" + `xml:"sku" json:"sku"` + "`" + `
+}`,
+		"OrderLineItem2Quantity": `type OrderLineItem2Quantity int32`,
+		"OrderLineItem2Status":   `type OrderLineItem2Status string`,
+		"CustomerAddress": `type CustomerAddress struct {
+	Street	string	` + "`" + `xml:"street" json:"street"` + "`" + `
+
+	City	string	` + "`" + `xml:"city" json:"city"` + "`" + `
+}`,
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	// Hoisted simple types carry their facets and enumerations.
+	actual, err := getFuncDeclaration(resp, "Validate", "OrderLineItem2Quantity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `func (v OrderLineItem2Quantity) Validate() error {
+	var errs soap.Validation
+	errs.Facet("", v, "minInclusive", "1")
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	if !strings.Contains(string(resp["types"]), "OrderLineItem2StatusShipped OrderLineItem2Status = \"shipped\"") {
+		t.Error("the enumeration of the status attribute has no constants")
+	}
+
+	actual, err = getFuncDeclaration(resp, "Validate", "OrderLineItem2")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (t *OrderLineItem2) Validate() error {
+	if t == nil {
+		return nil
+	}
+	var errs soap.Validation
+	errs.Occurs("Product", t.Product, 1, 1)
+	errs.Check("Product", t.Product)
+	errs.Occurs("Quantity", t.Quantity, 1, 1)
+	errs.Check("Quantity", t.Quantity)
+	errs.Check("Status", t.Status)
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestRecursiveTypes(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stdout)
+
+	g, err := NewGoWSDL("fixtures/recursion.wsdl", "myservice", false, true, WithVerbose())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Types derived from themselves ignore the base closing the cycle, and
+	// types referring to themselves do it through pointers and slices.
+	for name, expected := range map[string]string{
+		"Code": `type Code string`,
+		"Sku":  `type Sku Code`,
+		"Label": `type Label struct {
+	Value	string	` + "`" + `xml:",chardata" json:"-,"` + "`" + `
+
+	Lang	string	` + "`" + `xml:"http://example.com/catalog lang,attr,omitempty" json:"lang,omitempty"` + "`" + `
+}`,
+		"Shape": `type Shape struct {
+	Sides int32 ` + "`" + `xml:"sides" json:"sides"` + "`" + `
+}`,
+		"Polygon": `type Polygon struct {
+	*Shape
+
+	Area	float64	` + "`" + `xml:"area" json:"area"` + "`" + `
+}`,
+		"Category": `type Category struct {
+	Name	string	` + "`" + `xml:"name" json:"name"` + "`" + `
+
+	Parent	*Category	` + "`" + `xml:"parent,omitempty" json:"parent,omitempty"` + "`" + `
+
+	Children	struct {
+		Category []*Category ` + "`" + `xml:"category" json:"category"` + "`" + `
+	}	` + "`" + `xml:"children" json:"children"` + "`" + `
+}`,
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	for _, expected := range []string{
+		"[WARN] Type Shape derives from itself (Shape -> Polygon -> Shape), ignoring its base Polygon\n",
+		"[WARN] Type Code derives from itself (Code -> Sku -> Code), ignoring its base Sku\n",
+		"Recursive type Category, through Category.parent (pointer), Category.children.category (slice)\n",
+		"Recursive types Folder, Document, through Folder.entry.folder (pointer), Folder.entry.document (pointer), Document.attachments (pointer)\n",
+		"Recursive type Expression, through Expression.sum.Expression (slice)\n",
+	} {
+		if !strings.Contains(logged.String(), expected) {
+			t.Errorf("%q wasn't logged", expected)
+		}
+	}
+}
+
+func TestNaming(t *testing.T) {
+	var report bytes.Buffer
+	g, err := NewGoWSDL("fixtures/naming.wsdl", "myservice", false, true, WithInitialisms(), WithNamingReport(&report))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Colliding names are suffixed in declaration order: types before
+	// elements, and elements before attributes.
+	for name, expected := range map[string]string{
+		"OrderLine2":     `type OrderLine2 struct {`,
+		"AnyURI2":        `type AnyURI2 string`,
+		"Shop2":          `type Shop2 struct {`,
+		"Order2":         `type Order2 OrderType`,
+		"Item":           `type Item struct {`,
+		"StatusInstock":  `const StatusInstock Status = "in-stock"`,
+		"StatusInstock2": `const StatusInstock2 Status = "instock"`,
+		"Order": `type Order struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/shop order"` + "`" + `
+
+	OrderID	string	` + "`" + `xml:"orderId" json:"orderId"` + "`" + `
+
+	Name	string	` + "`" + `xml:"name" json:"name"` + "`" + `
+
+	Validate2	*bool	` + "`" + `xml:"validate,omitempty" json:"validate,omitempty"` + "`" + `
+
+	Line	[]*OrderLine2	` + "`" + `xml:"line" json:"line"` + "`" + `
+
+	Name2	string	` + "`" + `xml:"http://example.com/shop name,attr" json:"name2"` + "`" + `
+
+	HomeURL	AnyURI	` + "`" + `xml:"http://example.com/shop homeUrl,attr,omitempty" json:"homeUrl,omitempty"` + "`" + `
+}`,
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(actual, expected) {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+
+	for _, expected := range []string{
+		"type\t{http://example.com/shop}orderLine\tOrderLine2\t(renamed from OrderLine)\n",
+		"type\t{http://example.com/shop}Shop\tShop2\t(renamed from Shop)\n",
+		"element\t{http://example.com/shop}Order\tOrder2\t(renamed from Order)\n",
+		"element\t{http://example.com/shop}Item\tItem\n",
+		"enumeration\tStatus=instock\tStatusInstock2\t(renamed from StatusInstock)\n",
+		"element\tOrder/orderId\tOrder.OrderID\n",
+		"attribute\tOrder/name\tOrder.Name2\t(renamed from Order.Name)\n",
+	} {
+		if !strings.Contains(report.String(), expected) {
+			t.Errorf("%q wasn't reported", expected)
+		}
+	}
+}
+
+func TestTypedEnumerations(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/enums.wsdl", "myservice", false, true, WithStrictEnums())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Constants follow the base type of the enumeration, resolved through
+	// the simple types it restricts.
+	for name, expected := range map[string]string{
+		"Priority010": "const Priority010 Priority = 10",
+		"Weight1_50":  "const Weight1_50 Weight = 1.5",
+		"StateOpen":   `const StateOpen State = "open"`,
+		"Urgency1":    "const Urgency1 Urgency = 1",
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+	if !bytes.Contains(resp["types"], []byte("// Fix immediately.")) {
+		t.Error("the documentation of Priority1 is not carried over")
+	}
+
+	actual, err := getFuncDeclaration(resp, "Values", "Priority")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `func (Priority) Values() []Priority {
+	return []Priority{
+		Priority1,
+		Priority2,
+		Priority010,
+	}
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "String", "Priority")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (v Priority) String() string {
+	return soap.FormatEnum(v)
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "UnmarshalXMLAttr", "State")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (v *State) UnmarshalXMLAttr(attr xml.Attr) error {
+	return soap.UnmarshalEnum(attr.Value, v)
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Text and JSON decoding is strict as well, instead of being forwarded to
+	// the type enumerated.
+	for _, recv := range []string{"State", "Code"} {
+		actual, err = getFuncDeclaration(resp, "UnmarshalText", recv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = `func (v *` + recv + `) UnmarshalText(text []byte) error {
+	return soap.UnmarshalEnum(string(text), v)
+}`
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+
+		actual, err = getFuncDeclaration(resp, "UnmarshalJSON", recv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected = `func (v *` + recv + `) UnmarshalJSON(data []byte) error {
+	return soap.UnmarshalJSONEnum(data, v)
+}`
+		if actual != expected {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+	if n := bytes.Count(resp["types"], []byte("func (v *Code) UnmarshalText(")); n != 1 {
+		t.Errorf("got %d UnmarshalText methods of Code", n)
+	}
+
+	g, err = NewGoWSDL("fixtures/enums.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := getFuncDeclaration(resp, "UnmarshalXML", "Priority"); err == nil {
+		t.Error("enumerations decode unknown values unless strict")
+	}
+	actual, err = getFuncDeclaration(resp, "UnmarshalText", "Code")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = `func (v *Code) UnmarshalText(text []byte) error {
+	return (*soap.Integer)(v).UnmarshalText(text)
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
 func TestComplexTypeGeneratedCorrectly(t *testing.T) {
 	g, err := NewGoWSDL("fixtures/workday-time-min.wsdl", "myservice", false, true)
 	if err != nil {
-		t.Error(err)
+		t.Error(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Error(err)
+	}
+
+	decl, err := getTypeDeclaration(resp, "WorkerObjectIDType")
+
+	expected := "type WorkerObjectIDType struct"
+	re := regexp.MustCompile(expected)
+	matches := re.FindStringSubmatch(decl)
+
+	if len(matches) != 1 {
+		t.Errorf("No match or too many matches found for WorkerObjectIDType")
+	} else if matches[0] != expected {
+		t.Errorf("WorkerObjectIDType got '%s' but expected '%s'", matches[1], expected)
+	}
+}
+
+func TestSOAP12Binding(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/soap12.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `err := service.client.CallContextWithOptions(ctx, "http://example.com/weather/GetForecast", request, response, soap.WithSOAPVersion(soap.SOAP12))`
+	if !strings.Contains(string(resp["operations"]), expected) {
+		t.Errorf("expected SOAP 1.2 call %q in\n%s", expected, resp["operations"])
+	}
+
+	if addr := g.findServiceAddress("WeatherSoap12"); addr != "http://example.com/weather/service.asmx" {
+		t.Errorf("got service address %q", addr)
+	}
+}
+
+func TestSOAP11PreferredOverSOAP12Binding(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/ferry.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Contains(string(resp["operations"]), "soap.SOAP12") {
+		t.Error("expected the SOAP 1.1 binding to be used when both versions are bound")
+	}
+	expected := `err := service.client.CallContext(ctx, "http://www.wsdot.wa.gov/ferries/schedule/GetAllAlerts", request, response)`
+	if !strings.Contains(string(resp["operations"]), expected) {
+		t.Errorf("expected SOAP 1.1 call %q", expected)
+	}
+}
+
+func TestRPCLiteralWrappers(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/rpc.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "AddRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type AddRequest struct {
+	XMLName	xml.Name	` + "`" + `xml:"Add"` + "`" + `
+
+	A	int32	` + "`" + `xml:"a" json:"a"` + "`" + `
+
+	B	int32	` + "`" + `xml:"b" json:"b"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "MultiplyResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type MultiplyResponse struct {
+	XMLName	xml.Name	` + "`" + `xml:"MultiplyResponse"` + "`" + `
+
+	Result	int64	` + "`" + `xml:"result" json:"result"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "MarshalXML", "AddRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (m AddRequest) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	start = xml.StartElement{Name: xml.Name{Space: "urn:calculator", Local: "Add"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	if err := e.EncodeElement(m.A, xml.StartElement{Name: xml.Name{Local: "a"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}}}}); err != nil {
+		return err
+	}
+
+	if err := e.EncodeElement(m.B, xml.StartElement{Name: xml.Name{Local: "b"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}}}}); err != nil {
+		return err
+	}
+
+	return e.EncodeToken(start.End())
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Parts are exported fields, whatever their names.
+	actual, err = getTypeDeclaration(resp, "ResetRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type ResetRequest struct {
+	XMLName	xml.Name	` + "`" + `xml:"Reset"` + "`" + `
+
+	This	string	` + "`" + `xml:"_this" json:"_this"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	ops := string(resp["operations"])
+	for _, expected := range []string{
+		"Add (request *AddRequest) (*AddResponse, error)",
+		"Reset (request *ResetRequest) (*ResetResponse, error)",
+	} {
+		if !strings.Contains(ops, expected) {
+			t.Errorf("expected operation %q in\n%s", expected, ops)
+		}
+	}
+
+	if !strings.Contains(string(resp["server"]), "AddRequest *AddRequest `xml:\",omitempty\"`") {
+		t.Errorf("expected the server to accept the RPC wrapper in\n%s", resp["server"])
+	}
+}
+
+func TestIsEncodedOperation(t *testing.T) {
+	encoded := WSDLSOAPBody{Use: "encoded"}
+	tests := []struct {
+		op       WSDLOperation
+		expected bool
+	}{
+		{WSDLOperation{}, false},
+		{WSDLOperation{Input: WSDLInput{SOAPBody: encoded}}, true},
+		{WSDLOperation{Output: WSDLOutput{SOAPBody: encoded}}, true},
+		{WSDLOperation{Output: WSDLOutput{SOAP12Body: encoded}}, true},
+	}
+	for i, test := range tests {
+		if actual := isEncodedOperation(&test.op); actual != test.expected {
+			t.Errorf("%d: got %t wanted %t", i, actual, test.expected)
+		}
+	}
+}
+
+func TestRPCEncoded(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/encoded.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "GetQuotesRequest")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type GetQuotesRequest struct {
+	XMLName	xml.Name	` + "`" + `xml:"urn:stockquote GetQuotes"` + "`" + `
+
+	Symbols	*ArrayOfString	` + "`" + `xml:"symbols" json:"symbols"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "ArrayOfQuote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = "type ArrayOfQuote []*Quote"
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "MarshalXML", "ArrayOfQuote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (a ArrayOfQuote) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return soap.MarshalArray(e, start, []*Quote(a), xml.Name{Space: "urn:stockquote", Local: "Quote"})
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "MarshalEncoded", "ArrayOfQuote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (a ArrayOfQuote) MarshalEncoded(enc *soap.Encoder, start xml.StartElement) error {
+	return enc.MarshalArray(start, []*Quote(a), xml.Name{Space: "urn:stockquote", Local: "Quote"})
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "XSDType", "Quote")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (Quote) XSDType() xml.Name {
+	return xml.Name{Space: "urn:stockquote", Local: "Quote"}
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	if _, err := getFuncDeclaration(resp, "XSDType", "ArrayOfQuote"); err == nil {
+		t.Error("SOAP-ENC arrays must not declare their XSD type")
+	}
+
+	if !strings.Contains(string(resp["operations"]), `"urn:stockquote#GetQuotes", request, response, soap.WithSOAPEncoding())`) {
+		t.Errorf("expected the operation to use the SOAP encoding in\n%s", resp["operations"])
+	}
+}
+
+func TestWSDLImport(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/wsdlimport/service.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The imported interface imports the service back.
+	if len(g.wsdl.PortTypes) != 1 || len(g.wsdl.Binding) != 1 || len(g.wsdl.Service) != 1 {
+		t.Errorf("expected each definition to be merged once, got %d port types, %d bindings and %d services",
+			len(g.wsdl.PortTypes), len(g.wsdl.Binding), len(g.wsdl.Service))
+	}
+
+	// The QNames of the imported definitions resolve in the importing one.
+	op := g.wsdl.PortTypes[0].Operations[0]
+	for _, message := range []string{op.Input.Message, op.Output.Message} {
+		if qname := resolveQName(message, g.wsdl.Xmlns); qname.Space != "http://example.com/inventory/interface" {
+			t.Errorf("expected %s to resolve to the namespace of the interface, got %v", message, qname)
+		}
+	}
+
+	// Elements are looked up by their exact name only.
+	if _, el := g.findElement(xml.Name{Space: "http://example.com/inventory/types", Local: "getStock"}); el != nil {
+		t.Errorf("expected no element named getStock, got %s", el.Name)
+	}
+
+	actual, err := getTypeDeclaration(resp, "GetStockResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type GetStockResponse struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/inventory/types GetStockResponse"` + "`" + `
+
+	Quantity	int32	` + "`" + `xml:"quantity" json:"quantity"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	ops := string(resp["operations"])
+	for _, expected := range []string{
+		"GetStock (request *GetStock) (*GetStockResponse, error)",
+		`"http://example.com/inventory/GetStock", request, response)`,
+	} {
+		if !strings.Contains(ops, expected) {
+			t.Errorf("expected %q in\n%s", expected, ops)
+		}
+	}
+}
+
+func TestXMLCatalog(t *testing.T) {
+	// The catalog maps the WSDL, rewrites the location of the remote schema,
+	// whose include resolves against the local copy, and chains to a catalog
+	// mapping the namespace imported without a location. The other one is
+	// declared inline.
+	g, err := NewGoWSDL("http://example.com/wsdl/shop.wsdl", "myservice", false, true, WithCatalog("fixtures/catalog/catalog.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, expected := range map[string]string{
+		"Code": `type Code string`,
+		"Note": `type Note struct {`,
+		"Part": `type Part struct {`,
+		"Item": `type Item struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/remote item"` + "`" + `
+
+	Sku	string	` + "`" + `xml:"sku" json:"sku"` + "`" + `
+
+	Part	[]*Part	` + "`" + `xml:"part" json:"part"` + "`" + `
+}`,
+	} {
+		actual, err := getTypeDeclaration(resp, name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(actual, expected) {
+			t.Error("got \n" + actual + " want \n" + expected)
+		}
+	}
+}
+
+func TestLocationOption(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/catalog/shop.wsdl", "myservice", false, true,
+		WithLocation("http://example.com/schemas/remote.xsd", "fixtures/catalog/schemas/remote.xsd"),
+		WithLocation("http://example.com/common", "fixtures/catalog/schemas/common.xsd"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Order", "Item", "Part", "Code", "Note"} {
+		if _, err := getTypeDeclaration(resp, name); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/groups.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "Person")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type Person struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/directory person"` + "`" + `
+
+	Id	int32	` + "`" + `xml:"id" json:"id"` + "`" + `
+
+	FirstName	string	` + "`" + `xml:"firstName" json:"firstName"` + "`" + `
+
+	LastName	string	` + "`" + `xml:"lastName" json:"lastName"` + "`" + `
+
+	Email	string	` + "`" + `xml:"email" json:"email"` + "`" + `
+
+	Mobile	[]string	` + "`" + `xml:"mobile,omitempty" json:"mobile,omitempty"` + "`" + `
+
+	Landline	[]string	` + "`" + `xml:"landline,omitempty" json:"landline,omitempty"` + "`" + `
+
+	Note	string	` + "`" + `xml:"note" json:"note"` + "`" + `
+
+	CreatedBy	string	` + "`" + `xml:"http://example.com/directory createdBy,attr,omitempty" json:"createdBy,omitempty"` + "`" + `
+
+	CreatedAt	soap.XSDDateTime	` + "`" + `xml:"http://example.com/directory createdAt,attr,omitempty" json:"createdAt,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// The required choice group must hold a branch.
+	actual, err = getFuncDeclaration(resp, "Validate", "Person")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (t *Person) Validate() error {
+	if t == nil {
+		return nil
+	}
+	var errs soap.Validation
+	errs.OccursChoice("Mobile|Landline", 1, -1, t.Mobile, t.Landline)
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// The groups of the base type aren't inlined again.
+	actual, err = getTypeDeclaration(resp, "Employee")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type Employee struct {
+	*Person
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "GetPerson")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type GetPerson struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/directory GetPerson"` + "`" + `
+
+	FirstName	string	` + "`" + `xml:"firstName" json:"firstName"` + "`" + `
+
+	LastName	string	` + "`" + `xml:"lastName" json:"lastName"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestRestrictions(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/restriction.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "ItemSummary")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type ItemSummary struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/catalog item"` + "`" + `
+
+	Id	int32	` + "`" + `xml:"id" json:"id"` + "`" + `
+
+	Name	string	` + "`" + `xml:"name" json:"name"` + "`" + `
+
+	Lang	string	` + "`" + `xml:"http://example.com/catalog lang,attr,omitempty" json:"lang,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "PositivePrice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type PositivePrice struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/catalog price"` + "`" + `
+
+	Value	soap.Decimal	` + "`" + `xml:",chardata" json:"-,"` + "`" + `
+
+	Currency	string	` + "`" + `xml:"http://example.com/catalog currency,attr,omitempty" json:"currency,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+	if !bytes.Contains(resp["types"], []byte("// Value is restricted by minExclusive 0, totalDigits 10.")) {
+		t.Error("facets of PositivePrice are not documented")
+	}
+
+	actual, err = getTypeDeclaration(resp, "SizeLarge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected = `const SizeLarge string = "large"`; actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestPolymorphicTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/polymorphism.wsdl", "myservice", false, true, WithPolymorphicTypes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "GetDrawingResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type GetDrawingResponse struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/drawing GetDrawingResponse"` + "`" + `
+
+	Origin	*Point	` + "`" + `xml:"origin" json:"origin"` + "`" + `
+
+	Frame	*AnySquare	` + "`" + `xml:"frame,omitempty" json:"frame,omitempty"` + "`" + `
+
+	Shape	[]*AnyShape	` + "`" + `xml:"shape" json:"shape"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "BaseShape")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type BaseShape interface {
+	GetShape() *Shape
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "UnmarshalXML", "AnyShape")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (a *AnyShape) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return soap.UnmarshalXSIType(d, start, &a.BaseShape, xml.Name{Space: "http://example.com/drawing", Local: "Shape"})
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "GetShape", "RoundedSquare")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (t *RoundedSquare) GetShape() *Shape {
+	if t.Square == nil {
+		t.Square = new(Square)
+	}
+	return t.Square.GetShape()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Types without derived types are left alone.
+	if _, err := getTypeDeclaration(resp, "AnyPoint"); err == nil {
+		t.Error("AnyPoint should not be generated")
+	}
+}
+
+func TestSubstitutionGroups(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/substitution.wsdl", "myservice", false, true, WithPolymorphicTypes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "PlaceOrder")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type PlaceOrder struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/orders PlaceOrder"` + "`" + `
+
+	Id	int32	` + "`" + `xml:"id" json:"id"` + "`" + `
+
+	Payment	[]*AnyPayment	` + "`" + `xml:",any,omitempty" json:"Payment,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "UnmarshalXML", "AnyPayment")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (a *AnyPayment) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return soap.UnmarshalElement(d, start, &a.Value)
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Members of nested groups are registered, abstract heads aren't.
+	for _, name := range []string{"CashPayment", "CardPayment", "GiftCardPayment"} {
+		registration := `soap.RegisterElement(xml.Name{Space: "http://example.com/orders", Local: "` + name + `"}, func() interface{} { return new(` + name + `) })`
+		if !bytes.Contains(resp["types"], []byte(registration)) {
+			t.Errorf("%s is not registered", name)
+		}
+	}
+	if bytes.Contains(resp["types"], []byte(`Local: "Payment"}`)) {
+		t.Error("abstract Payment is registered")
+	}
+}
+
+func TestParticleOrder(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/particles.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "Stop")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Fields follow the document order of nested sequences, choices, groups
+	// and wildcards; the elements of choices are optional, and the ones of
+	// repeated choices are slices.
+	expected := `type Stop struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/routes stop"` + "`" + `
+
+	Name	string	` + "`" + `xml:"name" json:"name"` + "`" + `
+
+	Address	*string	` + "`" + `xml:"address,omitempty" json:"address,omitempty"` + "`" + `
+
+	City	*string	` + "`" + `xml:"city,omitempty" json:"city,omitempty"` + "`" + `
+
+	Zip	*string	` + "`" + `xml:"zip,omitempty" json:"zip,omitempty"` + "`" + `
+
+	Latitude	*float64	` + "`" + `xml:"latitude,omitempty" json:"latitude,omitempty"` + "`" + `
+
+	Longitude	*float64	` + "`" + `xml:"longitude,omitempty" json:"longitude,omitempty"` + "`" + `
+
+	Items	[]soap.LaxElement	` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
+
+	Note	[]string	` + "`" + `xml:"note,omitempty" json:"note,omitempty"` + "`" + `
+
+	Tag	[]string	` + "`" + `xml:"tag,omitempty" json:"tag,omitempty"` + "`" + `
+
+	Eta	*soap.XSDDateTime	` + "`" + `xml:"eta,omitempty" json:"eta,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestOccurrences(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/occurs.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "Reading")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Required elements are encoded even when zero, optional values are
+	// pointers and elements occurring more than once are slices.
+	expected := `type Reading struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/sensors reading"` + "`" + `
+
+	Value	int32	` + "`" + `xml:"value" json:"value"` + "`" + `
+
+	Valid	bool	` + "`" + `xml:"valid" json:"valid"` + "`" + `
+
+	Offset	*float64	` + "`" + `xml:"offset,omitempty" json:"offset,omitempty"` + "`" + `
+
+	Samples	[]int32	` + "`" + `xml:"samples" json:"samples"` + "`" + `
+
+	Location	*Location	` + "`" + `xml:"location,omitempty" json:"location,omitempty"` + "`" + `
+
+	Signature	[]byte	` + "`" + `xml:"signature,omitempty" json:"signature,omitempty"` + "`" + `
+
+	Label	[]string	` + "`" + `xml:"label,omitempty" json:"label,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestValidation(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/validation.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
 	}
 
 	resp, err := g.Start()
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
-	decl, err := getTypeDeclaration(resp, "WorkerObjectIDType")
+	actual, err := getFuncDeclaration(resp, "Validate", "PromoSku")
+	if err != nil {
+		t.Fatal(err)
+	}
 
-	expected := "type WorkerObjectIDType struct"
-	re := regexp.MustCompile(expected)
-	matches := re.FindStringSubmatch(decl)
+	// Simple types check the facets of the types they restrict first.
+	expected := `func (v PromoSku) Validate() error {
+	var errs soap.Validation
+	errs.Check("", Sku(v))
+	errs.Facet("", v, "maxLength", "8")
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
 
-	if len(matches) != 1 {
-		t.Errorf("No match or too many matches found for WorkerObjectIDType")
-	} else if matches[0] != expected {
-		t.Errorf("WorkerObjectIDType got '%s' but expected '%s'", matches[1], expected)
+	actual, err = getFuncDeclaration(resp, "Validate", "Line")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (t *Line) Validate() error {
+	if t == nil {
+		return nil
+	}
+	var errs soap.Validation
+	errs.Check("Sku", t.Sku)
+	errs.Check("Quantity", t.Quantity)
+	errs.Check("Price", t.Price)
+	errs.Facet("Note", t.Note, "maxLength", "20")
+	errs.Check("Status", t.Status)
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "Validate", "Order")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (t *Order) Validate() error {
+	if t == nil {
+		return nil
+	}
+	var errs soap.Validation
+	errs.Occurs("Line", t.Line, 1, 10)
+	errs.Check("Line", t.Line)
+	errs.Check("Promo", t.Promo)
+	return errs.Err()
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestChoiceTypes(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/choice.wsdl", "myservice", false, true, WithChoiceTypes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "Contact")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type Contact struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/contacts contact"` + "`" + `
+
+	Name	string	` + "`" + `xml:"name" json:"name"` + "`" + `
+
+	Choice	*ContactChoice	` + "`" + `xml:",any,omitempty" json:"choice,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Group branches get a struct holding their elements.
+	actual, err = getTypeDeclaration(resp, "ContactChoiceStreet")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type ContactChoiceStreet struct {
+	Street	string	` + "`" + `xml:"street" json:"street"` + "`" + `
+
+	City	string	` + "`" + `xml:"city" json:"city"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getFuncDeclaration(resp, "Phone", "ContactChoice")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `func (c *ContactChoice) Phone() (value []string, ok bool) {
+	if c != nil {
+		if v, isBranch := c.choice.Value(1).(*[]string); isBranch {
+			return *v, true
+		}
+	}
+	return value, false
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	// Repeated choices are slices of choices.
+	actual, err = getTypeDeclaration(resp, "Labels")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type Labels struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/contacts labels"` + "`" + `
+
+	Choice	[]*LabelsChoice	` + "`" + `xml:",any,omitempty" json:"choice,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestChoiceTypesConflicts(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/particles.wsdl", "myservice", false, true, WithChoiceTypes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Stop holds two choices and a wildcard, which encoding/xml can't tell
+	// apart: its choices remain optional fields.
+	if bytes.Contains(resp["types"], []byte("soap.Choice")) {
+		t.Error("choices of Stop are generated as types")
+	}
+}
+
+func TestNamespaceCollisions(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/collision.wsdl", "myservice", false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	actual, err := getTypeDeclaration(resp, "GetCustomerResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type GetCustomerResponse struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/crm/customer GetCustomerResponse"` + "`" + `
+
+	Status	Status	` + "`" + `xml:"status" json:"status"` + "`" + `
+
+	Home	*Address	` + "`" + `xml:"home" json:"home"` + "`" + `
+
+	Billing	*BillingAddress	` + "`" + `xml:"billing" json:"billing"` + "`" + `
+
+	BillingStatus	*BillingStatus	` + "`" + `xml:"http://example.com/crm/customer billingStatus,attr,omitempty" json:"billingStatus,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "BillingAddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected = `type BillingAddress struct {
+	Line	[]string	` + "`" + `xml:"line" json:"line"` + "`" + `
+
+	Status	BillingStatus	` + "`" + `xml:"status" json:"status"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	actual, err = getTypeDeclaration(resp, "BillingStatus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected = "type BillingStatus int32"; actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+}
+
+func TestNamespacePrefixOption(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/collision.wsdl", "myservice", false, true,
+		WithNamespacePrefix("http://example.com/crm/billing/v2", "Invoice"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"Address", "Status", "InvoiceAddress", "InvoiceStatus"} {
+		if _, err := getTypeDeclaration(resp, name); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestPackagePerNamespace(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/collision.wsdl", "crm", false, true,
+		WithPackagePerNamespace("example.com/crm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	customer := map[string][]byte{"header": resp["package:customer"]}
+	actual, err := getTypeDeclaration(customer, "GetCustomerResponse")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `type GetCustomerResponse struct {
+	XMLName	xml.Name	` + "`" + `xml:"http://example.com/crm/customer GetCustomerResponse"` + "`" + `
+
+	Status	Status	` + "`" + `xml:"status" json:"status"` + "`" + `
+
+	Home	*Address	` + "`" + `xml:"home" json:"home"` + "`" + `
+
+	Billing	*billing.Address	` + "`" + `xml:"billing" json:"billing"` + "`" + `
+
+	BillingStatus	*billing.Status	` + "`" + `xml:"http://example.com/crm/customer billingStatus,attr,omitempty" json:"billingStatus,omitempty"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+	if !bytes.Contains(resp["package:customer"], []byte(`"example.com/crm/billing"`)) {
+		t.Error("customer package doesn't import the billing package")
+	}
+
+	billing := map[string][]byte{"header": resp["package:billing"]}
+	for _, name := range []string{"Address", "Status"} {
+		if _, err := getTypeDeclaration(billing, name); err != nil {
+			t.Error(err)
+		}
+	}
+
+	if _, err := getTypeDeclaration(resp, "Address"); err == nil {
+		t.Error("namespace types are generated in the main package")
+	}
+	if !bytes.Contains(resp["header"], []byte(`"example.com/crm/customer"`)) {
+		t.Error("main package doesn't import the customer package")
+	}
+	if !bytes.Contains(resp["operations"], []byte("(request *customer.GetCustomer) (*customer.GetCustomerResponse, error)")) {
+		t.Error("operations don't refer to the customer package")
+	}
+	if !bytes.Contains(resp["server"], []byte("GetCustomerFunc(request *customer.GetCustomer) (*customer.GetCustomerResponse, error)")) {
+		t.Error("server doesn't refer to the customer package")
+	}
+}
+
+func TestPackagePerNamespaceBuilds(t *testing.T) {
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	// The directory is inside the module, so that the generated packages
+	// build against its soap package. ./... skips it for its underscore.
+	dir, err := ioutil.TempDir(".", "_pkgns")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// The last path element of http://example.com/shop is the name of the
+	// port type Shop, whose struct the main package declares as shop.
+	g, err := NewGoWSDL("fixtures/naming.wsdl", "myservice", false, true,
+		WithPackagePerNamespace("github.com/hooklift/gowsdl/"+filepath.Base(dir)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	write := func(name string, parts ...[]byte) {
+		source, err := format.Source(bytes.Join(parts, nil))
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if err := os.MkdirAll(filepath.Dir(name), 0744); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(name, source, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(dir, "myservice.go"), resp["header"], resp["types"], resp["operations"], resp["soap"])
+	write(filepath.Join(dir, "server.go"), resp["server_header"], resp["server_wsdl"], resp["server"])
+	for key, code := range resp {
+		if strings.HasPrefix(key, "package:") {
+			pkgDir := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(key, "package:")))
+			write(filepath.Join(pkgDir, filepath.Base(pkgDir)+".go"), code)
+		}
+	}
+
+	// The main package imports the namespace packages, which build along.
+	cmd := exec.Command(goTool, "build", "./"+filepath.Base(dir))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Errorf("generated code doesn't build: %v\n%s", err, out)
+	}
+}
+
+func TestNamespacePackageOption(t *testing.T) {
+	g, err := NewGoWSDL("fixtures/collision.wsdl", "crm", false, true,
+		WithPackagePerNamespace("example.com/crm"),
+		WithNamespacePackage("http://example.com/crm/customer", "example.com/crm"),
+		WithNamespacePackage("http://example.com/crm/billing/v2", "example.com/shared/billing-v2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := g.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for key := range resp {
+		if strings.HasPrefix(key, "package:") {
+			t.Errorf("unexpected package %s", key)
+		}
+	}
+
+	actual, err := getTypeDeclaration(resp, "Address")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `type Address struct {
+	Street	string	` + "`" + `xml:"street" json:"street"` + "`" + `
+
+	City	string	` + "`" + `xml:"city" json:"city"` + "`" + `
+}`
+	if actual != expected {
+		t.Error("got \n" + actual + " want \n" + expected)
+	}
+
+	if !bytes.Contains(resp["header"], []byte(`billingv2 "example.com/shared/billing-v2"`)) {
+		t.Error("main package doesn't import the external billing package")
+	}
+	if !bytes.Contains(resp["types"], []byte("Billing *billingv2.Address")) {
+		t.Error("types don't refer to the external billing package")
+	}
+}
+
+func TestNamespacePrefix(t *testing.T) {
+	for ns, expected := range map[string]string{
+		"http://example.com/crm/billing/v2":          "Billing",
+		"urn:oasis:names:tc:SAML:2.0:assertion":      "Assertion",
+		"http://tempuri.org/":                        "Tempuri",
+		"http://www.example.com":                     "Example",
+		"http://example.com/schemas/order-types.xsd": "OrderTypes",
+		"urn:epcglobal:epcis-query:xsd:1":            "EpcisQuery",
+		"":                                           "Ns",
+	} {
+		if actual := namespacePrefix(ns); actual != expected {
+			t.Errorf("namespacePrefix(%q) = %q, want %q", ns, actual, expected)
+		}
 	}
 }
 
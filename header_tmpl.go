@@ -4,25 +4,34 @@
 
 package gowsdl
 
+// headerData is the data header templates are executed with. TypesOnly is
+// set for the packages generated for namespaces, which hold no operations.
+type headerData struct {
+	Package   string
+	Imports   []goImport
+	TypesOnly bool
+}
+
 var headerTmpl = `
 // Code generated by gowsdl DO NOT EDIT.
 
-package {{.}}
+package {{.Package}}
 
 import (
-	"context"
+	{{if not .TypesOnly}}"context"{{end}}
 	"encoding/xml"
 	"time"
 	"github.com/hooklift/gowsdl/soap"
 
-	{{/*range .Imports*/}}
-		{{/*.*/}}
-	{{/*end*/}}
+	{{range .Imports}}
+		{{.Name}} "{{.Path}}"
+	{{end}}
 )
 
 // against "unused imports"
 var _ time.Time
 var _ xml.Name
+{{if .TypesOnly}}var _ soap.XSDDateTime{{end}}
 
 type AnyType struct {
 	InnerXML string ` + "`" + `xml:",innerxml"` + "`" + `
@@ -0,0 +1,164 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import "encoding/xml"
+
+// hoistedType describes an anonymous type declared inline by a local element
+// or an attribute, generated as a named type of its own.
+type hoistedType struct {
+	GoName string
+	// ComplexType or SimpleType is the anonymous type.
+	ComplexType *XSDComplexType
+	SimpleType  *XSDSimpleType
+}
+
+// hoistedTypes holds the anonymous types generated as named types.
+type hoistedTypes struct {
+	// names maps the anonymous complex and simple types to the names of
+	// their Go types.
+	names map[interface{}]string
+	// schemas lists the types of each schema, in document order.
+	schemas map[*XSDSchema][]*hoistedType
+}
+
+// collectHoistedTypes names the anonymous complex types of the local elements
+// of the schemas, and the anonymous simple types of their local elements and
+// attributes, at every nesting level. Types are named after their element or
+// attribute, prefixed by the name of the type declaring it, such as
+// OrderLineItem for the lineItem element of Order. The anonymous lists and
+// unions, which are generated as types of their own anyway, are left out.
+func (g *GoWSDL) collectHoistedTypes(uniqueName func(*goPackage, string) string) *hoistedTypes {
+	h := &hoistedTypes{
+		names:   make(map[interface{}]string),
+		schemas: make(map[*XSDSchema][]*hoistedType),
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		c := &hoistCollector{g: g, uniqueName: uniqueName, types: h, schema: schema, pkg: g.packageOf(schema.TargetNamespace)}
+		for _, ct := range schema.ComplexTypes {
+			if g.soapArrays[ct] == nil {
+				_, owner, _ := g.symbols.lookup(typeSymbol, xml.Name{Space: schema.TargetNamespace, Local: ct.Name})
+				c.complexType(owner, ct, 0)
+			}
+		}
+		for _, elm := range schema.Elements {
+			if elm.Type == "" && elm.ComplexType != nil {
+				_, owner, _ := g.symbols.lookup(elementSymbol, xml.Name{Space: schema.TargetNamespace, Local: elm.Name})
+				c.complexType(owner, elm.ComplexType, 0)
+			}
+		}
+	}
+	return h
+}
+
+type hoistCollector struct {
+	g          *GoWSDL
+	uniqueName func(*goPackage, string) string
+	types      *hoistedTypes
+	schema     *XSDSchema
+	pkg        *goPackage
+}
+
+// complexType names the anonymous types of the elements and attributes of the
+// complex type ct, whose Go type is named owner.
+func (c *hoistCollector) complexType(owner string, ct *XSDComplexType, depth uint8) {
+	if depth >= maxRecursion {
+		return
+	}
+
+	for _, attrs := range [][]*XSDAttribute{
+		ct.Attributes,
+		ct.ComplexContent.Extension.Attributes,
+		ct.ComplexContent.Restriction.Attributes,
+		ct.SimpleContent.Extension.Attributes,
+		ct.SimpleContent.Restriction.Attributes,
+	} {
+		for _, attr := range attrs {
+			// The type of attributes declaring their type inline is set to
+			// its base by the traverser.
+			if attr.Ref == "" && hoistable(attr.SimpleType) {
+				c.add(owner, attr.Name, &hoistedType{SimpleType: attr.SimpleType})
+			}
+		}
+	}
+
+	for _, content := range []XSDContent{ct.XSDContent, ct.ComplexContent.Extension.XSDContent, ct.ComplexContent.Restriction.XSDContent} {
+		for _, elm := range particleElements(content.Particle()) {
+			switch {
+			case elm.Type != "" || elm.Ref != "":
+			case hoistable(elm.SimpleType):
+				c.add(owner, elm.Name, &hoistedType{SimpleType: elm.SimpleType})
+			case elm.SimpleType == nil && elm.ComplexType != nil:
+				if t := c.add(owner, elm.Name, &hoistedType{ComplexType: elm.ComplexType}); t != nil {
+					c.complexType(t.GoName, elm.ComplexType, depth+1)
+				}
+			}
+		}
+	}
+}
+
+// add names the type t of the element or attribute name of the type owner,
+// unless it was named already as the type of another one, with which it is
+// shared, and returns it.
+func (c *hoistCollector) add(owner, name string, t *hoistedType) *hoistedType {
+	var key interface{} = t.SimpleType
+	if t.ComplexType != nil {
+		key = t.ComplexType
+	}
+	if _, ok := c.types.names[key]; ok {
+		return nil
+	}
+
+	t.GoName = c.uniqueName(c.pkg, owner+makePublic(normalize(name)))
+	c.types.names[key] = t.GoName
+	c.types.schemas[c.schema] = append(c.types.schemas[c.schema], t)
+	return t
+}
+
+// hoistable tells whether the anonymous simple type st is generated as a
+// named type when hoisting anonymous types.
+func hoistable(st *XSDSimpleType) bool {
+	return st != nil && st.Restriction.Base != "" && !isList(st) && !isUnion(st)
+}
+
+// hoistedType returns the Go type of the field holding the local element or
+// the attribute v, of type *XSDElement or *XSDAttribute, when its anonymous
+// type is generated as a named type, or an empty string otherwise.
+func (g *GoWSDL) hoistedType(v interface{}) string {
+	if g.hoisted == nil {
+		return ""
+	}
+
+	var key interface{}
+	switch v := v.(type) {
+	case *XSDElement:
+		if v.Type != "" || v.Ref != "" {
+			return ""
+		}
+		key = v.SimpleType
+		if v.SimpleType == nil {
+			key = v.ComplexType
+		}
+	case *XSDAttribute:
+		if v.Ref != "" {
+			return ""
+		}
+		key = v.SimpleType
+	}
+	if goName, ok := g.hoisted.names[key]; ok {
+		return "*" + goName
+	}
+	return ""
+}
+
+// schemaHoistedTypes returns the anonymous types of schema generated as named
+// types.
+func (g *GoWSDL) schemaHoistedTypes(schema *XSDSchema) []*hoistedType {
+	if g.hoisted == nil {
+		return nil
+	}
+	return g.hoisted.schemas[schema]
+}
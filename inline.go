@@ -0,0 +1,86 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// forEachInlineSimpleType calls fn with the simple types the elements and the
+// attributes of the complex types of the schemas declare inline, along with
+// the name of their element or attribute.
+func (g *GoWSDL) forEachInlineSimpleType(fn func(schema *XSDSchema, name string, st *XSDSimpleType)) {
+	var complexType func(schema *XSDSchema, ct *XSDComplexType)
+	var particle func(schema *XSDSchema, p *XSDParticle)
+
+	complexType = func(schema *XSDSchema, ct *XSDComplexType) {
+		for _, content := range []XSDContent{ct.XSDContent, ct.ComplexContent.Extension.XSDContent, ct.ComplexContent.Restriction.XSDContent} {
+			particle(schema, content.Particle())
+		}
+		for _, attrs := range [][]*XSDAttribute{
+			ct.Attributes,
+			ct.ComplexContent.Extension.Attributes,
+			ct.ComplexContent.Restriction.Attributes,
+			ct.SimpleContent.Extension.Attributes,
+			ct.SimpleContent.Restriction.Attributes,
+		} {
+			for _, attr := range attrs {
+				if attr.SimpleType != nil {
+					fn(schema, attr.Name, attr.SimpleType)
+				}
+			}
+		}
+	}
+	particle = func(schema *XSDSchema, p *XSDParticle) {
+		switch {
+		case p == nil:
+		case p.Element != nil:
+			elm := p.Element
+			if elm.Type != "" || elm.Ref != "" {
+				return
+			}
+			if elm.SimpleType != nil {
+				fn(schema, elm.Name, elm.SimpleType)
+			}
+			if elm.ComplexType != nil {
+				complexType(schema, elm.ComplexType)
+			}
+		case p.ModelGroup != nil:
+			for _, child := range p.ModelGroup.Particles {
+				particle(schema, child)
+			}
+		}
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			complexType(schema, ct)
+		}
+		for _, elm := range schema.Elements {
+			if elm.Type == "" && elm.ComplexType != nil {
+				complexType(schema, elm.ComplexType)
+			}
+		}
+	}
+}
+
+// inlineType returns the Go type of the values of the simple type st, which
+// an element or an attribute of the schema being generated declares inline,
+// when it is generated as a type of its own: lists and unions. It returns an
+// empty string otherwise.
+func (g *GoWSDL) inlineType(st *XSDSimpleType) string {
+	if lt := g.inlineLists.types[st]; lt != nil {
+		return g.currentImports.qualify(lt.pkg, lt.GoName)
+	}
+	if ut := g.unionTypes[st]; ut != nil {
+		return g.currentImports.qualify(ut.pkg, ut.GoName)
+	}
+	return ""
+}
+
+// validatesInline tells whether the type of the simple type st declared
+// inline has a Validate method.
+func (g *GoWSDL) validatesInline(st *XSDSimpleType) bool {
+	if lt := g.inlineLists.types[st]; lt != nil {
+		return lt.Validate != nil
+	}
+	return g.unionTypes[st] != nil
+}
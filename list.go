@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// listType describes a Go type generated for an XSD list, a slice written as
+// the lexical values of its items separated by spaces.
+type listType struct {
+	// GoName is the name of the type, and ItemType the Go type of its items.
+	GoName   string
+	ItemType string
+	// Validate is the Validate method of the types generated for the lists
+	// declared inline, nil when their items need no validation.
+	Validate *validation
+
+	pkg *goPackage
+}
+
+// inlineLists holds the types generated for the lists declared inline by
+// elements and attributes. Inline lists of the same item type share the type
+// of their package named after the item type, such as IntList.
+type inlineLists struct {
+	types    map[*XSDSimpleType]*listType
+	packages map[*goPackage][]*listType
+}
+
+// collectInlineLists returns the types generated for the lists declared
+// inline by the elements and the attributes of the schemas.
+func (g *GoWSDL) collectInlineLists(uniqueName func(*goPackage, string) string) *inlineLists {
+	c := &listCollector{
+		g:          g,
+		uniqueName: uniqueName,
+		lists:      &inlineLists{types: make(map[*XSDSimpleType]*listType), packages: make(map[*goPackage][]*listType)},
+		byItem:     make(map[*goPackage]map[xml.Name]*listType),
+	}
+	g.forEachInlineSimpleType(func(schema *XSDSchema, _ string, st *XSDSimpleType) {
+		c.collect(schema, st)
+	})
+	return c.lists
+}
+
+type listCollector struct {
+	g          *GoWSDL
+	uniqueName func(*goPackage, string) string
+	lists      *inlineLists
+	byItem     map[*goPackage]map[xml.Name]*listType
+}
+
+// collect generates the type of st when it is a list, and shares it with the
+// other inline lists of the package with the same item type.
+func (c *listCollector) collect(schema *XSDSchema, st *XSDSimpleType) {
+	if !isList(st) || c.lists.types[st] != nil {
+		return
+	}
+
+	item := listItem(st.List)
+	name := resolveQName(item, schema.Xmlns)
+	if item == "" {
+		item, name = "string", xml.Name{Space: xmlschema11, Local: "string"}
+	}
+
+	pkg := c.g.packageOf(schema.TargetNamespace)
+	if c.byItem[pkg] == nil {
+		c.byItem[pkg] = make(map[xml.Name]*listType)
+	}
+	if lt := c.byItem[pkg][name]; lt != nil {
+		c.lists.types[st] = lt
+		return
+	}
+
+	itemType := c.g.goType(item, schema.Xmlns, false, pkg.imports)
+	goName := c.g.makePublicFn(normalize(name.Local) + "List")
+	if _, itemName, ok := c.g.symbols.lookup(typeSymbol, name); ok && strings.HasPrefix(itemType, "*") {
+		goName = itemName + "List"
+	}
+
+	lt := &listType{
+		GoName:   c.uniqueName(pkg, goName),
+		ItemType: removePointerFromType(itemType),
+		pkg:      pkg,
+	}
+	if strings.HasPrefix(itemType, "*") {
+		lt.Validate = &validation{
+			Name:     lt.GoName,
+			Receiver: "v " + lt.GoName,
+			Checks:   []*valueCheck{{Value: "[]" + lt.ItemType + "(v)", Nested: true}},
+		}
+	}
+	c.byItem[pkg][name] = lt
+	c.lists.types[st] = lt
+	c.lists.packages[pkg] = append(c.lists.packages[pkg], lt)
+}
+
+// isList tells whether st is a list.
+func isList(st *XSDSimpleType) bool {
+	return st != nil && (st.List.ItemType != "" || st.List.SimpleType != nil)
+}
+
+// listItem returns the item type of list, or the type the item type it
+// declares inline restricts.
+func listItem(list XSDList) string {
+	if list.ItemType == "" && list.SimpleType != nil {
+		return list.SimpleType.Restriction.Base
+	}
+	return list.ItemType
+}
+
+// listOf returns the list type name is generated as for the simple type st
+// of the schema being generated, when st is a list or restricts one.
+func (g *GoWSDL) listOf(name string, st *XSDSimpleType) *listType {
+	item := g.listItemType(st, g.currentSchema.Xmlns, 0)
+	if item == "" {
+		return nil
+	}
+	return &listType{GoName: name, ItemType: item}
+}
+
+// listItemType returns the Go type of the items of the simple type st, whose
+// prefixes are declared by xmlns, or an empty string when st is not a list
+// nor restricts one, built-in lists included.
+func (g *GoWSDL) listItemType(st *XSDSimpleType, xmlns map[string]string, depth uint8) string {
+	switch {
+	case isList(st):
+		item := listItem(st.List)
+		if item == "" {
+			return "string"
+		}
+		return removePointerFromType(g.goType(item, xmlns, false, g.currentImports))
+	case st.Restriction.Base != "" && depth < maxRecursion:
+		base := resolveQName(st.Restriction.Base, xmlns)
+		if base.Space == xmlschema11 && toGoType(base.Local, false) == "soap.TokenList" {
+			return "string"
+		}
+		if schema, base := g.findSimpleType(base); base != nil {
+			return g.listItemType(base, schema.Xmlns, depth+1)
+		}
+	}
+	return ""
+}
+
+// packageInlineLists returns the types generated for the inline lists of the
+// package being generated.
+func (g *GoWSDL) packageInlineLists() []*listType {
+	return g.inlineLists.packages[g.currentImports.pkg]
+}
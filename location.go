@@ -7,6 +7,7 @@ package gowsdl
 import (
 	"net/url"
 	"path/filepath"
+	"strings"
 )
 
 // A Location encapsulate information about the loc of WSDL/XSD.
@@ -24,7 +25,7 @@ type Location struct {
 func ParseLocation(rawloc string) (*Location, error) {
 	u, _ := url.Parse(rawloc)
 	if u.Scheme != "" {
-		return &Location{u: u}, nil
+		return urlLocation(u), nil
 	}
 
 	absURI, err := filepath.Abs(rawloc)
@@ -43,7 +44,7 @@ func (r *Location) Parse(ref string) (*Location, error) {
 		if err != nil {
 			return nil, err
 		}
-		return &Location{u: u}, nil
+		return urlLocation(u), nil
 	}
 
 	if filepath.IsAbs(ref) {
@@ -52,11 +53,25 @@ func (r *Location) Parse(ref string) (*Location, error) {
 
 	if u, err := url.Parse(ref); err == nil {
 		if u.Scheme != "" {
-			return &Location{u: u}, nil
+			return urlLocation(u), nil
 		}
 	}
 
-	return &Location{f: filepath.Join(filepath.Dir(r.f), ref)}, nil
+	// A reference ending with a slash is a directory, against which further
+	// references resolve.
+	f := filepath.Join(filepath.Dir(r.f), ref)
+	if strings.HasSuffix(ref, "/") {
+		f += string(filepath.Separator)
+	}
+	return &Location{f: f}, nil
+}
+
+// urlLocation returns the Location of u, which is a file path for file URLs.
+func urlLocation(u *url.URL) *Location {
+	if u.Scheme == "file" {
+		return &Location{f: filepath.FromSlash(u.Path)}
+	}
+	return &Location{u: u}
 }
 
 // IsFile determines whether the Location contains a file path.
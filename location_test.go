@@ -145,3 +145,35 @@ func TestLocation_Parse_FileToURL(t *testing.T) {
 		}
 	}
 }
+
+func TestLocation_Parse_FileURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		ref      string
+		expected string
+	}{
+		{"fixtures/test.wsdl", "file:///schemas/some.xsd", "/schemas/some.xsd"},
+		{"http://example.org/my.wsdl", "file:///schemas/some.xsd", "/schemas/some.xsd"},
+		{"file:///schemas/my.wsdl", "some.xsd", "/schemas/some.xsd"},
+	}
+	for _, test := range tests {
+		r, err := ParseLocation(test.name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		r, err = r.Parse(test.ref)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+
+		if r.isURL() || !r.isFile() {
+			t.Error("Location should be a File type")
+			continue
+		}
+		if r.String() != filepath.FromSlash(test.expected) {
+			t.Error("got " + r.String() + " wanted " + test.expected)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+// isMixed tells whether the complex type ct of the schema being generated has
+// mixed content, text interleaved with its elements. Types extending a mixed
+// type are mixed too, as XML Schema requires, so that they don't inherit the
+// encoding methods of their base type.
+func (g *GoWSDL) isMixed(ct *XSDComplexType) bool {
+	return g.mixed(ct, g.currentSchema.Xmlns, 0)
+}
+
+func (g *GoWSDL) mixed(ct *XSDComplexType, xmlns map[string]string, depth uint8) bool {
+	switch {
+	case ct == nil || depth >= maxRecursion:
+		return false
+	case ct.SimpleContent.Extension.Base != "" || ct.SimpleContent.Restriction.Base != "":
+		return false
+	case ct.Mixed || ct.ComplexContent.Mixed:
+		return true
+	case ct.ComplexContent.Extension.Base == "":
+		return false
+	}
+	schema, base := g.findComplexType(resolveQName(ct.ComplexContent.Extension.Base, xmlns))
+	return base != nil && g.mixed(base, schema.Xmlns, depth+1)
+}
@@ -0,0 +1,403 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// scope tracks the Go identifiers declared in a package or in a struct.
+type scope struct {
+	used map[string]bool
+}
+
+func newScope(reserved ...string) *scope {
+	s := &scope{used: make(map[string]bool)}
+	for _, name := range reserved {
+		s.used[name] = true
+	}
+	return s
+}
+
+// declare returns name, or name followed by the smallest number from 2 which
+// makes it unique in s, and reserves it.
+func (s *scope) declare(name string) string {
+	unique := name
+	for i := 2; s.used[unique]; i++ {
+		unique = name + strconv.Itoa(i)
+	}
+	s.used[unique] = true
+	return unique
+}
+
+// namer names the Go types declared in each package and the fields of each
+// struct, so that the names XSD declarations map to don't collide. Colliding
+// names are made unique by a numeric suffix, given in declaration order so
+// that they are stable from one generation to the next.
+type namer struct {
+	initialisms bool
+	packages    map[*goPackage]*scope
+	structs     map[interface{}]*structFields
+	// stack holds the structs being generated, innermost last.
+	stack []*structFields
+	// enums holds the names of the values of each enumerated type.
+	enums map[string][]string
+	// mappings lists the names given, in declaration order.
+	mappings []nameMapping
+}
+
+// nameMapping is an entry of the naming report: the Go name given to an XSD
+// declaration, and the name it would have had without collisions.
+type nameMapping struct {
+	Kind      string
+	XSDName   string
+	GoName    string
+	Preferred string
+}
+
+func newNamer(initialisms bool) *namer {
+	return &namer{
+		initialisms: initialisms,
+		packages:    make(map[*goPackage]*scope),
+		structs:     make(map[interface{}]*structFields),
+		enums:       make(map[string][]string),
+	}
+}
+
+// headerTypes are the types the header of every package declares.
+var headerTypes = []string{"AnyType", "AnyURI", "NCName"}
+
+// packageScope returns the scope of the types of pkg.
+func (n *namer) packageScope(pkg *goPackage) *scope {
+	s := n.packages[pkg]
+	if s == nil {
+		s = newScope(headerTypes...)
+		n.packages[pkg] = s
+	}
+	return s
+}
+
+// identifier returns name, with its initialisms in upper case when asked to.
+func (n *namer) identifier(name string) string {
+	if n.initialisms {
+		return initialisms(name)
+	}
+	return name
+}
+
+// declareType declares the type name in pkg, for the XSD declaration xsdName
+// of kind, and returns its unique Go name.
+func (n *namer) declareType(pkg *goPackage, kind, xsdName, name string) string {
+	name = n.identifier(name)
+	goName := n.packageScope(pkg).declare(name)
+	n.mappings = append(n.mappings, nameMapping{Kind: kind, XSDName: xsdName, GoName: goName, Preferred: name})
+	return goName
+}
+
+// uniqueTypeNames returns the function naming the types generated for
+// anonymous types, choices and the like, which aren't declared by the schemas
+// under a name of their own.
+func (g *GoWSDL) uniqueTypeNames() func(*goPackage, string) string {
+	return func(pkg *goPackage, name string) string {
+		return g.names.declareType(pkg, "generated type", "", name)
+	}
+}
+
+// reserveOperationNames reserves the names of the interfaces, structs and
+// constructors generated for the port types, so that no type takes them.
+func (g *GoWSDL) reserveOperationNames() {
+	s := g.names.packageScope(g.packages[0])
+	for _, pt := range g.wsdl.PortTypes {
+		s.used[makePublic(pt.Name)] = true
+		s.used[makePrivate(pt.Name)] = true
+		s.used["New"+makePublic(pt.Name)] = true
+	}
+}
+
+// commonInitialisms are the initialisms Go names spell in upper case, as
+// listed by golint.
+var commonInitialisms = map[string]bool{
+	"ACL": true, "API": true, "ASCII": true, "CPU": true, "CSS": true,
+	"DNS": true, "EOF": true, "GUID": true, "HTML": true, "HTTP": true,
+	"HTTPS": true, "ID": true, "IP": true, "JSON": true, "LHS": true,
+	"QPS": true, "RAM": true, "RHS": true, "RPC": true, "SLA": true,
+	"SMTP": true, "SQL": true, "SSH": true, "TCP": true, "TLS": true,
+	"TTL": true, "UDP": true, "UI": true, "UID": true, "UUID": true,
+	"URI": true, "URL": true, "UTF8": true, "VM": true, "XML": true,
+	"XMPP": true, "XSRF": true, "XSS": true,
+}
+
+// initialisms spells the capitalized words of identifier which are common
+// initialisms in upper case, such as OrderID for OrderId. Words start at
+// upper case letters following lower case ones or digits, at the last upper
+// case letter of a run followed by lower case ones, and around underscores.
+func initialisms(identifier string) string {
+	runes := []rune(identifier)
+	var b strings.Builder
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && !wordBoundary(runes, i) {
+			continue
+		}
+		word := string(runes[start:i])
+		if upper := strings.ToUpper(word); unicode.IsUpper(runes[start]) && commonInitialisms[upper] {
+			word = upper
+		}
+		b.WriteString(word)
+		start = i
+	}
+	return b.String()
+}
+
+func wordBoundary(runes []rune, i int) bool {
+	prev, r := runes[i-1], runes[i]
+	switch {
+	case r == '_' || prev == '_':
+		return true
+	case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+		return true
+	}
+	return unicode.IsUpper(prev) && unicode.IsUpper(r) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+}
+
+// fieldKey identifies the field of a struct holding an element or an
+// attribute, by local name.
+type fieldKey struct {
+	attribute bool
+	name      string
+}
+
+// structFields names the fields of a struct, and the keys they are encoded
+// to in JSON, which must be unique as well.
+type structFields struct {
+	owner    string
+	scope    *scope
+	names    map[fieldKey]string
+	keys     *scope
+	jsonKeys map[fieldKey]string
+}
+
+// structFields returns the fields of the struct generated for v, a complex
+// type or the group branch of a choice, named owner, naming them on first
+// use. Fields the templates always generate, the embedded base type and the
+// methods of the struct keep their names, and the elements and attributes
+// are named in the order they are generated in.
+func (g *GoWSDL) structFields(owner string, v interface{}) *structFields {
+	if s, ok := g.names.structs[v]; ok {
+		return s
+	}
+
+	s := newStructFields(owner, "XMLName", "Validate")
+	g.names.structs[v] = s
+
+	var leaves []*XSDParticle
+	var attrs []*XSDAttribute
+	switch v := v.(type) {
+	case *choiceBranch:
+		leaves = v.Particles
+	case *XSDComplexType:
+		if g.soapEncoded || g.polymorphic {
+			s.scope.declare("XSDType")
+		}
+		if g.isMixed(v) {
+			s.scope.declare("Mixed")
+			s.keys.declare("mixed")
+			s.scope.declare("MarshalXML")
+			s.scope.declare("MarshalEncoded")
+			s.scope.declare("UnmarshalXML")
+		}
+
+		var anyAttribute *XSDAnyAttribute
+		switch ct := v; {
+		case ct.ComplexContent.Extension.Base != "":
+			ext := ct.ComplexContent.Extension
+			base := removePointerFromType(g.goType(ext.Base, g.currentSchema.Xmlns, false, g.currentImports))
+			s.scope.declare(base[strings.LastIndex(base, ".")+1:])
+			leaves, attrs, anyAttribute = g.particles(ext.Particle()), ext.Attributes, ext.AnyAttribute
+		case ct.SimpleContent.Extension.Base != "":
+			ext := ct.SimpleContent.Extension
+			s.scope.declare("Value")
+			attrs, anyAttribute = ext.Attributes, ext.AnyAttribute
+		case ct.ComplexContent.Restriction.Base != "":
+			res := ct.ComplexContent.Restriction
+			leaves, attrs, anyAttribute = g.particles(res.Particle()), g.restrictionAttributes(res), res.AnyAttribute
+		case ct.SimpleContent.Restriction.Base != "":
+			res := ct.SimpleContent.Restriction
+			s.scope.declare("Value")
+			attrs, anyAttribute = g.restrictionAttributes(res), res.AnyAttribute
+		default:
+			leaves, attrs, anyAttribute = g.particles(ct.Particle()), ct.Attributes, ct.AnyAttribute
+		}
+		if anyAttribute != nil {
+			s.scope.declare("AnyAttrs")
+			s.keys.declare("anyAttrs")
+		}
+	}
+
+	for _, leaf := range leaves {
+		switch {
+		case leaf.Any != nil:
+			s.scope.declare("Items")
+			s.keys.declare("items")
+		case leaf.ModelGroup != nil && g.choiceTypes[leaf.ModelGroup] != nil:
+			s.scope.declare("Choice")
+			s.keys.declare("choice")
+		}
+	}
+	for _, leaf := range leaves {
+		if elm := leaf.Element; elm != nil {
+			s.declare(g, fieldKey{name: elementLocalName(elm)}, g.elementFieldName(elm))
+		}
+	}
+	for _, attr := range attrs {
+		s.declare(g, fieldKey{attribute: true, name: attr.Name}, makePublic(normalize(attr.Name)))
+	}
+	return s
+}
+
+// newStructFields returns the fields of a struct named owner, with the
+// reserved names taken.
+func newStructFields(owner string, reserved ...string) *structFields {
+	return &structFields{
+		owner:    owner,
+		scope:    newScope(reserved...),
+		names:    make(map[fieldKey]string),
+		keys:     newScope(),
+		jsonKeys: make(map[fieldKey]string),
+	}
+}
+
+func (s *structFields) declare(g *GoWSDL, key fieldKey, name string) {
+	if _, ok := s.names[key]; ok {
+		return
+	}
+
+	name = g.names.identifier(exportedName(name))
+	s.names[key] = s.scope.declare(name)
+	s.jsonKeys[key] = s.keys.declare(key.name)
+
+	kind := "element"
+	if key.attribute {
+		kind = "attribute"
+	}
+	g.names.mappings = append(g.names.mappings, nameMapping{Kind: kind, XSDName: s.owner + "/" + key.name, GoName: s.owner + "." + s.names[key], Preferred: s.owner + "." + name})
+}
+
+// exportedName returns name, a field name, exported: the underscores it
+// starts with are dropped, and it is prefixed with X when it doesn't start
+// with a letter then.
+func exportedName(name string) string {
+	r := []rune(strings.TrimLeft(name, "_"))
+	if len(r) == 0 || !unicode.IsLetter(r[0]) {
+		return "X" + string(r)
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// elementFieldName returns the name the field holding the element elm would
+// have, were it alone in its struct.
+func (g *GoWSDL) elementFieldName(elm *XSDElement) string {
+	switch {
+	case elm.Ref != "":
+		return g.makePublicFn(replaceReservedWords(removeNS(elm.Ref)))
+	case elm.Type != "":
+		return makePublic(replaceAttrReservedWords(elm.Name))
+	case elm.SimpleType != nil:
+		return makePublic(normalize(elm.Name))
+	}
+	return g.makePublicFn(replaceReservedWords(elm.Name))
+}
+
+// fieldName returns the name of the field holding v, an *XSDElement or an
+// *XSDAttribute, in the struct being generated.
+func (g *GoWSDL) fieldName(v interface{}) string {
+	var key fieldKey
+	var name string
+	switch v := v.(type) {
+	case *XSDElement:
+		key, name = fieldKey{name: elementLocalName(v)}, g.elementFieldName(v)
+	case *XSDAttribute:
+		key, name = fieldKey{attribute: true, name: v.Name}, makePublic(normalize(v.Name))
+	}
+	return g.names.field(key, name)
+}
+
+// jsonName returns the JSON key of the field holding v, an *XSDElement or an
+// *XSDAttribute, in the struct being generated: its local name, suffixed like
+// the names of colliding fields when another field has the same key.
+func (g *GoWSDL) jsonName(v interface{}) string {
+	var key fieldKey
+	switch v := v.(type) {
+	case *XSDElement:
+		key = fieldKey{name: elementLocalName(v)}
+	case *XSDAttribute:
+		key = fieldKey{attribute: true, name: v.Name}
+	}
+	if n := len(g.names.stack); n > 0 {
+		if jsonKey, ok := g.names.stack[n-1].jsonKeys[key]; ok {
+			return jsonKey
+		}
+	}
+	return key.name
+}
+
+// field returns the name of the field key of the innermost struct being
+// generated, or name outside of structs.
+func (n *namer) field(key fieldKey, name string) string {
+	if len(n.stack) > 0 {
+		if goName, ok := n.stack[len(n.stack)-1].names[key]; ok {
+			return goName
+		}
+	}
+	return n.identifier(exportedName(name))
+}
+
+// fieldPath returns the name of the field of the innermost struct being
+// generated, qualified by the name of the struct, as the owner of the inline
+// struct it holds.
+func (g *GoWSDL) fieldPath(field string) string {
+	if n := len(g.names.stack); n > 0 {
+		return g.names.stack[n-1].owner + "." + field
+	}
+	return field
+}
+
+// beginStruct makes the struct generated for v, named owner, the innermost
+// struct being generated, whose fields fieldName names.
+func (g *GoWSDL) beginStruct(owner string, v interface{}) string {
+	g.names.stack = append(g.names.stack, g.structFields(owner, v))
+	return ""
+}
+
+// endStruct ends the innermost struct being generated.
+func (g *GoWSDL) endStruct() string {
+	g.names.stack = g.names.stack[:len(g.names.stack)-1]
+	return ""
+}
+
+// writeNamingReport writes the naming report to w: a line for each type and
+// field named, giving the kind of its XSD declaration, the XSD name, the Go
+// name and, when it collided, the name it would have had.
+func (n *namer) writeNamingReport(w io.Writer) error {
+	for _, m := range n.mappings {
+		xsdName := m.XSDName
+		if xsdName == "" {
+			xsdName = "-"
+		}
+		line := m.Kind + "\t" + xsdName + "\t" + m.GoName
+		if m.GoName != m.Preferred {
+			line += "\t(renamed from " + m.Preferred + ")"
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -13,8 +13,8 @@ var opsTmpl = `
 		{{range .Operations}}
 			{{$faults := len .Faults}}
 			{{$soapAction := findSOAPAction .Name $privateType}}
-			{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
-			{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+			{{$requestType := findType .Input.Message | replaceReservedWords | makePublic | qualify .Input.Message}}
+			{{$responseType := findType .Output.Message | replaceReservedWords | makePublic | qualify .Output.Message}}
 
 			{{/*if ne $soapAction ""*/}}
 			{{if gt $faults 0}}
@@ -40,12 +40,13 @@ var opsTmpl = `
 	}
 
 	{{range .Operations}}
-		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
+		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic | qualify .Input.Message}}
 		{{$soapAction := findSOAPAction .Name $privateType}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic | qualify .Output.Message}}
+		{{$callOptions := findCallOptions .Name $privateType}}
 		func (service *{{$privateType}}) {{makePublic .Name | replaceReservedWords}}Context (ctx context.Context, {{if ne $requestType ""}}request *{{$requestType}}{{end}}) ({{if ne $responseType ""}}*{{$responseType}}, {{end}}error) {
 			{{if ne $responseType ""}}response := new({{$responseType}}){{end}}
-			err := service.client.CallContext(ctx, "{{if ne $soapAction ""}}{{$soapAction}}{{else}}''{{end}}", {{if ne $requestType ""}}request{{else}}nil{{end}}, {{if ne $responseType ""}}response{{else}}struct{}{}{{end}})
+			err := service.client.{{if $callOptions}}CallContextWithOptions{{else}}CallContext{{end}}(ctx, "{{if ne $soapAction ""}}{{$soapAction}}{{else}}''{{end}}", {{if ne $requestType ""}}request{{else}}nil{{end}}, {{if ne $responseType ""}}response{{else}}struct{}{}{{end}}{{range $callOptions}}, {{.}}{{end}})
 			if err != nil {
 				return {{if ne $responseType ""}}nil, {{end}}err
 			}
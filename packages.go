@@ -0,0 +1,189 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// goPackage is a Go package the types of one or more target namespaces are
+// generated in.
+type goPackage struct {
+	name string
+	path string
+	// dir is the directory of the package relative to the one of the main
+	// package. It is empty for the main package and for external packages.
+	dir      string
+	external bool
+	schemas  []*XSDSchema
+	imports  *importSet
+}
+
+func newGoPackage(name, importPath string) *goPackage {
+	pkg := &goPackage{name: name, path: importPath}
+	pkg.imports = newImportSet(pkg)
+	return pkg
+}
+
+// reservedPackageNames are the identifiers generated code already uses at
+// file scope or as local variables, which namespace packages can't be named
+// after.
+var reservedPackageNames = map[string]bool{
+	"context":  true,
+	"xml":      true,
+	"time":     true,
+	"soap":     true,
+	"fmt":      true,
+	"errors":   true,
+	"reflect":  true,
+	"strings":  true,
+	"http":     true,
+	"wsdl":     true,
+	"service":  true,
+	"client":   true,
+	"request":  true,
+	"response": true,
+	"ctx":      true,
+	"err":      true,
+}
+
+// assignPackages decides which Go package the types of each target namespace
+// are generated in. Unless WithPackagePerNamespace is used, they all go into
+// the main package.
+func (g *GoWSDL) assignPackages() {
+	main := newGoPackage(g.pkg, g.importPath)
+	g.packages = []*goPackage{main}
+	g.nsPackages = make(map[string]*goPackage)
+
+	if g.importPath == "" {
+		main.schemas = g.wsdl.Types.Schemas
+		return
+	}
+
+	byPath := map[string]*goPackage{main.path: main}
+	used := map[string]bool{main.name: true}
+	// The unexported structs of the port types are declared at file scope
+	// in the main package, which imports the namespace packages.
+	for _, pt := range g.wsdl.PortTypes {
+		used[makePrivate(pt.Name)] = true
+	}
+	uniqueName := func(name string) string {
+		unique := name
+		for i := 2; used[unique] || reservedPackageNames[unique]; i++ {
+			unique = name + strconv.Itoa(i)
+		}
+		used[unique] = true
+		return unique
+	}
+
+	addPackage := func(ns, importPath, name string) {
+		pkg := byPath[importPath]
+		if pkg == nil {
+			pkg = newGoPackage(uniqueName(name), importPath)
+			if dir := strings.TrimPrefix(importPath, g.importPath+"/"); dir != importPath {
+				pkg.dir = dir
+			} else {
+				pkg.external = true
+			}
+			byPath[importPath] = pkg
+			g.packages = append(g.packages, pkg)
+		}
+		g.nsPackages[ns] = pkg
+	}
+
+	// Configured namespaces are assigned first, so that the names derived
+	// for the other ones don't take their place.
+	for _, schema := range g.wsdl.Types.Schemas {
+		ns := schema.TargetNamespace
+		if importPath, ok := g.nsImportPaths[ns]; ok && g.nsPackages[ns] == nil {
+			addPackage(ns, importPath, packageName(path.Base(importPath)))
+		}
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		ns := schema.TargetNamespace
+		if g.nsPackages[ns] == nil {
+			name := packageName(namespacePrefix(ns))
+			for i := 2; byPath[path.Join(g.importPath, name)] != nil || used[name] || reservedPackageNames[name]; i++ {
+				name = packageName(namespacePrefix(ns)) + strconv.Itoa(i)
+			}
+			addPackage(ns, path.Join(g.importPath, name), name)
+		}
+
+		if pkg := g.nsPackages[ns]; !pkg.external {
+			pkg.schemas = append(pkg.schemas, schema)
+		}
+	}
+}
+
+// packageName turns a namespace prefix or import path element into a package
+// name.
+func packageName(s string) string {
+	name := strings.ToLower(nonIdentifier.ReplaceAllString(s, ""))
+	if name == "" || name[0] >= '0' && name[0] <= '9' {
+		name = "ns" + name
+	}
+	return name
+}
+
+// packageOf returns the package the types of namespace ns are generated in.
+func (g *GoWSDL) packageOf(ns string) *goPackage {
+	if pkg, ok := g.nsPackages[ns]; ok {
+		return pkg
+	}
+	return g.packages[0]
+}
+
+// importSet collects the packages the code generated for pkg refers to.
+type importSet struct {
+	pkg  *goPackage
+	mu   sync.Mutex
+	used map[*goPackage]bool
+}
+
+func newImportSet(pkg *goPackage) *importSet {
+	return &importSet{pkg: pkg, used: make(map[*goPackage]bool)}
+}
+
+// qualify returns the Go name of a type declared in pkg, as referred to from
+// the package of the import set.
+func (s *importSet) qualify(pkg *goPackage, name string) string {
+	if s == nil || pkg == nil || pkg == s.pkg {
+		return name
+	}
+
+	s.mu.Lock()
+	s.used[pkg] = true
+	s.mu.Unlock()
+
+	return pkg.name + "." + name
+}
+
+// goImport is an import declaration of generated code. Name is only set when
+// it differs from the last element of Path.
+type goImport struct {
+	Name string
+	Path string
+}
+
+func (s *importSet) list() []goImport {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var imports []goImport
+	for pkg := range s.used {
+		imp := goImport{Path: pkg.path}
+		if path.Base(pkg.path) != pkg.name {
+			imp.Name = pkg.name
+		}
+		imports = append(imports, imp)
+	}
+	sort.Slice(imports, func(i, j int) bool { return imports[i].Path < imports[j].Path })
+	return imports
+}
@@ -0,0 +1,141 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"strconv"
+	"strings"
+)
+
+// flattenParticles returns copies of the elements and wildcards of the
+// particle p in document order, with the occurrences of the model groups
+// enclosing them applied: the elements of a choice are optional, and the
+// elements of a repeated group are repeated.
+func flattenParticles(p *XSDParticle) []*XSDParticle {
+	return appendParticles(nil, p, "", "", nil)
+}
+
+// appendParticles appends the leaves of p to leaves. The choices keep tells
+// to keep, given their occurrences, are leaves themselves.
+func appendParticles(leaves []*XSDParticle, p *XSDParticle, minOccurs, maxOccurs string, keep func(mg *XSDModelGroup, minOccurs, maxOccurs string) bool) []*XSDParticle {
+	switch {
+	case p == nil:
+	case p.Element != nil:
+		elm := *p.Element
+		elm.MinOccurs = multiplyOccurs(minOccurs, elm.MinOccurs)
+		elm.MaxOccurs = multiplyOccurs(maxOccurs, elm.MaxOccurs)
+		leaves = append(leaves, &XSDParticle{Element: &elm})
+	case p.Any != nil:
+		wildcard := *p.Any
+		wildcard.MinOccurs = multiplyOccurs(minOccurs, wildcard.MinOccurs)
+		wildcard.MaxOccurs = multiplyOccurs(maxOccurs, wildcard.MaxOccurs)
+		leaves = append(leaves, &XSDParticle{Any: &wildcard})
+	case p.ModelGroup != nil:
+		mg := p.ModelGroup
+		minOccurs = multiplyOccurs(minOccurs, mg.MinOccurs)
+		maxOccurs = multiplyOccurs(maxOccurs, mg.MaxOccurs)
+		if mg.XMLName.Local == "choice" && len(mg.Particles) > 1 {
+			if keep != nil && keep(mg, minOccurs, maxOccurs) {
+				return append(leaves, p)
+			}
+			minOccurs = "0"
+		}
+		for _, child := range mg.Particles {
+			leaves = appendParticles(leaves, child, minOccurs, maxOccurs, keep)
+		}
+	}
+	return leaves
+}
+
+// particleElements returns the elements of the particle p in document order,
+// as flattened by flattenParticles.
+func particleElements(p *XSDParticle) []*XSDElement {
+	var elements []*XSDElement
+	for _, leaf := range flattenParticles(p) {
+		if leaf.Element != nil {
+			elements = append(elements, leaf.Element)
+		}
+	}
+	return elements
+}
+
+// multiplyOccurs returns the occurrences of a particle occurring a times in a
+// model group occurring b times. Absent occurrences stand for 1, and are
+// kept absent.
+func multiplyOccurs(a, b string) string {
+	switch {
+	case a == "" || a == "1":
+		return b
+	case b == "" || b == "1":
+		return a
+	case a == "0" || b == "0":
+		return "0"
+	case a == "unbounded" || b == "unbounded":
+		return "unbounded"
+	}
+
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return b
+	}
+	return strconv.Itoa(x * y)
+}
+
+// repeated tells whether a particle occurring up to maxOccurs times is
+// generated as a slice.
+func repeated(maxOccurs string) bool {
+	return maxOccurs != "" && maxOccurs != "0" && maxOccurs != "1"
+}
+
+// occursType returns the Go type of the field holding the element elm, whose
+// values are of type goType: a slice when elm may occur more than once, or a
+// pointer when it may be absent and goType has no nil value.
+func occursType(goType string, elm *XSDElement) string {
+	switch {
+	case repeated(elm.MaxOccurs):
+		return "[]" + goType
+	case elm.MinOccurs == "0" && !hasNil(goType):
+		return "*" + goType
+	}
+	return goType
+}
+
+// elementFieldType returns the Go type of the field holding the element elm,
+// of the type elm.Type, in the schema being generated. Like built-in types,
+// declared simple types are held by value unless elm is nillable, and by
+// pointer when it may be absent.
+func (g *GoWSDL) elementFieldType(elm *XSDElement) string {
+	xmlns := g.currentSchema.Xmlns
+	goType := g.goFieldType(elm.Type, xmlns, elm.Nillable, g.currentImports)
+	if _, st := g.findSimpleType(resolveQName(elm.Type, xmlns)); st != nil && !elm.Nillable {
+		goType = strings.TrimPrefix(goType, "*")
+	}
+	return occursType(goType, elm)
+}
+
+// hasNil tells whether the Go type goType has a nil value.
+func hasNil(goType string) bool {
+	return strings.HasPrefix(goType, "*") || strings.HasPrefix(goType, "[]") || goType == "interface{}"
+}
+
+// omitEmpty returns the omitempty option of the tags of the field holding the
+// element elm when elm may be absent. Required elements are always encoded,
+// zero values included.
+func omitEmpty(elm *XSDElement) string {
+	if elm.MinOccurs == "0" {
+		return ",omitempty"
+	}
+	return ""
+}
+
+// attributeOmitEmpty returns the omitempty option of the tags of the field
+// holding the attribute attr, unless attr is required.
+func attributeOmitEmpty(attr *XSDAttribute) string {
+	if attr.Use == "required" {
+		return ""
+	}
+	return ",omitempty"
+}
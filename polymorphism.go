@@ -0,0 +1,152 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+// polymorphicType describes a complex type of a type hierarchy, whose values
+// may stand for one another with xsi:type.
+type polymorphicType struct {
+	// Name is the QName of the type.
+	Name xml.Name
+	// GoName is the name of the Go type generated for it.
+	GoName string
+	// Interface and Holder are the names of the interface implemented by the
+	// type and the ones derived from it, and of the struct holding any of
+	// them. They are empty for types no other type is derived from.
+	Interface string
+	Holder    string
+
+	pkg    *goPackage
+	parent *polymorphicType
+}
+
+// ancestorMethod is a method giving access to an ancestor of a derived type.
+type ancestorMethod struct {
+	// Method is the name of the method, Type the Go type of the ancestor.
+	Method string
+	Type   string
+	// Parent and ParentType are the name and the type of the field embedding
+	// the type the derived type extends.
+	Parent     string
+	ParentType string
+	// Direct tells whether the ancestor is the type the derived type extends.
+	Direct bool
+}
+
+// collectPolymorphicTypes returns the complex types which are abstract or
+// extended by other ones, along with the types derived from them.
+func (g *GoWSDL) collectPolymorphicTypes(uniqueName func(*goPackage, string) string) map[*XSDComplexType]*polymorphicType {
+	types := make(map[*XSDComplexType]*polymorphicType)
+
+	polymorphic := func(schema *XSDSchema, ct *XSDComplexType) *polymorphicType {
+		if p, ok := types[ct]; ok {
+			return p
+		}
+		if g.soapArrays[ct] != nil || ct.SimpleContent.Extension.Base != "" || ct.SimpleContent.Restriction.Base != "" {
+			return nil
+		}
+
+		name := xml.Name{Space: schema.TargetNamespace, Local: ct.Name}
+		_, goName, _ := g.symbols.lookup(typeSymbol, name)
+		p := &polymorphicType{Name: name, GoName: goName, pkg: g.packageOf(name.Space)}
+		types[ct] = p
+		return p
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			if ct.Abstract {
+				polymorphic(schema, ct)
+			}
+
+			base := ct.ComplexContent.Extension.Base
+			if base == "" {
+				continue
+			}
+			baseSchema, baseType := g.findComplexType(resolveQName(base, schema.Xmlns))
+			if baseType == nil {
+				continue
+			}
+			if parent := polymorphic(baseSchema, baseType); parent != nil {
+				if p := polymorphic(schema, ct); p != nil {
+					p.parent = parent
+				}
+			}
+		}
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, ct := range schema.ComplexTypes {
+			p := types[ct]
+			if p == nil || !ct.Abstract && !isParent(types, p) {
+				continue
+			}
+			p.Interface = uniqueName(p.pkg, "Base"+p.GoName)
+			p.Holder = uniqueName(p.pkg, "Any"+p.GoName)
+		}
+	}
+
+	return types
+}
+
+func isParent(types map[*XSDComplexType]*polymorphicType, parent *polymorphicType) bool {
+	for _, p := range types {
+		if p.parent == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// findPolymorphicType returns the description of ct when it is part of a type
+// hierarchy and polymorphic types are generated.
+func (g *GoWSDL) findPolymorphicType(ct *XSDComplexType) *polymorphicType {
+	return g.polymorphicTypes[ct]
+}
+
+// ancestorMethods returns the methods giving access to the ancestors of the
+// derived type ct, generated in the package being generated.
+func (g *GoWSDL) ancestorMethods(ct *XSDComplexType) []ancestorMethod {
+	p := g.polymorphicTypes[ct]
+	if p == nil || p.parent == nil {
+		return nil
+	}
+
+	parentType := g.currentImports.qualify(p.parent.pkg, p.parent.GoName)
+	var methods []ancestorMethod
+	for a := p.parent; a != nil && len(methods) < int(maxRecursion); a = a.parent {
+		methods = append(methods, ancestorMethod{
+			Method:     "Get" + a.GoName,
+			Type:       g.currentImports.qualify(a.pkg, a.GoName),
+			Parent:     p.parent.GoName,
+			ParentType: parentType,
+			Direct:     a == p.parent,
+		})
+	}
+	return methods
+}
+
+// goFieldType returns the Go type of a field of type name: the holder of the
+// type and the ones derived from it for polymorphic types, or the one given
+// by goType otherwise.
+func (g *GoWSDL) goFieldType(name string, xmlns map[string]string, nillable bool, imports *importSet) string {
+	goType := g.goType(name, xmlns, nillable, imports)
+	if len(g.polymorphicTypes) == 0 || !strings.HasPrefix(goType, "*") {
+		return goType
+	}
+
+	schema, ct := g.findComplexType(resolveQName(name, xmlns))
+	if ct == nil {
+		return goType
+	}
+	if p := g.polymorphicTypes[ct]; p != nil && p.Holder != "" {
+		return "*" + imports.qualify(g.packageOf(schema.TargetNamespace), p.Holder)
+	}
+	return goType
+}
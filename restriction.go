@@ -0,0 +1,192 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// findComplexType returns the global complex type name refers to and the
+// schema declaring it.
+func (g *GoWSDL) findComplexType(name xml.Name) (*XSDSchema, *XSDComplexType) {
+	for _, schema := range g.wsdl.Types.Schemas {
+		if schema.TargetNamespace != name.Space {
+			continue
+		}
+		for _, ct := range schema.ComplexTypes {
+			if ct.Name == name.Local {
+				return schema, ct
+			}
+		}
+	}
+	return nil, nil
+}
+
+// findSimpleType returns the global simple type name refers to and the
+// schema declaring it.
+func (g *GoWSDL) findSimpleType(name xml.Name) (*XSDSchema, *XSDSimpleType) {
+	for _, schema := range g.wsdl.Types.Schemas {
+		if schema.TargetNamespace != name.Space {
+			continue
+		}
+		for _, st := range schema.SimpleType {
+			if st.Name == name.Local {
+				return schema, st
+			}
+		}
+	}
+	return nil, nil
+}
+
+// restrictionAttributes returns the attributes of a type derived by
+// restriction from a complex type of the schema being generated: the ones of
+// its base type, overridden by the ones declared by the restriction, less the
+// prohibited ones.
+func (g *GoWSDL) restrictionAttributes(res XSDRestriction) []*XSDAttribute {
+	return g.derivedAttributes(g.currentSchema, &res, 0)
+}
+
+func (g *GoWSDL) derivedAttributes(schema *XSDSchema, res *XSDRestriction, depth uint8) []*XSDAttribute {
+	var attrs []*XSDAttribute
+	if baseSchema, base := g.findComplexType(resolveQName(res.Base, schema.Xmlns)); base != nil && depth < maxRecursion {
+		t := newTraverser(schema, g.wsdl.Types.Schemas)
+		for _, attr := range g.typeAttributes(baseSchema, base, depth+1) {
+			a := *attr
+			a.Type = t.requalify(a.Type, baseSchema)
+			attrs = append(attrs, &a)
+		}
+	}
+
+	for _, attr := range res.Attributes {
+		i := 0
+		for _, a := range attrs {
+			if a.Name != attr.Name {
+				attrs[i] = a
+				i++
+			}
+		}
+		attrs = attrs[:i]
+		if attr.Use != "prohibited" {
+			attrs = append(attrs, attr)
+		}
+	}
+	return attrs
+}
+
+// typeAttributes returns all the attributes of a complex type, including the
+// ones it derives from its base type.
+func (g *GoWSDL) typeAttributes(schema *XSDSchema, ct *XSDComplexType, depth uint8) []*XSDAttribute {
+	switch {
+	case ct.ComplexContent.Restriction.Base != "":
+		return g.derivedAttributes(schema, &ct.ComplexContent.Restriction, depth)
+	case ct.SimpleContent.Restriction.Base != "":
+		return g.derivedAttributes(schema, &ct.SimpleContent.Restriction, depth)
+	}
+
+	ext := ct.ComplexContent.Extension
+	if ext.Base == "" {
+		ext = ct.SimpleContent.Extension
+	}
+	if ext.Base == "" {
+		return ct.Attributes
+	}
+
+	var attrs []*XSDAttribute
+	if baseSchema, base := g.findComplexType(resolveQName(ext.Base, schema.Xmlns)); base != nil && depth < maxRecursion {
+		t := newTraverser(schema, g.wsdl.Types.Schemas)
+		for _, attr := range g.typeAttributes(baseSchema, base, depth+1) {
+			a := *attr
+			a.Type = t.requalify(a.Type, baseSchema)
+			attrs = append(attrs, &a)
+		}
+	}
+	return append(attrs, ext.Attributes...)
+}
+
+// simpleContentType returns the Go type of the value of a complex type with
+// simple content, derived from base in the schema being generated.
+func (g *GoWSDL) simpleContentType(base string) string {
+	return removePointerFromType(g.goType(g.simpleContentBase(base), g.currentSchema.Xmlns, false, g.currentImports))
+}
+
+// simpleContentBase returns the simple type of the value of a complex type
+// with simple content, derived from base in the schema being generated.
+func (g *GoWSDL) simpleContentBase(base string) string {
+	t := newTraverser(g.currentSchema, g.wsdl.Types.Schemas)
+	for depth := uint8(0); depth < maxRecursion; depth++ {
+		baseSchema, ct := g.findComplexType(resolveQName(base, g.currentSchema.Xmlns))
+		if ct == nil {
+			break
+		}
+
+		next := ct.SimpleContent.Extension.Base
+		if next == "" {
+			next = ct.SimpleContent.Restriction.Base
+		}
+		if next == "" {
+			break
+		}
+		base = t.requalify(next, baseSchema)
+	}
+	return base
+}
+
+// facetsDoc describes the facets of a restriction, other than enumerations.
+func facetsDoc(res XSDRestriction) string {
+	var facets []string
+	for _, facet := range []struct {
+		name  string
+		value XSDRestrictionValue
+	}{
+		{"pattern", res.Pattern},
+		{"length", res.Length},
+		{"minLength", res.MinLength},
+		{"maxLength", res.MaxLength},
+		{"minInclusive", res.MinInclusive},
+		{"maxInclusive", res.MaxInclusive},
+		{"minExclusive", res.MinExclusive},
+		{"maxExclusive", res.MaxExclusive},
+		{"totalDigits", res.TotalDigits},
+		{"fractionDigits", res.FractionDigits},
+	} {
+		if facet.value.Value != "" {
+			facets = append(facets, fmt.Sprintf("%s %s", facet.name, facet.value.Value))
+		}
+	}
+	return strings.Join(facets, ", ")
+}
+
+// goLiteral returns value as a Go literal of type goType. Numbers and
+// booleans are written the way Go spells them, so that 010 stays ten.
+func goLiteral(value, goType string) string {
+	text := strings.TrimSpace(value)
+	switch goType {
+	case "bool":
+		if b, err := strconv.ParseBool(text); err == nil {
+			return strconv.FormatBool(b)
+		}
+		return value
+	case "int", "int8", "int16", "int32", "int64":
+		if n, err := strconv.ParseInt(text, 10, 64); err == nil {
+			return strconv.FormatInt(n, 10)
+		}
+		return value
+	case "byte", "uint", "uint8", "uint16", "uint32", "uint64":
+		if n, err := strconv.ParseUint(strings.TrimPrefix(text, "+"), 10, 64); err == nil {
+			return strconv.FormatUint(n, 10)
+		}
+		return value
+	case "float32", "float64":
+		if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) {
+			return strconv.FormatFloat(f, 'g', -1, 64)
+		}
+		return value
+	}
+	return `"` + goString(value) + `"`
+}
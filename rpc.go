@@ -0,0 +1,151 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"log"
+	"sort"
+)
+
+// rpcWrapper describes the element wrapping the parts of a message sent or
+// received by an RPC style operation. The wrapper is named after the
+// operation and qualified by the namespace of its soap:body. Wrappers of
+// encoded operations are written by the SOAP encoding of the soap package.
+type rpcWrapper struct {
+	Name      string
+	Element   string
+	Namespace string
+	Encoded   bool
+	Parts     []*rpcPart
+}
+
+// rpcPart is a message part rendered as a child of its wrapper: unqualified
+// when the part has a type, and qualified by the namespace of its element
+// otherwise.
+type rpcPart struct {
+	Name      string
+	JSONName  string
+	Element   string
+	Namespace string
+	Type      string
+}
+
+// collectRPCWrappers returns the wrappers of all messages used by RPC style
+// operations, keyed by message name.
+func (g *GoWSDL) collectRPCWrappers() map[string]*rpcWrapper {
+	wrappers := make(map[string]*rpcWrapper)
+
+	for _, binding := range g.wsdl.Binding {
+		portType := g.findPortType(stripns(binding.Type))
+		if portType == nil {
+			continue
+		}
+
+		for _, bindingOp := range binding.Operations {
+			if findOperationStyle(binding, bindingOp) != "rpc" {
+				continue
+			}
+
+			for _, op := range portType.Operations {
+				if op.Name != bindingOp.Name {
+					continue
+				}
+
+				input, output := bindingOp.Input, bindingOp.Output
+				ns := bindingBodyNamespace(input.SOAPBody, input.SOAP12Body, g.wsdl.TargetNamespace)
+				g.addRPCWrapper(wrappers, op.Input.Message, op.Name, ns, isEncodedBody(input.SOAPBody, input.SOAP12Body))
+
+				ns = bindingBodyNamespace(output.SOAPBody, output.SOAP12Body, g.wsdl.TargetNamespace)
+				g.addRPCWrapper(wrappers, op.Output.Message, op.Name+"Response", ns, isEncodedBody(output.SOAPBody, output.SOAP12Body))
+			}
+		}
+	}
+
+	return wrappers
+}
+
+func (g *GoWSDL) addRPCWrapper(wrappers map[string]*rpcWrapper, message, element, ns string, encoded bool) {
+	message = stripns(message)
+	if message == "" {
+		return
+	}
+
+	if w, ok := wrappers[message]; ok {
+		if w.Element != element {
+			log.Printf("[WARN] %s message is used by several RPC operations, wrapping it in %s", message, w.Element)
+		}
+		return
+	}
+
+	w := &rpcWrapper{
+		Name:      g.names.declareType(g.packages[0], "message", message, g.makePublicFn(replaceReservedWords(message))),
+		Element:   element,
+		Namespace: ns,
+		Encoded:   encoded,
+	}
+	fields := newStructFields(w.Name, "XMLName", "MarshalXML")
+
+	for _, msg := range g.wsdl.Messages {
+		if msg.Name != message {
+			continue
+		}
+
+		for _, part := range msg.Parts {
+			key := fieldKey{name: part.Name}
+			fields.declare(g, key, normalize(part.Name))
+			p := &rpcPart{
+				Name:     fields.names[key],
+				JSONName: fields.jsonKeys[key],
+				Element:  part.Name,
+			}
+			if part.Type != "" {
+				p.Type = g.goType(part.Type, g.wsdl.Xmlns, false, g.packages[0].imports)
+			} else {
+				qname := resolveQName(part.Element, g.wsdl.Xmlns)
+				p.Element, p.Namespace = qname.Local, qname.Space
+				p.Type = g.goElementType(part.Element, g.wsdl.Xmlns, false, g.packages[0].imports)
+			}
+			w.Parts = append(w.Parts, p)
+		}
+	}
+
+	wrappers[message] = w
+}
+
+// sortedRPCWrappers returns the RPC wrappers ordered by name, so they are
+// generated in a stable order.
+func (g *GoWSDL) sortedRPCWrappers() []*rpcWrapper {
+	wrappers := make([]*rpcWrapper, 0, len(g.rpcWrappers))
+	for _, w := range g.rpcWrappers {
+		wrappers = append(wrappers, w)
+	}
+
+	sort.Slice(wrappers, func(i, j int) bool {
+		return wrappers[i].Name < wrappers[j].Name
+	})
+
+	return wrappers
+}
+
+func (g *GoWSDL) findPortType(name string) *WSDLPortType {
+	for _, pt := range g.wsdl.PortTypes {
+		if pt.Name == name {
+			return pt
+		}
+	}
+	return nil
+}
+
+// bindingBodyNamespace returns the namespace of a SOAP 1.1 or SOAP 1.2 body,
+// falling back to the given default namespace.
+func bindingBodyNamespace(body, body12 WSDLSOAPBody, defaultNS string) string {
+	if body.Namespace != "" {
+		return body.Namespace
+	}
+	if body12.Namespace != "" {
+		return body12.Namespace
+	}
+	return defaultNS
+}
@@ -0,0 +1,42 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+var rpcTmpl = `
+{{range .}}
+	type {{.Name}} struct {
+		XMLName xml.Name ` + "`" + `xml:"{{if .Encoded}}{{.Namespace}} {{end}}{{.Element}}"` + "`" + `
+		{{range .Parts}}
+			{{.Name}} {{.Type}} ` + "`" + `xml:"{{.Element}}" json:"{{.JSONName}}"` + "`" + `
+		{{end}}
+	}
+	{{if not .Encoded}}
+	{{$ns := .Namespace}}
+
+	// MarshalXML encodes {{.Name}} as an RPC wrapper element: the wrapper is
+	// qualified by the operation namespace while its parts are unqualified,
+	// unless they are elements.
+	func (m {{.Name}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		start = xml.StartElement{Name: xml.Name{Space: "{{$ns}}", Local: "{{.Element}}"}}
+		if err := e.EncodeToken(start); err != nil {
+			return err
+		}
+		{{range .Parts}}
+			{{if or .Namespace (not $ns)}}
+				if err := e.EncodeElement(m.{{.Name}}, xml.StartElement{Name: xml.Name{Space: "{{.Namespace}}", Local: "{{.Element}}"}}); err != nil {
+					return err
+				}
+			{{else}}
+				// The empty default namespace keeps the part unqualified.
+				if err := e.EncodeElement(m.{{.Name}}, xml.StartElement{Name: xml.Name{Local: "{{.Element}}"}, Attr: []xml.Attr{ {Name: xml.Name{Local: "xmlns"} } } }); err != nil {
+					return err
+				}
+			{{end}}
+		{{end}}
+		return e.EncodeToken(start.End())
+	}
+	{{end}}
+{{end}}
+`
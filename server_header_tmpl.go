@@ -3,7 +3,7 @@ package gowsdl
 var serverHeaderTmpl = `
 // Code generated by gowsdl DO NOT EDIT.
 
-package {{.}}
+package {{.Package}}
 
 import (
 	"fmt"
@@ -13,9 +13,9 @@ import (
 	"encoding/xml"
 	"net/http"
 
-	{{/*range .Imports*/}}
-		{{/*.*/}}
-	{{/*end*/}}
+	{{range .Imports}}
+		{{.Name}} "{{.Path}}"
+	{{end}}
 )
 
 `
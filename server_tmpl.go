@@ -14,7 +14,7 @@ type SOAPBodyRequest struct {
 	{{range .}}
 		{{range .Operations}}
 				{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}} ` + `
-  				{{$requestType}} *{{$requestType}} ` + "`" + `xml:,omitempty` + "`" + `
+  				{{$requestType}} *{{qualify .Input.Message $requestType}} ` + "`" + `xml:",omitempty"` + "`" + `
 		{{end}}
 	{{end}}
 }
@@ -52,7 +52,7 @@ type SOAPBodyResponse struct { ` + `
 	Fault   *Fault ` + "`" + `xml:",omitempty"` + "`" + `
 {{range .}}
 	{{range .Operations}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic | qualify .Output.Message}}
 		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}} ` + `
 			{{$requestType}} *{{$responseType}} ` + "`" + `xml:",omitempty"` + "`" + `
 	{{end}}
@@ -62,10 +62,10 @@ type SOAPBodyResponse struct { ` + `
 
 {{range .}}
 	{{range .Operations}}
-		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic}}
+		{{$responseType := findType .Output.Message | replaceReservedWords | makePublic | qualify .Output.Message}}
 		{{$requestType := findType .Input.Message | replaceReservedWords | makePublic}}
 		{{$requestTypeSource := findType .Input.Message | replaceReservedWords }}
-func (service *SOAPBodyRequest) {{$requestType}}Func(request *{{$requestType}}) (*{{$responseType}}, error) {
+func (service *SOAPBodyRequest) {{$requestType}}Func(request *{{qualify .Input.Message $requestType}}) (*{{$responseType}}, error) {
 	return nil, WSDLUndefinedError
 }
 	{{end}}
@@ -0,0 +1,352 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// xmlNamespace is the namespace the xml prefix is bound to.
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// AnyElement holds an element an xs:any wildcard allows as it was read: its
+// name, its attributes and its content. It is written back as it was read, so
+// that the extensions a schema leaves room for survive a round trip.
+type AnyElement struct {
+	XMLName xml.Name
+	// Attrs holds the attributes of the element, namespace declarations
+	// included.
+	Attrs []xml.Attr `json:"attrs,omitempty"`
+	// InnerXML holds the content of the element, which declares the
+	// namespaces it uses but the ones the element itself is in or declares.
+	InnerXML string `json:"innerXML,omitempty"`
+}
+
+// UnmarshalXML reads the element start into a.
+func (a *AnyElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	a.XMLName = start.Name
+	a.Attrs = append([]xml.Attr(nil), start.Attr...)
+
+	w := newInnerWriter(start.Name, start.Attr)
+	for depth := 0; ; {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			w.writeStart(t)
+		case xml.EndElement:
+			if depth == 0 {
+				a.InnerXML = w.buf.String()
+				return nil
+			}
+			depth--
+			w.writeEnd()
+		case xml.CharData:
+			escapeText(&w.buf, string(t))
+		case xml.Comment:
+			w.buf.WriteString("<!--")
+			w.buf.Write(t)
+			w.buf.WriteString("-->")
+		case xml.ProcInst:
+			w.buf.WriteString("<?" + t.Target)
+			if len(t.Inst) > 0 {
+				w.buf.WriteByte(' ')
+				w.buf.Write(t.Inst)
+			}
+			w.buf.WriteString("?>")
+		case xml.Directive:
+			w.buf.WriteString("<!")
+			w.buf.Write(t)
+			w.buf.WriteByte('>')
+		}
+	}
+}
+
+// MarshalXML writes a as it was read. The name of start is ignored, since a
+// holds the name of its element.
+func (a AnyElement) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	if a.XMLName.Local == "" {
+		return nil
+	}
+
+	// The element is written without a prefix, in the namespace its content
+	// was written for.
+	w := newInnerWriter(a.XMLName, a.Attrs)
+	start := xml.StartElement{
+		Name: xml.Name{Local: a.XMLName.Local},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: a.XMLName.Space}},
+	}
+	var attrs []xml.Attr
+	for _, attr := range a.Attrs {
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			continue
+		}
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: w.attrName(attr.Name, &start.Attr)}, Value: attr.Value})
+	}
+	start.Attr = append(start.Attr, attrs...)
+
+	return e.EncodeElement(struct {
+		InnerXML string `xml:",innerxml"`
+	}{a.InnerXML}, start)
+}
+
+// Set sets a to the element v is encoded as: the global element its Go type
+// is registered for with RegisterElement, or else the element encoding/xml
+// names after v.
+func (a *AnyElement) Set(v interface{}) error {
+	var buf bytes.Buffer
+	e := xml.NewEncoder(&buf)
+
+	elements.RLock()
+	name, ok := elements.byType[reflect.TypeOf(v)]
+	elements.RUnlock()
+	var err error
+	if ok {
+		err = e.EncodeElement(v, xml.StartElement{Name: name})
+	} else {
+		err = e.Encode(v)
+	}
+	if err == nil {
+		err = e.Flush()
+	}
+	if err != nil {
+		return err
+	}
+
+	var elm AnyElement
+	if err := xml.Unmarshal(buf.Bytes(), &elm); err != nil {
+		return err
+	}
+	*a = elm
+	return nil
+}
+
+// decode decodes the element a holds into a new value of the Go type
+// registered for its name, or returns nil when none is.
+func (a *AnyElement) decode() (interface{}, error) {
+	elements.RLock()
+	alloc, ok := elements.byName[a.XMLName]
+	elements.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := xml.Marshal(a)
+	if err != nil {
+		return nil, err
+	}
+	value := alloc()
+	if err := xml.Unmarshal(data, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// LaxElement holds an element an xs:any wildcard whose processContents is lax
+// allows. Beside being kept as an AnyElement, the elements registered with
+// RegisterElement are decoded into Value.
+type LaxElement struct {
+	AnyElement
+	// Value holds a copy of the element, decoded into a new value of the Go
+	// type registered for its name, or nil when none is. The element is
+	// written as the AnyElement holds it, which Set changes as well.
+	Value interface{} `xml:"-" json:"-"`
+}
+
+// UnmarshalXML reads the element start into l, decoding its Value.
+func (l *LaxElement) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	if err := l.AnyElement.UnmarshalXML(d, start); err != nil {
+		return err
+	}
+	value, err := l.decode()
+	if err != nil {
+		return err
+	}
+	l.Value = value
+	return nil
+}
+
+// Set sets l to the element v is encoded as, as AnyElement.Set does, and its
+// Value to v.
+func (l *LaxElement) Set(v interface{}) error {
+	if err := l.AnyElement.Set(v); err != nil {
+		return err
+	}
+	l.Value = v
+	return nil
+}
+
+// Validate validates the Value of l, if any.
+func (l LaxElement) Validate() error {
+	return Validate(l.Value)
+}
+
+// StrictElement holds an element an xs:any wildcard whose processContents is
+// strict allows. It is a LaxElement whose Validate method also reports the
+// elements which aren't registered with RegisterElement.
+type StrictElement struct {
+	LaxElement
+}
+
+// Validate validates the Value of s, which is required.
+func (s StrictElement) Validate() error {
+	if s.Value == nil {
+		return fmt.Errorf("element %s is not declared", clarkName(s.XMLName))
+	}
+	return s.LaxElement.Validate()
+}
+
+// clarkName returns name as {namespace}local.
+func clarkName(name xml.Name) string {
+	if name.Space == "" {
+		return name.Local
+	}
+	return "{" + name.Space + "}" + name.Local
+}
+
+// nsBinding binds a namespace prefix, empty for the default namespace, to a
+// namespace.
+type nsBinding struct {
+	prefix, url string
+}
+
+// innerWriter writes the content of an element read by a decoder, which gave
+// the namespaces of its names rather than their prefixes, declaring the
+// namespaces no open element declares.
+type innerWriter struct {
+	buf      bytes.Buffer
+	bindings []nsBinding
+	// scopes holds the number of bindings before each open element, and
+	// names the names they are written with.
+	scopes []int
+	names  []string
+}
+
+// newInnerWriter returns a writer of the content of an element named name,
+// declaring the namespaces of attrs, and written without a prefix.
+func newInnerWriter(name xml.Name, attrs []xml.Attr) *innerWriter {
+	w := new(innerWriter)
+	w.bindDeclared(attrs)
+	w.bindings = append(w.bindings, nsBinding{"", name.Space})
+	return w
+}
+
+// bindDeclared binds the prefixes the namespace declarations among attrs
+// declare.
+func (w *innerWriter) bindDeclared(attrs []xml.Attr) {
+	for _, attr := range attrs {
+		switch {
+		case attr.Name.Space == "xmlns":
+			w.bindings = append(w.bindings, nsBinding{attr.Name.Local, attr.Value})
+		case attr.Name.Space == "" && attr.Name.Local == "xmlns":
+			w.bindings = append(w.bindings, nsBinding{"", attr.Value})
+		}
+	}
+}
+
+// lookup returns the namespace prefix is bound to.
+func (w *innerWriter) lookup(prefix string) (string, bool) {
+	for i := len(w.bindings) - 1; i >= 0; i-- {
+		if w.bindings[i].prefix == prefix {
+			return w.bindings[i].url, true
+		}
+	}
+	return "", false
+}
+
+// prefixOf returns a prefix other than the empty one bound to url.
+func (w *innerWriter) prefixOf(url string) (string, bool) {
+	for i := len(w.bindings) - 1; i >= 0; i-- {
+		b := w.bindings[i]
+		if b.prefix == "" || b.url != url {
+			continue
+		}
+		if bound, _ := w.lookup(b.prefix); bound == url {
+			return b.prefix, true
+		}
+	}
+	return "", false
+}
+
+// attrName returns the name an attribute named name is written with,
+// appending to decls the declaration of the prefix of its namespace when
+// none is bound to it yet.
+func (w *innerWriter) attrName(name xml.Name, decls *[]xml.Attr) string {
+	switch name.Space {
+	case "":
+		return name.Local
+	case "xmlns":
+		return "xmlns:" + name.Local
+	case xmlNamespace:
+		return "xml:" + name.Local
+	}
+
+	prefix, ok := w.prefixOf(name.Space)
+	if !ok {
+		for n := 1; ; n++ {
+			prefix = "ns" + strconv.Itoa(n)
+			if _, ok := w.lookup(prefix); !ok {
+				break
+			}
+		}
+		w.bindings = append(w.bindings, nsBinding{prefix, name.Space})
+		*decls = append(*decls, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: name.Space})
+	}
+	return prefix + ":" + name.Local
+}
+
+func (w *innerWriter) writeStart(t xml.StartElement) {
+	w.scopes = append(w.scopes, len(w.bindings))
+	w.bindDeclared(t.Attr)
+
+	var decls []xml.Attr
+	name := t.Name.Local
+	if def, _ := w.lookup(""); t.Name.Space != def {
+		if prefix, ok := w.prefixOf(t.Name.Space); ok && t.Name.Space != "" {
+			name = prefix + ":" + name
+		} else {
+			w.bindings = append(w.bindings, nsBinding{"", t.Name.Space})
+			decls = append(decls, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: t.Name.Space})
+		}
+	}
+	attrs := make([]xml.Attr, len(t.Attr))
+	for i, attr := range t.Attr {
+		attrs[i] = xml.Attr{Name: xml.Name{Local: w.attrName(attr.Name, &decls)}, Value: attr.Value}
+	}
+
+	w.names = append(w.names, name)
+	w.buf.WriteString("<" + name)
+	for _, attr := range append(decls, attrs...) {
+		w.buf.WriteString(" " + attr.Name.Local + `="`)
+		xml.EscapeText(&w.buf, []byte(attr.Value))
+		w.buf.WriteByte('"')
+	}
+	w.buf.WriteByte('>')
+}
+
+func (w *innerWriter) writeEnd() {
+	n := len(w.names) - 1
+	w.buf.WriteString("</" + w.names[n] + ">")
+	w.names = w.names[:n]
+	w.bindings = w.bindings[:w.scopes[n]]
+	w.scopes = w.scopes[:n]
+}
+
+// textEscaper escapes the characters of text content which can't be written
+// as such, keeping line feeds and tabs unlike xml.EscapeText.
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+
+func escapeText(buf *bytes.Buffer, text string) {
+	textEscaper.WriteString(buf, text)
+}
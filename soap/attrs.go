@@ -0,0 +1,39 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import "encoding/xml"
+
+// AnyAttrs holds the attributes of an element no other field of its type is
+// named after, those an xs:anyAttribute wildcard allows. Unlike a plain
+// []xml.Attr, it leaves out the namespace declarations, which encoding/xml
+// would otherwise write back as attributes of their own, and the xsi
+// attributes, which no wildcard matches.
+type AnyAttrs []xml.Attr
+
+// UnmarshalXMLAttr adds attr to a, unless it declares a namespace or is an
+// xsi attribute.
+func (a *AnyAttrs) UnmarshalXMLAttr(attr xml.Attr) error {
+	if declaresNamespace(attr) || attr.Name.Space == XmlNsXsi {
+		return nil
+	}
+	*a = append(*a, attr)
+	return nil
+}
+
+// Get returns the value of the attribute name, and whether a holds it.
+func (a AnyAttrs) Get(name xml.Name) (string, bool) {
+	for _, attr := range a {
+		if attr.Name == name {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// declaresNamespace tells whether attr is a namespace declaration.
+func declaresNamespace(attr xml.Attr) bool {
+	return attr.Name.Space == "xmlns" || attr.Name.Space == "" && attr.Name.Local == "xmlns"
+}
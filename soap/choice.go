@@ -0,0 +1,149 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ChoiceBranch describes a branch of a choice held by a Choice.
+type ChoiceBranch struct {
+	// Name is the name of the branch.
+	Name string
+	// Element is the local name of the element of a branch made of a single
+	// element. It is empty for branches made of a group of elements, whose
+	// Go value is a struct holding them.
+	Element string
+	// New returns a pointer to a new Go value of the branch.
+	New func() interface{}
+}
+
+// Choice holds the branch of a choice set or decoded last, among the branches
+// described by the generated choice type embedding it.
+type Choice struct {
+	// branch is the index of the branch plus one, zero when there is none.
+	branch int
+	value  interface{}
+}
+
+// Branch returns the name of the branch c holds, or an empty string when it
+// holds none.
+func (c *Choice) Branch(branches []ChoiceBranch) string {
+	if c.branch == 0 {
+		return ""
+	}
+	return branches[c.branch-1].Name
+}
+
+// Value returns the pointer to the value of the branch i, or nil when c holds
+// another branch.
+func (c *Choice) Value(i int) interface{} {
+	if c.branch != i+1 {
+		return nil
+	}
+	return c.value
+}
+
+// Set makes c hold the branch i, whose value is the pointer value.
+func (c *Choice) Set(i int, value interface{}) {
+	c.branch, c.value = i+1, value
+}
+
+// Unmarshal decodes the element start into the branch it belongs to. It fails
+// when c holds another branch, since a choice holds exactly one. Elements of
+// no branch are skipped.
+func (c *Choice) Unmarshal(d *xml.Decoder, start xml.StartElement, branches []ChoiceBranch) error {
+	i, field := branchOf(branches, start.Name.Local)
+	if i < 0 {
+		return d.Skip()
+	}
+	if c.branch != 0 && c.branch != i+1 {
+		return fmt.Errorf("soap: element %s of branch %s conflicts with branch %s of the same choice",
+			start.Name.Local, branches[i].Name, branches[c.branch-1].Name)
+	}
+
+	if c.value == nil || reflect.ValueOf(c.value).IsNil() {
+		c.Set(i, branches[i].New())
+	}
+	if field == nil {
+		return d.DecodeElement(c.value, &start)
+	}
+	fv, _ := fieldByIndex(reflect.ValueOf(c.value).Elem(), field.index)
+	return d.DecodeElement(fv.Addr().Interface(), &start)
+}
+
+// Marshal encodes the branch c holds: the element of single element branches,
+// or the elements of group branches. It fails when c holds no branch.
+func (c *Choice) Marshal(e *xml.Encoder, branches []ChoiceBranch) error {
+	return c.marshal(xmlEncoder{e}, branches)
+}
+
+func (c *Choice) marshal(e elementEncoder, branches []ChoiceBranch) error {
+	if c.branch == 0 {
+		return fmt.Errorf("soap: no branch of the choice between %s is set", branchNames(branches))
+	}
+	branch := branches[c.branch-1]
+	v := reflect.ValueOf(c.value)
+	if v.IsNil() {
+		return nil
+	}
+
+	if branch.Element != "" {
+		return e.encodeElement(v, xml.StartElement{Name: xml.Name{Local: branch.Element}})
+	}
+	v = v.Elem()
+	for _, f := range structFields(v.Type()) {
+		fv, ok := fieldByIndex(v, f.index)
+		if f.attr || f.charData || f.innerXML || !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		if err := e.encodeElement(fv, xml.StartElement{Name: f.name}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate checks that c holds a branch, and validates its value with its
+// Validate method, if it has one.
+func (c *Choice) Validate(branches []ChoiceBranch) error {
+	var errs Validation
+	if c.branch == 0 {
+		errs.Add("", "no branch of the choice between %s is set", branchNames(branches))
+	} else {
+		errs.Check(branches[c.branch-1].Name, c.value)
+	}
+	return errs.Err()
+}
+
+// branchOf returns the index of the branch the element named local belongs
+// to, along with its field in the struct of group branches, or -1.
+func branchOf(branches []ChoiceBranch, local string) (int, *fieldInfo) {
+	for i, branch := range branches {
+		if branch.Element != "" {
+			if branch.Element == local {
+				return i, nil
+			}
+			continue
+		}
+		for _, f := range structFields(reflect.TypeOf(branch.New()).Elem()) {
+			if !f.attr && f.name.Local == local {
+				return i, &f
+			}
+		}
+	}
+	return -1, nil
+}
+
+func branchNames(branches []ChoiceBranch) string {
+	names := make([]string, len(branches))
+	for i, branch := range branches {
+		names[i] = branch.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,1086 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Namespaces used by the SOAP encoding (use="encoded") and XML Schema instances.
+	XmlNsSoapEnc   string = "http://schemas.xmlsoap.org/soap/encoding/"
+	XmlNsSoapEnc12 string = "http://www.w3.org/2003/05/soap-encoding"
+	XmlNsXsi       string = "http://www.w3.org/2001/XMLSchema-instance"
+	XmlNsXsd       string = "http://www.w3.org/2001/XMLSchema"
+)
+
+// XSDTyper is implemented by types knowing the XML Schema type they were
+// generated from. The SOAP encoding uses it to annotate values with xsi:type.
+type XSDTyper interface {
+	XSDType() xml.Name
+}
+
+var (
+	xsdTyperType         = reflect.TypeOf((*XSDTyper)(nil)).Elem()
+	encodedMarshalerType = reflect.TypeOf((*EncodedMarshaler)(nil)).Elem()
+	marshalerType        = reflect.TypeOf((*xml.Marshaler)(nil)).Elem()
+	marshalerAttr        = reflect.TypeOf((*xml.MarshalerAttr)(nil)).Elem()
+	textMarshalerTyp     = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+	unmarshalerAttrTyp = reflect.TypeOf((*xml.UnmarshalerAttr)(nil)).Elem()
+	textUnmarshalerTyp = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// EncodedMarshaler is implemented by the generated types whose MarshalXML
+// method calls a function of this package. Within content sent with the SOAP
+// encoding (use="encoded"), their MarshalEncoded method is called instead,
+// with the Encoder writing the content, which calls the counterpart of the
+// function, so that their values are encoded with the encoding too.
+type EncodedMarshaler interface {
+	MarshalEncoded(enc *Encoder, start xml.StartElement) error
+}
+
+// Encoder writes content with the SOAP encoding. It holds the namespaces
+// declared by the elements written and the SOAP version of the encoding.
+type Encoder struct {
+	enc *soapEncoder
+}
+
+// MarshalArray encodes the slice v as a SOAP-ENC array, as the MarshalArray
+// function does.
+func (e *Encoder) MarshalArray(start xml.StartElement, v interface{}, itemType xml.Name) error {
+	return marshalArray(e.enc, start, v, itemType)
+}
+
+// MarshalElement encodes v as the element its Go type is registered for, as
+// the MarshalElement function does.
+func (e *Encoder) MarshalElement(start xml.StartElement, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	name, err := registeredElement(rv.Type())
+	if err != nil {
+		return err
+	}
+	start.Name = name
+	return e.enc.encodeElement(rv, start)
+}
+
+// MarshalXSIType encodes v as the element start, as the MarshalXSIType
+// function does. Values are always annotated with their xsi:type by the
+// encoding.
+func (e *Encoder) MarshalXSIType(start xml.StartElement, v interface{}, declared xml.Name) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+	return e.enc.encodeElement(rv, start)
+}
+
+// MarshalChoice encodes the branch c holds, as the Marshal method of Choice
+// does.
+func (e *Encoder) MarshalChoice(c *Choice, branches []ChoiceBranch) error {
+	return c.marshal(e.enc, branches)
+}
+
+// MarshalMixed encodes the struct v of a mixed complex type as the element
+// start, as the MarshalMixed function does.
+func (e *Encoder) MarshalMixed(start xml.StartElement, v interface{}, m Mixed) error {
+	return marshalMixed(e.enc, start, v, m)
+}
+
+// elementEncoder writes the elements of the content of generated types, as
+// encoding/xml does or with the SOAP encoding.
+type elementEncoder interface {
+	// qname returns name as the name of an element start, declaring its
+	// namespace on start if needed.
+	qname(start *xml.StartElement, name xml.Name) xml.Name
+	encodeElement(v reflect.Value, start xml.StartElement) error
+	encodeToken(t xml.Token) error
+}
+
+// xmlEncoder writes elements as encoding/xml does.
+type xmlEncoder struct {
+	e *xml.Encoder
+}
+
+func (x xmlEncoder) qname(_ *xml.StartElement, name xml.Name) xml.Name {
+	return name
+}
+
+func (x xmlEncoder) encodeElement(v reflect.Value, start xml.StartElement) error {
+	return x.e.EncodeElement(v.Interface(), start)
+}
+
+func (x xmlEncoder) encodeToken(t xml.Token) error {
+	return x.e.EncodeToken(t)
+}
+
+// encodedContent wraps the body content of a request sent with the SOAP encoding.
+type encodedContent struct {
+	content interface{}
+	version SOAPVersion
+}
+
+// MarshalXML implements xml.Marshaler on encodedContent.
+func (c encodedContent) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	enc := newSOAPEncoder(e, c.version)
+	// The envelope declares the namespaces used by the encoding.
+	enc.scopes = []map[string]string{{
+		XmlNsXsi:         "xsi",
+		XmlNsXsd:         "xsd",
+		enc.encNamespace: "soapenc",
+	}}
+
+	v := reflect.ValueOf(c.content)
+	start := enc.element(elementName(v))
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "soap:encodingStyle"}, Value: enc.encNamespace})
+	if err := enc.encode(v, start); err != nil {
+		return err
+	}
+	return e.Flush()
+}
+
+// MarshalArray encodes the slice v as a SOAP-ENC array, annotating the array
+// with the given item type. It is meant to be called by the MarshalXML methods
+// of generated array types.
+func MarshalArray(e *xml.Encoder, start xml.StartElement, v interface{}, itemType xml.Name) error {
+	return marshalArray(newSOAPEncoder(e, SOAP11), start, v, itemType)
+}
+
+func marshalArray(enc *soapEncoder, start xml.StartElement, v interface{}, itemType xml.Name) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return errors.New("soap: MarshalArray expects a slice")
+	}
+
+	start.Name = enc.qname(&start, start.Name)
+	if err := enc.encodeArray(rv, start, itemType); err != nil {
+		return err
+	}
+	return enc.e.Flush()
+}
+
+// UnmarshalArray decodes the items of a SOAP-ENC array into the slice v
+// points to. It is meant to be called by the UnmarshalXML methods of
+// generated array types.
+func UnmarshalArray(d *xml.Decoder, start xml.StartElement, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Slice {
+		return errors.New("soap: UnmarshalArray expects a pointer to a slice")
+	}
+	slice := rv.Elem()
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			item := reflect.New(slice.Type().Elem())
+			if isNil(t) {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+			} else if err := d.DecodeElement(item.Interface(), &t); err != nil {
+				return err
+			}
+			slice.Set(reflect.Append(slice, item.Elem()))
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+func isNil(start xml.StartElement) bool {
+	for _, attr := range start.Attr {
+		if attr.Name.Space == XmlNsXsi && attr.Name.Local == "nil" {
+			return attr.Value == "true" || attr.Value == "1"
+		}
+	}
+	return false
+}
+
+// soapEncoder writes values following the SOAP encoding rules: every value is
+// annotated with its xsi:type, nil values with xsi:nil and slices are written
+// as SOAP-ENC arrays. Qualified names are always written with a prefix, so
+// unqualified accessors stay unqualified.
+type soapEncoder struct {
+	e            *xml.Encoder
+	version      SOAPVersion
+	encNamespace string
+	// scopes holds the namespace prefixes declared by the open elements.
+	scopes []map[string]string
+	// pending holds the namespace prefixes declared by the element being built.
+	pending map[string]string
+	n       int
+}
+
+func newSOAPEncoder(e *xml.Encoder, version SOAPVersion) *soapEncoder {
+	enc := &soapEncoder{
+		e:            e,
+		version:      version,
+		encNamespace: XmlNsSoapEnc,
+		pending:      make(map[string]string),
+	}
+	if version == SOAP12 {
+		enc.encNamespace = XmlNsSoapEnc12
+	}
+	return enc
+}
+
+// prefix returns the prefix bound to ns, declaring it on start if needed.
+func (enc *soapEncoder) prefix(start *xml.StartElement, ns string) string {
+	if p, ok := enc.pending[ns]; ok {
+		return p
+	}
+	for i := len(enc.scopes) - 1; i >= 0; i-- {
+		if p, ok := enc.scopes[i][ns]; ok {
+			return p
+		}
+	}
+
+	var p string
+	switch ns {
+	case XmlNsXsi:
+		p = "xsi"
+	case XmlNsXsd:
+		p = "xsd"
+	case enc.encNamespace:
+		p = "soapenc"
+	default:
+		enc.n++
+		p = "ns" + strconv.Itoa(enc.n)
+	}
+	enc.pending[ns] = p
+	start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + p}, Value: ns})
+	return p
+}
+
+// qname returns name as a prefixed name, declaring its namespace on start if needed.
+func (enc *soapEncoder) qname(start *xml.StartElement, name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: enc.prefix(start, name.Space) + ":" + name.Local}
+}
+
+// element returns the start of an element named name.
+func (enc *soapEncoder) element(name xml.Name) xml.StartElement {
+	var start xml.StartElement
+	start.Name = enc.qname(&start, name)
+	return start
+}
+
+func (enc *soapEncoder) setAttr(start *xml.StartElement, name xml.Name, value string) {
+	qn := enc.qname(start, name)
+	for i, attr := range start.Attr {
+		if attr.Name == qn {
+			start.Attr[i].Value = value
+			return
+		}
+	}
+	start.Attr = append(start.Attr, xml.Attr{Name: qn, Value: value})
+}
+
+func (enc *soapEncoder) setType(start *xml.StartElement, typ xml.Name) {
+	value := typ.Local
+	if typ.Space != "" {
+		value = enc.prefix(start, typ.Space) + ":" + typ.Local
+	}
+	enc.setAttr(start, xml.Name{Space: XmlNsXsi, Local: "type"}, value)
+}
+
+func (enc *soapEncoder) writeStart(start xml.StartElement) error {
+	enc.scopes = append(enc.scopes, enc.pending)
+	enc.pending = make(map[string]string)
+	return enc.e.EncodeToken(start)
+}
+
+func (enc *soapEncoder) writeEnd(start xml.StartElement) error {
+	enc.scopes = enc.scopes[:len(enc.scopes)-1]
+	return enc.e.EncodeToken(start.End())
+}
+
+// encodeElement encodes v as the element start and flushes it.
+func (enc *soapEncoder) encodeElement(v reflect.Value, start xml.StartElement) error {
+	start.Name = enc.qname(&start, start.Name)
+	if err := enc.encode(v, start); err != nil {
+		return err
+	}
+	return enc.e.Flush()
+}
+
+func (enc *soapEncoder) encodeToken(t xml.Token) error {
+	switch t := t.(type) {
+	case xml.StartElement:
+		return enc.writeStart(t)
+	case xml.EndElement:
+		enc.scopes = enc.scopes[:len(enc.scopes)-1]
+	}
+	return enc.e.EncodeToken(t)
+}
+
+func (enc *soapEncoder) writeText(start xml.StartElement, text string) error {
+	if err := enc.writeStart(start); err != nil {
+		return err
+	}
+	if text != "" {
+		if err := enc.e.EncodeToken(xml.CharData(text)); err != nil {
+			return err
+		}
+	}
+	return enc.writeEnd(start)
+}
+
+func (enc *soapEncoder) encode(v reflect.Value, start xml.StartElement) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			enc.setAttr(&start, xml.Name{Space: XmlNsXsi, Local: "nil"}, "true")
+			return enc.writeText(start, "")
+		}
+		v = v.Elem()
+	}
+
+	if typ, ok := xsdTypeOf(v); ok {
+		enc.setType(&start, typ)
+	}
+
+	if m, ok := implements(v, encodedMarshalerType); ok {
+		err := m.(EncodedMarshaler).MarshalEncoded(&Encoder{enc}, start)
+		enc.pending = make(map[string]string)
+		return err
+	}
+	if m, ok := implements(v, marshalerType); ok {
+		err := enc.e.EncodeElement(m, start)
+		enc.pending = make(map[string]string)
+		return err
+	}
+
+	_, isText := implements(v, textMarshalerTyp)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return enc.writeText(start, base64.StdEncoding.EncodeToString(bytesOf(v)))
+		}
+		// Lists are written as text, arrays as items.
+		if !isText {
+			return enc.encodeArray(v, start, xml.Name{})
+		}
+	case reflect.Struct:
+		if !isText {
+			return enc.encodeStruct(v, start)
+		}
+	}
+
+	text, err := formatValue(v)
+	if err != nil {
+		return err
+	}
+	return enc.writeText(start, text)
+}
+
+func (enc *soapEncoder) encodeArray(v reflect.Value, start xml.StartElement, itemType xml.Name) error {
+	if itemType.Local == "" {
+		itemType = itemTypeOf(v.Type().Elem())
+	}
+	itemTypeName := itemType.Local
+	if itemType.Space != "" {
+		itemTypeName = enc.prefix(&start, itemType.Space) + ":" + itemType.Local
+	}
+
+	if enc.version == SOAP12 {
+		enc.setAttr(&start, xml.Name{Space: enc.encNamespace, Local: "itemType"}, itemTypeName)
+		enc.setAttr(&start, xml.Name{Space: enc.encNamespace, Local: "arraySize"}, strconv.Itoa(v.Len()))
+	} else {
+		enc.setType(&start, xml.Name{Space: enc.encNamespace, Local: "Array"})
+		enc.setAttr(&start, xml.Name{Space: enc.encNamespace, Local: "arrayType"},
+			fmt.Sprintf("%s[%d]", itemTypeName, v.Len()))
+	}
+
+	if err := enc.writeStart(start); err != nil {
+		return err
+	}
+	for i := 0; i < v.Len(); i++ {
+		if err := enc.encode(v.Index(i), enc.element(xml.Name{Local: "item"})); err != nil {
+			return err
+		}
+	}
+	return enc.writeEnd(start)
+}
+
+func (enc *soapEncoder) encodeStruct(v reflect.Value, start xml.StartElement) error {
+	fields := structFields(v.Type())
+
+	// Raw XML can only be written by encoding/xml itself.
+	for _, f := range fields {
+		if f.innerXML {
+			err := enc.e.EncodeElement(v.Interface(), start)
+			enc.pending = make(map[string]string)
+			return err
+		}
+	}
+
+	for _, f := range fields {
+		if !f.attr {
+			continue
+		}
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+		attrs, err := formatAttrs(fv, f)
+		if err != nil {
+			return err
+		}
+		for _, attr := range attrs {
+			enc.setAttr(&start, attr.Name, attr.Value)
+		}
+	}
+
+	if err := enc.writeStart(start); err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if f.attr {
+			continue
+		}
+		fv, ok := fieldByIndex(v, f.index)
+		if !ok || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+
+		if f.charData {
+			text, err := formatValue(fv)
+			if err != nil {
+				return err
+			}
+			if err := enc.e.EncodeToken(xml.CharData(text)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := enc.encode(fv, enc.element(f.name)); err != nil {
+			return err
+		}
+	}
+
+	return enc.writeEnd(start)
+}
+
+// elementName returns the name of the element v is encoded as: the value of
+// its XMLName field, the name given by the tag of that field or its type name.
+func elementName(v reflect.Value) xml.Name {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return xml.Name{Local: v.Type().Name()}
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Struct {
+		if f, ok := v.Type().FieldByName("XMLName"); ok {
+			if name, ok := v.FieldByIndex(f.Index).Interface().(xml.Name); ok && name.Local != "" {
+				return name
+			}
+			if name, _ := parseTag(f.Tag.Get("xml")); name.Local != "" {
+				return name
+			}
+		}
+	}
+	return xml.Name{Local: v.Type().Name()}
+}
+
+type fieldInfo struct {
+	index     []int
+	name      xml.Name
+	attr      bool
+	charData  bool
+	innerXML  bool
+	omitEmpty bool
+	// any is set on the wildcard fields, holding the elements or attributes
+	// no other field is named after.
+	any bool
+}
+
+// structFields returns the fields of t as encoding/xml sees them, flattening
+// embedded structs.
+func structFields(t reflect.Type) []fieldInfo {
+	var fields []fieldInfo
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("xml")
+		if f.PkgPath != "" && !f.Anonymous || tag == "-" || f.Name == "XMLName" {
+			continue
+		}
+
+		if f.Anonymous && tag == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for _, embedded := range structFields(ft) {
+					embedded.index = append([]int{i}, embedded.index...)
+					fields = append(fields, embedded)
+				}
+				continue
+			}
+		}
+		if f.PkgPath != "" {
+			continue
+		}
+
+		name, flags := parseTag(tag)
+		if name.Local == "" {
+			name.Local = f.Name
+		}
+
+		info := fieldInfo{index: []int{i}, name: name}
+		for _, flag := range flags {
+			switch flag {
+			case "attr":
+				info.attr = true
+			case "chardata":
+				info.charData = true
+			case "innerxml":
+				info.innerXML = true
+			case "omitempty":
+				info.omitEmpty = true
+			case "any":
+				info.any = true
+			case "comment":
+				info.name.Local = ""
+			}
+		}
+		if info.name.Local == "" {
+			continue
+		}
+		fields = append(fields, info)
+	}
+
+	return fields
+}
+
+// parseTag splits an xml struct tag into the name it gives and its flags.
+func parseTag(tag string) (xml.Name, []string) {
+	tokens := strings.Split(tag, ",")
+	var name xml.Name
+	if i := strings.LastIndex(tokens[0], " "); i >= 0 {
+		name.Space, name.Local = tokens[0][:i], tokens[0][i+1:]
+	} else {
+		name.Local = tokens[0]
+	}
+	return name, tokens[1:]
+}
+
+// fieldByIndex is like reflect.Value.FieldByIndex, but reports false
+// instead of panicking when it runs into a nil embedded pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			if v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+// implements returns v, or its address, as an interface{} if it implements t.
+func implements(v reflect.Value, t reflect.Type) (interface{}, bool) {
+	if v.Type().Implements(t) && v.CanInterface() {
+		return v.Interface(), true
+	}
+	if v.CanAddr() && reflect.PtrTo(v.Type()).Implements(t) {
+		return v.Addr().Interface(), true
+	}
+	if reflect.PtrTo(v.Type()).Implements(t) && v.CanInterface() {
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		return p.Interface(), true
+	}
+	return nil, false
+}
+
+var xsdTypes = map[reflect.Kind]string{
+	reflect.String:  "string",
+	reflect.Bool:    "boolean",
+	reflect.Int:     "int",
+	reflect.Int8:    "byte",
+	reflect.Int16:   "short",
+	reflect.Int32:   "int",
+	reflect.Int64:   "long",
+	reflect.Uint:    "unsignedInt",
+	reflect.Uint8:   "unsignedByte",
+	reflect.Uint16:  "unsignedShort",
+	reflect.Uint32:  "unsignedInt",
+	reflect.Uint64:  "unsignedLong",
+	reflect.Float32: "float",
+	reflect.Float64: "double",
+}
+
+// xsdTypeOf returns the XML Schema type of v, if known.
+func xsdTypeOf(v reflect.Value) (xml.Name, bool) {
+	if t, ok := implements(v, xsdTyperType); ok {
+		return t.(XSDTyper).XSDType(), true
+	}
+
+	switch v.Interface().(type) {
+	case XSDDateTime:
+		return xml.Name{Space: XmlNsXsd, Local: "dateTime"}, true
+	case XSDDate:
+		return xml.Name{Space: XmlNsXsd, Local: "date"}, true
+	case XSDTime:
+		return xml.Name{Space: XmlNsXsd, Local: "time"}, true
+	case Decimal:
+		return xml.Name{Space: XmlNsXsd, Local: "decimal"}, true
+	case Integer:
+		return xml.Name{Space: XmlNsXsd, Local: "integer"}, true
+	case Duration:
+		return xml.Name{Space: XmlNsXsd, Local: "duration"}, true
+	case GYear:
+		return xml.Name{Space: XmlNsXsd, Local: "gYear"}, true
+	case GYearMonth:
+		return xml.Name{Space: XmlNsXsd, Local: "gYearMonth"}, true
+	case GMonthDay:
+		return xml.Name{Space: XmlNsXsd, Local: "gMonthDay"}, true
+	case GDay:
+		return xml.Name{Space: XmlNsXsd, Local: "gDay"}, true
+	case GMonth:
+		return xml.Name{Space: XmlNsXsd, Local: "gMonth"}, true
+	case QName:
+		return xml.Name{Space: XmlNsXsd, Local: "QName"}, true
+	}
+
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8 {
+		return xml.Name{Space: XmlNsXsd, Local: "base64Binary"}, true
+	}
+	if local, ok := xsdTypes[v.Kind()]; ok {
+		return xml.Name{Space: XmlNsXsd, Local: local}, true
+	}
+	return xml.Name{}, false
+}
+
+// itemTypeOf returns the XML Schema type of the items of a slice of t.
+func itemTypeOf(t reflect.Type) xml.Name {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Interface {
+		if typ, ok := xsdTypeOf(reflect.New(t).Elem()); ok {
+			return typ
+		}
+	}
+	return xml.Name{Space: XmlNsXsd, Local: "anyType"}
+}
+
+func bytesOf(v reflect.Value) []byte {
+	if v.Kind() == reflect.Slice {
+		return v.Bytes()
+	}
+	b := make([]byte, v.Len())
+	reflect.Copy(reflect.ValueOf(b), v)
+	return b
+}
+
+// formatValue returns the textual representation of a simple value.
+func formatValue(v reflect.Value) (string, error) {
+	if m, ok := implements(v, textMarshalerTyp); ok {
+		text, err := m.(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()), nil
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return base64.StdEncoding.EncodeToString(bytesOf(v)), nil
+		}
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return "", nil
+		}
+		return formatValue(v.Elem())
+	}
+	return "", fmt.Errorf("soap: cannot encode value of type %s", v.Type())
+}
+
+// parseValue parses the lexical value text into v, a boolean or a number.
+func parseValue(text string, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(text, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimPrefix(text, "+"), 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(text, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	default:
+		return fmt.Errorf("cannot decode values of kind %s", v.Kind())
+	}
+	return nil
+}
+
+// formatAttr returns the attribute name takes for v, or nil if no attribute
+// should be written.
+func formatAttr(v reflect.Value, name xml.Name) (*xml.Attr, error) {
+	if m, ok := implements(v, marshalerAttr); ok {
+		attr, err := m.(xml.MarshalerAttr).MarshalXMLAttr(name)
+		if err != nil || attr.Name.Local == "" {
+			return nil, err
+		}
+		return &attr, nil
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return nil, nil
+	}
+
+	text, err := formatValue(v)
+	if err != nil {
+		return nil, err
+	}
+	return &xml.Attr{Name: name, Value: text}, nil
+}
+
+// formatAttrs returns the attributes the attribute field f, whose value is v,
+// is written as: those a wildcard field holds, or the attribute named after f.
+func formatAttrs(v reflect.Value, f fieldInfo) ([]xml.Attr, error) {
+	if f.any {
+		if attrsType := reflect.TypeOf([]xml.Attr(nil)); v.Type().ConvertibleTo(attrsType) {
+			return v.Convert(attrsType).Interface().([]xml.Attr), nil
+		}
+		return nil, nil
+	}
+	attr, err := formatAttr(v, f.name)
+	if err != nil || attr == nil {
+		return nil, err
+	}
+	return []xml.Attr{*attr}, nil
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	}
+	return false
+}
+
+// xmlNode is an element of a document kept in memory by resolveMultiRefs.
+// Names are kept as written, prefixes included.
+type xmlNode struct {
+	start    xml.StartElement
+	children []interface{}
+}
+
+func (n *xmlNode) attr(local string) (string, bool) {
+	for _, attr := range n.start.Attr {
+		if attr.Name.Local == local && attr.Name.Space != "xmlns" {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+func (n *xmlNode) walk(fn func(*xmlNode)) {
+	fn(n)
+	for _, c := range n.children {
+		if child, ok := c.(*xmlNode); ok {
+			child.walk(fn)
+		}
+	}
+}
+
+// resolveMultiRefs inlines the multi-reference values of a SOAP encoded
+// response: every accessor referring to a value through href="#id" (SOAP 1.1)
+// or ref="id" (SOAP 1.2) gets the content of that value, and the values only
+// referenced this way are removed from the body.
+func resolveMultiRefs(data []byte) ([]byte, error) {
+	root, err := parseXMLTree(data)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[string]*xmlNode)
+	collectIDs(root, nil, false, ids)
+	hasRefs := false
+	root.walk(func(n *xmlNode) {
+		if _, ok := refOf(n); ok {
+			hasRefs = true
+		}
+	})
+	if !hasRefs {
+		return data, nil
+	}
+
+	r := &refResolver{
+		ids:        ids,
+		resolved:   make(map[*xmlNode]bool),
+		resolving:  make(map[*xmlNode]bool),
+		referenced: make(map[*xmlNode]bool),
+	}
+	r.resolve(root)
+
+	root.walk(func(n *xmlNode) {
+		if n.start.Name.Local != "Body" {
+			return
+		}
+		children := n.children[:0]
+		for _, c := range n.children {
+			if child, ok := c.(*xmlNode); ok && r.referenced[child] {
+				if isRoot, _ := child.attr("root"); isRoot != "1" && isRoot != "true" {
+					continue
+				}
+			}
+			children = append(children, c)
+		}
+		n.children = children
+	})
+
+	buf := new(bytes.Buffer)
+	e := xml.NewEncoder(buf)
+	if err := writeXMLTree(e, root); err != nil {
+		return nil, err
+	}
+	if err := e.Flush(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// collectIDs records in ids the values of the tree n which accessors may refer
+// to, by identifier: the children of the Body with an unqualified id attribute,
+// the multi-reference values of SOAP 1.1, and the elements with the id
+// attribute of the SOAP 1.2 encoding. prefixes maps the namespace prefixes in
+// scope to their namespace, and inBody tells whether n is a child of the Body.
+func collectIDs(n *xmlNode, prefixes map[string]string, inBody bool, ids map[string]*xmlNode) {
+	for _, attr := range n.start.Attr {
+		if attr.Name.Space == "xmlns" {
+			scoped := make(map[string]string, len(prefixes)+1)
+			for prefix, ns := range prefixes {
+				scoped[prefix] = ns
+			}
+			prefixes = scoped
+			break
+		}
+	}
+	for _, attr := range n.start.Attr {
+		if attr.Name.Space == "xmlns" {
+			prefixes[attr.Name.Local] = attr.Value
+		}
+	}
+
+	for _, attr := range n.start.Attr {
+		if attr.Name.Local != "id" {
+			continue
+		}
+		if attr.Name.Space == "" && inBody || attr.Name.Space != "" && prefixes[attr.Name.Space] == XmlNsSoapEnc12 {
+			ids[attr.Value] = n
+		}
+	}
+
+	for _, c := range n.children {
+		if child, ok := c.(*xmlNode); ok {
+			collectIDs(child, prefixes, n.start.Name.Local == "Body", ids)
+		}
+	}
+}
+
+// refOf returns the identifier of the value n refers to.
+func refOf(n *xmlNode) (string, bool) {
+	if href, ok := n.attr("href"); ok && strings.HasPrefix(href, "#") {
+		return href[1:], true
+	}
+	for _, attr := range n.start.Attr {
+		if attr.Name.Local == "ref" && attr.Name.Space != "" && attr.Name.Space != "xmlns" {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+type refResolver struct {
+	ids        map[string]*xmlNode
+	resolved   map[*xmlNode]bool
+	resolving  map[*xmlNode]bool
+	referenced map[*xmlNode]bool
+}
+
+func (r *refResolver) resolve(n *xmlNode) {
+	if r.resolved[n] || r.resolving[n] {
+		return
+	}
+	r.resolving[n] = true
+
+	if id, ok := refOf(n); ok {
+		if target := r.ids[id]; target != nil && !r.resolving[target] {
+			r.resolve(target)
+			r.referenced[target] = true
+			n.children = target.children
+			n.start.Attr = mergeRefAttrs(n.start.Attr, target.start.Attr)
+		}
+	}
+
+	for _, c := range n.children {
+		if child, ok := c.(*xmlNode); ok {
+			r.resolve(child)
+		}
+	}
+
+	delete(r.resolving, n)
+	r.resolved[n] = true
+}
+
+// mergeRefAttrs returns the attributes of an accessor once the value it
+// refers to has been inlined.
+func mergeRefAttrs(attrs, target []xml.Attr) []xml.Attr {
+	var merged []xml.Attr
+	for _, attr := range attrs {
+		if attr.Name.Local == "href" || (attr.Name.Local == "ref" && attr.Name.Space != "") {
+			continue
+		}
+		merged = append(merged, attr)
+	}
+
+Target:
+	for _, attr := range target {
+		if attr.Name.Local == "id" || attr.Name.Local == "root" {
+			continue
+		}
+		for i, m := range merged {
+			if m.Name == attr.Name {
+				merged[i] = attr
+				continue Target
+			}
+		}
+		merged = append(merged, attr)
+	}
+	return merged
+}
+
+func parseXMLTree(data []byte) (*xmlNode, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+
+	for {
+		tok, err := d.RawToken()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, err
+		}
+
+		parent := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{start: t.Copy()}
+			parent.children = append(parent.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) == 1 {
+				return nil, errors.New("soap: unexpected end element " + t.Name.Local)
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			parent.children = append(parent.children, t.Copy())
+		case xml.Comment:
+			parent.children = append(parent.children, t.Copy())
+		}
+	}
+
+	return root, nil
+}
+
+// rawName turns a name read by RawToken back into the name as written.
+func rawName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+func writeXMLTree(e *xml.Encoder, n *xmlNode) error {
+	for _, c := range n.children {
+		switch t := c.(type) {
+		case *xmlNode:
+			start := xml.StartElement{Name: rawName(t.start.Name)}
+			for _, attr := range t.start.Attr {
+				start.Attr = append(start.Attr, xml.Attr{Name: rawName(attr.Name), Value: attr.Value})
+			}
+			if err := e.EncodeToken(start); err != nil {
+				return err
+			}
+			if err := writeXMLTree(e, t); err != nil {
+				return err
+			}
+			if err := e.EncodeToken(start.End()); err != nil {
+				return err
+			}
+		case xml.Token:
+			if err := e.EncodeToken(t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
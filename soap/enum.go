@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// FormatEnum returns the lexical value of the value v of an enumerated type,
+// as it is encoded.
+func FormatEnum(v interface{}) string {
+	text, _ := formatValue(reflect.ValueOf(v))
+	return text
+}
+
+// Enum is implemented by the pointers to the values of the generated
+// enumerated types.
+type Enum interface {
+	// IsValid tells whether the value is one of the values enumerated by its
+	// schema.
+	IsValid() bool
+}
+
+// UnmarshalEnum decodes the lexical value text into the value of an
+// enumerated type v points to. It fails when the value is not one of the
+// values of the type, leaving v unchanged. The value is decoded as a value of
+// the type the enumerated type is declared as, so that the enumerated type
+// may implement encoding.TextUnmarshaler with UnmarshalEnum.
+func UnmarshalEnum(text string, v Enum) error {
+	rv := reflect.ValueOf(v).Elem()
+	value := reflect.New(rv.Type())
+	if err := parseEnum(text, value.Elem()); err != nil {
+		return fmt.Errorf("soap: %q is not a value of %s: %v", text, rv.Type(), err)
+	}
+
+	if !value.Interface().(Enum).IsValid() {
+		return fmt.Errorf("soap: %q is not a value of %s", text, rv.Type())
+	}
+	rv.Set(value.Elem())
+	return nil
+}
+
+// UnmarshalJSONEnum decodes the JSON value data into the value of an
+// enumerated type v points to, as UnmarshalEnum decodes its lexical value:
+// from a JSON string, or a JSON number or boolean when the values of the type
+// aren't strings. It leaves v unchanged when data is null.
+func UnmarshalJSONEnum(data []byte, v Enum) error {
+	data = bytes.TrimSpace(data)
+	switch {
+	case string(data) == "null":
+		return nil
+	case len(data) > 0 && data[0] == '"':
+		var text string
+		if err := json.Unmarshal(data, &text); err != nil {
+			return err
+		}
+		return UnmarshalEnum(text, v)
+	case reflect.ValueOf(v).Elem().Kind() == reflect.String:
+		return fmt.Errorf("soap: %s is not a value of %s", data, reflect.ValueOf(v).Elem().Type())
+	}
+	return UnmarshalEnum(string(data), v)
+}
+
+// enumBaseTypes are the types of the soap package enumerated types are
+// declared as, which decode their values themselves.
+var enumBaseTypes = []reflect.Type{
+	reflect.TypeOf(Integer{}),
+	reflect.TypeOf(Decimal{}),
+}
+
+// parseEnum parses the lexical value text into v, an addressable value of an
+// enumerated type, as a value of the type it is declared as.
+func parseEnum(text string, v reflect.Value) error {
+	for _, typ := range enumBaseTypes {
+		if v.Type().ConvertibleTo(typ) {
+			return v.Addr().Convert(reflect.PtrTo(typ)).Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+		}
+	}
+	if v.Kind() == reflect.String {
+		v.SetString(text)
+		return nil
+	}
+	return parseValue(strings.TrimSpace(text), v)
+}
@@ -0,0 +1,101 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding"
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// MarshalList returns the lexical value of an XML Schema list, the slice v:
+// the lexical values of its items, separated by spaces. Items are written as
+// they are in attributes, so that lists of dates and enumerations work too.
+func MarshalList(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	items := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		attr, err := formatAttr(rv.Index(i), xml.Name{Local: "item"})
+		if err != nil {
+			return nil, err
+		}
+		if attr != nil && attr.Value != "" {
+			items = append(items, attr.Value)
+		}
+	}
+	return []byte(strings.Join(items, " ")), nil
+}
+
+// MarshalListAttr returns the attribute name holding the list v, or no
+// attribute when v is empty.
+func MarshalListAttr(name xml.Name, v interface{}) (xml.Attr, error) {
+	text, err := MarshalList(v)
+	if err != nil || len(text) == 0 {
+		return xml.Attr{}, err
+	}
+	return xml.Attr{Name: name, Value: string(text)}, nil
+}
+
+// UnmarshalList decodes the lexical value of an XML Schema list, its items
+// separated by whitespace, into the slice v points to.
+func UnmarshalList(text []byte, v interface{}) error {
+	rv := reflect.ValueOf(v).Elem()
+	fields := strings.Fields(string(text))
+	items := reflect.MakeSlice(rv.Type(), len(fields), len(fields))
+	for i, field := range fields {
+		if err := parseText(field, items.Index(i)); err != nil {
+			return fmt.Errorf("soap: cannot decode item %q of a list of %s: %v", field, rv.Type().Elem(), err)
+		}
+	}
+	rv.Set(items)
+	return nil
+}
+
+// parseText parses the lexical value text of a simple value into v, which is
+// addressable, as the value of an attribute would be.
+func parseText(text string, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		v.Set(reflect.New(v.Type().Elem()))
+		v = v.Elem()
+	}
+
+	switch p := v.Addr().Interface(); {
+	case v.Addr().Type().Implements(unmarshalerAttrTyp):
+		return p.(xml.UnmarshalerAttr).UnmarshalXMLAttr(xml.Attr{Name: xml.Name{Local: "value"}, Value: text})
+	case v.Addr().Type().Implements(textUnmarshalerTyp):
+		return p.(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	case v.Kind() == reflect.String:
+		v.SetString(text)
+		return nil
+	}
+	return parseValue(strings.TrimSpace(text), v)
+}
+
+// TokenList holds the values of the built-in list types of XML Schema,
+// NMTOKENS, IDREFS and ENTITIES: tokens separated by whitespace.
+type TokenList []string
+
+// MarshalText implements encoding.TextMarshaler on TokenList.
+func (v TokenList) MarshalText() ([]byte, error) {
+	return MarshalList([]string(v))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on TokenList.
+func (v *TokenList) UnmarshalText(text []byte) error {
+	return UnmarshalList(text, (*[]string)(v))
+}
+
+// MarshalXMLAttr implements xml.MarshalerAttr on TokenList, omitting empty
+// lists.
+func (v TokenList) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return MarshalListAttr(name, []string(v))
+}
+
+// UnmarshalXMLAttr implements xml.UnmarshalerAttr on TokenList.
+func (v *TokenList) UnmarshalXMLAttr(attr xml.Attr) error {
+	return UnmarshalList([]byte(attr.Value), (*[]string)(v))
+}
@@ -0,0 +1,265 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"reflect"
+	"strings"
+)
+
+// Mixed records the content of an element of a mixed complex type: its text,
+// and where its child elements, which the other fields of the generated type
+// hold, stand among it.
+type Mixed []MixedNode
+
+// MixedNode is a node of mixed content: a text node, or the next child
+// element of the local name Element.
+type MixedNode struct {
+	Text    string `json:"text,omitempty"`
+	Element string `json:"element,omitempty"`
+}
+
+// Text returns the text of m, leaving out its child elements.
+func (m Mixed) Text() string {
+	var b strings.Builder
+	for _, node := range m {
+		b.WriteString(node.Text)
+	}
+	return b.String()
+}
+
+func (m *Mixed) addText(text string) {
+	if n := len(*m); n > 0 && (*m)[n-1].Element == "" {
+		(*m)[n-1].Text += text
+		return
+	}
+	*m = append(*m, MixedNode{Text: text})
+}
+
+// UnmarshalMixed decodes the element start of a mixed complex type into the
+// struct v points to, as encoding/xml would, and records its text and the
+// order of its child elements into m.
+func UnmarshalMixed(d *xml.Decoder, start xml.StartElement, v interface{}, m *Mixed) error {
+	rv := reflect.ValueOf(v).Elem()
+	fields := structFields(rv.Type())
+	*m = nil
+
+	if f, ok := rv.Type().FieldByName("XMLName"); ok && f.Type == reflect.TypeOf(start.Name) {
+		rv.FieldByIndex(f.Index).Set(reflect.ValueOf(start.Name))
+	}
+	for _, attr := range start.Attr {
+		if declaresNamespace(attr) {
+			continue
+		}
+		if f := fieldOf(fields, attr.Name, true); f != nil {
+			if err := unmarshalAttr(allocField(rv, f.index), attr); err != nil {
+				return err
+			}
+		}
+	}
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			f := fieldOf(fields, t.Name, false)
+			if f == nil {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			*m = append(*m, MixedNode{Element: t.Name.Local})
+			if err := d.DecodeElement(allocField(rv, f.index).Addr().Interface(), &t); err != nil {
+				return err
+			}
+		case xml.CharData:
+			m.addText(string(t))
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// mixedElement is a child element of mixed content to be written.
+type mixedElement struct {
+	// name is the local name of the element, empty for the values of
+	// wildcard fields, which are named after themselves.
+	name  string
+	start xml.StartElement
+	value reflect.Value
+}
+
+// MarshalMixed encodes the struct v of a mixed complex type as the element
+// start, writing the text of m with the child elements it holds in the order
+// m records. The child elements m doesn't mention are written last.
+func MarshalMixed(e *xml.Encoder, start xml.StartElement, v interface{}, m Mixed) error {
+	return marshalMixed(xmlEncoder{e}, start, v, m)
+}
+
+func marshalMixed(e elementEncoder, start xml.StartElement, v interface{}, m Mixed) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	// encoding/xml names the elements of Marshalers after their field or
+	// type, where it names other structs after their XMLName field.
+	if f, ok := rv.Type().FieldByName("XMLName"); ok {
+		if name, _ := parseTag(f.Tag.Get("xml")); name.Local != "" {
+			start.Name = e.qname(&start, name)
+		} else if name, ok := rv.FieldByIndex(f.Index).Interface().(xml.Name); ok && name.Local != "" {
+			start.Name = e.qname(&start, name)
+		}
+	}
+
+	var elements []*mixedElement
+	for _, f := range structFields(rv.Type()) {
+		fv, ok := fieldByIndex(rv, f.index)
+		if !ok || f.charData || f.innerXML || (f.omitEmpty && isEmptyValue(fv)) {
+			continue
+		}
+
+		if f.attr {
+			attrs, err := formatAttrs(fv, f)
+			if err != nil {
+				return err
+			}
+			start.Attr = append(start.Attr, attrs...)
+			continue
+		}
+
+		items := []reflect.Value{fv}
+		if repeatsElement(fv) {
+			items = items[:0]
+			for i := 0; i < fv.Len(); i++ {
+				items = append(items, fv.Index(i))
+			}
+		}
+		for _, item := range items {
+			if (item.Kind() == reflect.Ptr || item.Kind() == reflect.Interface) && item.IsNil() {
+				continue
+			}
+			element := &mixedElement{start: xml.StartElement{Name: f.name}, value: item}
+			if f.any {
+				element.start.Name = elementName(item)
+			} else {
+				element.name = f.name.Local
+			}
+			elements = append(elements, element)
+		}
+	}
+
+	if err := e.encodeToken(start); err != nil {
+		return err
+	}
+	for _, node := range m {
+		if node.Element == "" {
+			if err := e.encodeToken(xml.CharData(node.Text)); err != nil {
+				return err
+			}
+			continue
+		}
+		if element := nextElement(&elements, node.Element); element != nil {
+			if err := e.encodeElement(element.value, element.start); err != nil {
+				return err
+			}
+		}
+	}
+	for _, element := range elements {
+		if err := e.encodeElement(element.value, element.start); err != nil {
+			return err
+		}
+	}
+	return e.encodeToken(start.End())
+}
+
+// nextElement removes from elements and returns the first element named
+// local, or else the first value of a wildcard field, or nil.
+func nextElement(elements *[]*mixedElement, local string) *mixedElement {
+	found := -1
+	for i, element := range *elements {
+		if element.name == local {
+			found = i
+			break
+		}
+		if element.name == "" && found < 0 {
+			found = i
+		}
+	}
+	if found < 0 {
+		return nil
+	}
+	element := (*elements)[found]
+	*elements = append((*elements)[:found], (*elements)[found+1:]...)
+	return element
+}
+
+// repeatsElement tells whether the value v of a field is written as one
+// element per item, as encoding/xml writes slices other than lists.
+func repeatsElement(v reflect.Value) bool {
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return false
+	}
+	_, isText := implements(v, textMarshalerTyp)
+	_, isXML := implements(v, marshalerType)
+	return !isText && !isXML
+}
+
+// fieldOf returns the field of fields an element, or an attribute when attr
+// is set, named name is decoded into, as encoding/xml picks it: the field of
+// that name, or else the wildcard field.
+func fieldOf(fields []fieldInfo, name xml.Name, attr bool) *fieldInfo {
+	var wildcard *fieldInfo
+	for i := range fields {
+		f := &fields[i]
+		switch {
+		case f.attr != attr || f.charData || f.innerXML:
+		case f.any:
+			if wildcard == nil {
+				wildcard = f
+			}
+		case f.name.Local == name.Local && (f.name.Space == "" || f.name.Space == name.Space):
+			return f
+		}
+	}
+	return wildcard
+}
+
+// allocField returns the field of the struct v at index, allocating the
+// embedded structs it goes through.
+func allocField(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// unmarshalAttr decodes attr into v, which is addressable.
+func unmarshalAttr(v reflect.Value, attr xml.Attr) error {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if u, ok := v.Addr().Interface().(xml.UnmarshalerAttr); ok {
+		return u.UnmarshalXMLAttr(attr)
+	}
+	return parseText(attr.Value, v)
+}
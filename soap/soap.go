@@ -23,17 +23,20 @@ type SOAPDecoder interface {
 }
 
 type SOAPEnvelopeResponse struct {
-	XMLName     xml.Name `xml:"http://schemas.xmlsoap.org/soap/envelope/ Envelope"`
+	XMLName     xml.Name `xml:"Envelope"`
 	Header      *SOAPHeaderResponse
 	Body        SOAPBodyResponse
 	Attachments []MIMEMultipartAttachment `xml:"attachments,omitempty"`
 }
 
 type SOAPEnvelope struct {
-	XMLName xml.Name      `xml:"soap:Envelope"`
-	XmlNS   string        `xml:"xmlns:soap,attr"`
-	Headers []interface{} `xml:"soap:Header"`
-	Body    SOAPBody
+	XMLName  xml.Name      `xml:"soap:Envelope"`
+	XmlNS    string        `xml:"xmlns:soap,attr"`
+	XmlNSXsi string        `xml:"xmlns:xsi,attr,omitempty"`
+	XmlNSXsd string        `xml:"xmlns:xsd,attr,omitempty"`
+	XmlNSEnc string        `xml:"xmlns:soapenc,attr,omitempty"`
+	Headers  []interface{} `xml:"soap:Header"`
+	Body     SOAPBody
 }
 
 type SOAPHeaderResponse struct {
@@ -63,7 +66,8 @@ type SOAPBodyResponse struct {
 	// we cannot simply store SOAPFault as a pointer to indicate this, since
 	// fault is initialized to non-nil with user-provided detail type.
 	faultOccurred bool
-	Fault         *SOAPFault `xml:",omitempty"`
+	Fault         *SOAPFault   `xml:",omitempty"`
+	Fault12       *SOAP12Fault `xml:",omitempty"`
 }
 
 type MIMEMultipartAttachment struct {
@@ -97,8 +101,9 @@ Loop:
 		case xml.StartElement:
 			if consumed {
 				return xml.UnmarshalError("Found multiple elements inside SOAP body; not wrapped-document/literal WS-I compliant")
-			} else if se.Name.Space == "http://schemas.xmlsoap.org/soap/envelope/" && se.Name.Local == "Fault" {
+			} else if se.Name.Space == XmlNsSoapEnv && se.Name.Local == "Fault" {
 				b.Content = nil
+				b.Fault12 = nil
 
 				b.faultOccurred = true
 				err = d.DecodeElement(b.Fault, &se)
@@ -106,6 +111,17 @@ Loop:
 					return err
 				}
 
+				consumed = true
+			} else if se.Name.Space == XmlNsSoapEnv12 && se.Name.Local == "Fault" {
+				b.Content = nil
+				b.Fault = nil
+
+				b.faultOccurred = true
+				err = d.DecodeElement(b.Fault12, &se)
+				if err != nil {
+					return err
+				}
+
 				consumed = true
 			} else {
 				if err = d.DecodeElement(b.Content, &se); err != nil {
@@ -132,9 +148,13 @@ func (b *SOAPBody) ErrorFromFault() error {
 
 func (b *SOAPBodyResponse) ErrorFromFault() error {
 	if b.faultOccurred {
+		if b.Fault12 != nil {
+			return b.Fault12
+		}
 		return b.Fault
 	}
 	b.Fault = nil
+	b.Fault12 = nil
 	return nil
 }
 
@@ -168,6 +188,54 @@ func (f *SOAPFault) Error() string {
 	return f.String
 }
 
+// SOAP12Fault represents a fault returned within a SOAP 1.2 envelope.
+type SOAP12Fault struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2003/05/soap-envelope Fault"`
+
+	Code   SOAP12FaultCode   `xml:"Code"`
+	Reason SOAP12FaultReason `xml:"Reason"`
+	Node   string            `xml:"Node,omitempty"`
+	Role   string            `xml:"Role,omitempty"`
+	Detail FaultError        `xml:"Detail,omitempty"`
+}
+
+// SOAP12FaultCode holds the code of a SOAP 1.2 fault along with its
+// optional, possibly nested, subcodes.
+type SOAP12FaultCode struct {
+	Value   string           `xml:"Value"`
+	Subcode *SOAP12FaultCode `xml:"Subcode,omitempty"`
+}
+
+// SOAP12FaultReason holds the human readable explanations of a SOAP 1.2 fault.
+type SOAP12FaultReason struct {
+	Text []SOAP12FaultText `xml:"Text"`
+}
+
+// SOAP12FaultText is a SOAP 1.2 fault explanation in a given language.
+type SOAP12FaultText struct {
+	Lang  string `xml:"http://www.w3.org/XML/1998/namespace lang,attr"`
+	Value string `xml:",chardata"`
+}
+
+func (f *SOAP12Fault) Error() string {
+	if f.Detail != nil && f.Detail.HasData() {
+		return f.Detail.ErrorString()
+	}
+	if len(f.Reason.Text) > 0 {
+		return f.Reason.Text[0].Value
+	}
+	return f.Code.Value
+}
+
+// Subcodes returns the values of the fault subcodes, outermost first.
+func (f *SOAP12Fault) Subcodes() []string {
+	var codes []string
+	for c := f.Code.Subcode; c != nil; c = c.Subcode {
+		codes = append(codes, c.Value)
+	}
+	return codes
+}
+
 // HTTPError is returned whenever the HTTP request to the server fails
 type HTTPError struct {
 	//StatusCode is the status code returned in the HTTP response
@@ -187,6 +255,17 @@ const (
 	WssNsType       string = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordText"
 	mtomContentType string = `multipart/related; start-info="application/soap+xml"; type="application/xop+xml"; boundary="%s"`
 	XmlNsSoapEnv    string = "http://schemas.xmlsoap.org/soap/envelope/"
+	XmlNsSoapEnv12  string = "http://www.w3.org/2003/05/soap-envelope"
+)
+
+// SOAPVersion identifies the version of the SOAP protocol used by a Client.
+type SOAPVersion int
+
+const (
+	// SOAP11 sends SOAP 1.1 envelopes with a text/xml content type. It is the default.
+	SOAP11 SOAPVersion = iota
+	// SOAP12 sends SOAP 1.2 envelopes with an application/soap+xml content type.
+	SOAP12
 )
 
 type WSSSecurityHeader struct {
@@ -248,6 +327,9 @@ type options struct {
 	httpHeaders      map[string]string
 	mtom             bool
 	mma              bool
+	version          SOAPVersion
+	encoded          bool
+	validate         bool
 }
 
 var defaultOptions = options{
@@ -329,6 +411,34 @@ func WithMIMEMultipartAttachments() Option {
 	}
 }
 
+// WithSOAPVersion is an Option to set the version of the SOAP envelope
+// sent by the client. SOAP 1.1 is used by default.
+func WithSOAPVersion(version SOAPVersion) Option {
+	return func(o *options) {
+		o.version = version
+	}
+}
+
+// WithSOAPEncoding is an Option to send requests following the SOAP encoding
+// rules (use="encoded"): values are annotated with their xsi:type and slices
+// are sent as SOAP-ENC arrays. Multi-reference values (href/id) of the
+// responses are resolved before decoding.
+func WithSOAPEncoding() Option {
+	return func(o *options) {
+		o.encoded = true
+	}
+}
+
+// WithValidation is an Option to validate requests with their Validate
+// method before sending them. Requests violating the constraints of their
+// schema aren't sent, and the *ValidationError listing the violations is
+// returned instead.
+func WithValidation() Option {
+	return func(o *options) {
+		o.validate = true
+	}
+}
+
 // Client is soap client
 type Client struct {
 	url         string
@@ -375,13 +485,23 @@ func (s *Client) SetHeaders(headers ...interface{}) {
 
 // CallContext performs HTTP POST request with a context
 func (s *Client) CallContext(ctx context.Context, soapAction string, request, response interface{}) error {
-	return s.call(ctx, soapAction, request, response, nil, nil)
+	return s.call(ctx, s.opts, soapAction, request, response, nil, nil)
+}
+
+// CallContextWithOptions performs HTTP POST request with a context.
+// The given options override the client options for this call only.
+func (s *Client) CallContextWithOptions(ctx context.Context, soapAction string, request, response interface{}, opt ...Option) error {
+	opts := *s.opts
+	for _, o := range opt {
+		o(&opts)
+	}
+	return s.call(ctx, &opts, soapAction, request, response, nil, nil)
 }
 
 // Call performs HTTP POST request.
 // Note that if the server returns a status code >= 400, a HTTPError will be returned
 func (s *Client) Call(soapAction string, request, response interface{}) error {
-	return s.call(context.Background(), soapAction, request, response, nil, nil)
+	return s.call(context.Background(), s.opts, soapAction, request, response, nil, nil)
 }
 
 // CallContextWithAttachmentsAndFaultDetail performs HTTP POST request.
@@ -389,13 +509,13 @@ func (s *Client) Call(soapAction string, request, response interface{}) error {
 // On top the attachments array will be filled with attachments returned from the SOAP request.
 func (s *Client) CallContextWithAttachmentsAndFaultDetail(ctx context.Context, soapAction string, request,
 	response interface{}, faultDetail FaultError, attachments *[]MIMEMultipartAttachment) error {
-	return s.call(ctx, soapAction, request, response, faultDetail, attachments)
+	return s.call(ctx, s.opts, soapAction, request, response, faultDetail, attachments)
 }
 
 // CallContextWithFault performs HTTP POST request.
 // Note that if SOAP fault is returned, it will be stored in the error.
 func (s *Client) CallContextWithFaultDetail(ctx context.Context, soapAction string, request, response interface{}, faultDetail FaultError) error {
-	return s.call(ctx, soapAction, request, response, faultDetail, nil)
+	return s.call(ctx, s.opts, soapAction, request, response, faultDetail, nil)
 }
 
 // CallWithFaultDetail performs HTTP POST request.
@@ -403,26 +523,46 @@ func (s *Client) CallContextWithFaultDetail(ctx context.Context, soapAction stri
 // the passed in fault detail is expected to implement FaultError interface,
 // which allows to condense the detail into a short error message.
 func (s *Client) CallWithFaultDetail(soapAction string, request, response interface{}, faultDetail FaultError) error {
-	return s.call(context.Background(), soapAction, request, response, faultDetail, nil)
+	return s.call(context.Background(), s.opts, soapAction, request, response, faultDetail, nil)
 }
 
-func (s *Client) call(ctx context.Context, soapAction string, request, response interface{}, faultDetail FaultError,
-	retAttachments *[]MIMEMultipartAttachment) error {
+func (s *Client) call(ctx context.Context, opts *options, soapAction string, request, response interface{},
+	faultDetail FaultError, retAttachments *[]MIMEMultipartAttachment) error {
+	if opts.validate {
+		if err := Validate(request); err != nil {
+			return err
+		}
+	}
+
 	// SOAP envelope capable of namespace prefixes
 	envelope := SOAPEnvelope{
 		XmlNS: XmlNsSoapEnv,
 	}
+	if opts.version == SOAP12 {
+		envelope.XmlNS = XmlNsSoapEnv12
+	}
 
 	envelope.Headers = s.headers
 
 	envelope.Body.Content = request
+	if opts.encoded {
+		envelope.XmlNSXsi = XmlNsXsi
+		envelope.XmlNSXsd = XmlNsXsd
+		envelope.XmlNSEnc = XmlNsSoapEnc
+		if opts.version == SOAP12 {
+			envelope.XmlNSEnc = XmlNsSoapEnc12
+		}
+		if request != nil {
+			envelope.Body.Content = encodedContent{content: request, version: opts.version}
+		}
+	}
 	buffer := new(bytes.Buffer)
 	var encoder SOAPEncoder
-	if s.opts.mtom && s.opts.mma {
+	if opts.mtom && opts.mma {
 		return fmt.Errorf("cannot use MTOM (XOP) and MMA (MIME Multipart Attachments) option at the same time")
-	} else if s.opts.mtom {
+	} else if opts.mtom {
 		encoder = newMtomEncoder(buffer)
-	} else if s.opts.mma {
+	} else if opts.mma {
 		encoder = newMmaEncoder(buffer, s.attachments)
 	} else {
 		encoder = xml.NewEncoder(buffer)
@@ -440,39 +580,43 @@ func (s *Client) call(ctx context.Context, soapAction string, request, response
 	if err != nil {
 		return err
 	}
-	if s.opts.auth != nil {
-		req.SetBasicAuth(s.opts.auth.Login, s.opts.auth.Password)
+	if opts.auth != nil {
+		req.SetBasicAuth(opts.auth.Login, opts.auth.Password)
 	}
 
 	req = req.WithContext(ctx)
 
-	if s.opts.mtom {
+	if opts.mtom {
 		req.Header.Add("Content-Type", fmt.Sprintf(mtomContentType, encoder.(*mtomEncoder).Boundary()))
-	} else if s.opts.mma {
+	} else if opts.mma {
 		req.Header.Add("Content-Type", fmt.Sprintf(mmaContentType, encoder.(*mmaEncoder).Boundary()))
+	} else if opts.version == SOAP12 {
+		req.Header.Add("Content-Type", soap12ContentType(soapAction))
 	} else {
 		req.Header.Add("Content-Type", "text/xml; charset=\"utf-8\"")
 	}
-	req.Header.Add("SOAPAction", soapAction)
+	if opts.version != SOAP12 {
+		req.Header.Add("SOAPAction", soapAction)
+	}
 	req.Header.Set("User-Agent", "gowsdl/0.1")
-	if s.opts.httpHeaders != nil {
-		for k, v := range s.opts.httpHeaders {
+	if opts.httpHeaders != nil {
+		for k, v := range opts.httpHeaders {
 			req.Header.Set(k, v)
 		}
 	}
 	req.Close = true
 
-	client := s.opts.client
+	client := opts.client
 	if client == nil {
 		tr := &http.Transport{
-			TLSClientConfig: s.opts.tlsCfg,
+			TLSClientConfig: opts.tlsCfg,
 			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
-				d := net.Dialer{Timeout: s.opts.timeout}
+				d := net.Dialer{Timeout: opts.timeout}
 				return d.DialContext(ctx, network, addr)
 			},
-			TLSHandshakeTimeout: s.opts.tlshshaketimeout,
+			TLSHandshakeTimeout: opts.tlshshaketimeout,
 		}
-		client = &http.Client{Timeout: s.opts.contimeout, Transport: tr}
+		client = &http.Client{Timeout: opts.contimeout, Transport: tr}
 	}
 
 	res, err := client.Do(req)
@@ -497,6 +641,9 @@ func (s *Client) call(ctx context.Context, soapAction string, request, response
 		Fault: &SOAPFault{
 			Detail: faultDetail,
 		},
+		Fault12: &SOAP12Fault{
+			Detail: faultDetail,
+		},
 	}
 
 	mtomBoundary, err := getMtomHeader(res.Header.Get("Content-Type"))
@@ -505,7 +652,7 @@ func (s *Client) call(ctx context.Context, soapAction string, request, response
 	}
 
 	var mmaBoundary string
-	if s.opts.mma {
+	if opts.mma {
 		mmaBoundary, err = getMmaHeader(res.Header.Get("Content-Type"))
 		if err != nil {
 			return err
@@ -524,6 +671,19 @@ func (s *Client) call(ctx context.Context, soapAction string, request, response
 		body = io.NopCloser(bytes.NewReader(cachedErrorBody))
 	}
 
+	// multi-reference values are inlined, so the response decodes like a literal one
+	if opts.encoded && mtomBoundary == "" && mmaBoundary == "" {
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return err
+		}
+		// a malformed response is reported by the decoder below
+		if resolved, err := resolveMultiRefs(data); err == nil {
+			data = resolved
+		}
+		body = io.NopCloser(bytes.NewReader(data))
+	}
+
 	var dec SOAPDecoder
 	if mtomBoundary != "" {
 		dec = newMtomDecoder(body, mtomBoundary)
@@ -549,3 +709,12 @@ func (s *Client) call(ctx context.Context, soapAction string, request, response
 	}
 	return respEnvelope.Body.ErrorFromFault()
 }
+
+// soap12ContentType returns the SOAP 1.2 content type, which carries the
+// SOAP action as a parameter instead of a separate SOAPAction header.
+func soap12ContentType(soapAction string) string {
+	if soapAction == "" {
+		return `application/soap+xml; charset="utf-8"`
+	}
+	return fmt.Sprintf(`application/soap+xml; charset="utf-8"; action="%s"`, soapAction)
+}
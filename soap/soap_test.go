@@ -3,6 +3,8 @@ package soap
 import (
 	"bytes"
 	"context"
+	"encoding"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
@@ -363,6 +365,663 @@ func Test_Client_FaultDefault(t *testing.T) {
 	}
 }
 
+func TestClient_SOAP12(t *testing.T) {
+	var gotHeaders http.Header
+	var gotEnvelope struct {
+		XMLName xml.Name
+		Body    struct {
+			Ping Ping
+		} `xml:"http://www.w3.org/2003/05/soap-envelope Body"`
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = r.Header
+		xml.NewDecoder(r.Body).Decode(&gotEnvelope)
+		rsp := `<?xml version="1.0" encoding="utf-8"?>
+		<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope">
+			<env:Body>
+				<PingResponse xmlns="http://example.com/service.xsd">
+					<PingResult>
+						<Message>Pong hi</Message>
+					</PingResult>
+				</PingResponse>
+			</env:Body>
+		</env:Envelope>`
+		w.Header().Set("Content-Type", "application/soap+xml; charset=utf-8")
+		w.Write([]byte(rsp))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, WithSOAPVersion(SOAP12))
+	req := &Ping{Request: &PingRequest{Message: "Hi"}}
+	reply := &PingResponse{}
+	if err := client.Call("GetData", req, reply); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+
+	assert.Equal(t, `application/soap+xml; charset="utf-8"; action="GetData"`, gotHeaders.Get("Content-Type"))
+	assert.Empty(t, gotHeaders.Get("SOAPAction"))
+	assert.Equal(t, XmlNsSoapEnv12, gotEnvelope.XMLName.Space)
+	assert.Equal(t, "Hi", gotEnvelope.Body.Ping.Request.Message)
+	assert.Equal(t, "Pong hi", reply.PingResult.Message)
+}
+
+func TestClient_CallContextWithOptions(t *testing.T) {
+	var gotHeaders []http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = append(gotHeaders, r.Header)
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL)
+	client.CallContextWithOptions(context.Background(), "GetData", struct{}{}, struct{}{}, WithSOAPVersion(SOAP12))
+	client.CallContext(context.Background(), "GetData", struct{}{}, struct{}{})
+
+	// options given to a single call must not leak into the client
+	assert.Len(t, gotHeaders, 2)
+	assert.Equal(t, `application/soap+xml; charset="utf-8"; action="GetData"`, gotHeaders[0].Get("Content-Type"))
+	assert.Equal(t, `text/xml; charset="utf-8"`, gotHeaders[1].Get("Content-Type"))
+	assert.Equal(t, "GetData", gotHeaders[1].Get("SOAPAction"))
+}
+
+func Test_Client_SOAP12Fault(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rsp := `<?xml version="1.0" encoding="utf-8"?>
+<env:Envelope xmlns:env="http://www.w3.org/2003/05/soap-envelope" xmlns:m="http://example.com/faults">
+	<env:Body>
+		<env:Fault>
+			<env:Code>
+				<env:Value>env:Sender</env:Value>
+				<env:Subcode>
+					<env:Value>m:MessageTimeout</env:Value>
+					<env:Subcode>
+						<env:Value>m:Retry</env:Value>
+					</env:Subcode>
+				</env:Subcode>
+			</env:Code>
+			<env:Reason>
+				<env:Text xml:lang="en">Sender Timeout</env:Text>
+				<env:Text xml:lang="de">Zeitüberschreitung</env:Text>
+			</env:Reason>
+			<env:Detail>
+				<SimpleNode>
+					<Detail>detail message</Detail>
+					<Num>7.7</Num>
+				</SimpleNode>
+			</env:Detail>
+		</env:Fault>
+	</env:Body>
+</env:Envelope>`
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(rsp))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, WithSOAPVersion(SOAP12))
+
+	err := client.Call("GetData", &Ping{}, &PingResponse{})
+	fault, ok := err.(*SOAP12Fault)
+	if !ok {
+		t.Fatalf("expected a SOAP12Fault, got %v", err)
+	}
+	assert.EqualError(t, fault, "Sender Timeout")
+	assert.Equal(t, "env:Sender", fault.Code.Value)
+	assert.Equal(t, []string{"m:MessageTimeout", "m:Retry"}, fault.Subcodes())
+	assert.Equal(t, "de", fault.Reason.Text[1].Lang)
+
+	detail := Wrapper{Item: &SimpleNode{}, hasData: true}
+	err = client.CallWithFaultDetail("GetData", &Ping{}, &PingResponse{}, &detail)
+	assert.EqualError(t, err, "7.70: detail message")
+}
+
+type Quote struct {
+	XMLName xml.Name `xml:"urn:quotes GetQuotes"`
+	Symbols []string `xml:"symbols"`
+	Limit   *int32   `xml:"limit"`
+	Since   *XSDDate `xml:"since"`
+}
+
+type Price struct {
+	Symbol string  `xml:"symbol"`
+	Value  float64 `xml:"value"`
+}
+
+func (Price) XSDType() xml.Name {
+	return xml.Name{Space: "urn:quotes", Local: "Price"}
+}
+
+type QuoteResponse struct {
+	XMLName xml.Name `xml:"GetQuotesResponse"`
+	First   *Price   `xml:"first"`
+	Last    *Price   `xml:"last"`
+}
+
+func TestClient_SOAPEncoding(t *testing.T) {
+	var gotBody string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		gotBody = string(body)
+		rsp := `<?xml version="1.0" encoding="utf-8"?>
+		<soapenv:Envelope xmlns:soapenv="http://schemas.xmlsoap.org/soap/envelope/"
+			xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/"
+			xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+			<soapenv:Body>
+				<ns1:GetQuotesResponse xmlns:ns1="urn:quotes" soapenv:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">
+					<first href="#id0"/>
+					<last href="#id0"/>
+				</ns1:GetQuotesResponse>
+				<multiRef id="id0" soapenc:root="0" xsi:type="ns2:Price" xmlns:ns2="urn:quotes">
+					<symbol xsi:type="xsd:string">ACME</symbol>
+					<value xsi:type="xsd:double">12.5</value>
+				</multiRef>
+			</soapenv:Body>
+		</soapenv:Envelope>`
+		w.Write([]byte(rsp))
+	}))
+	defer ts.Close()
+
+	limit := int32(2)
+	client := NewClient(ts.URL, WithSOAPEncoding())
+	req := &Quote{Symbols: []string{"ACME", "INITECH"}, Limit: &limit}
+	reply := &QuoteResponse{}
+	if err := client.Call("GetQuotes", req, reply); err != nil {
+		t.Fatalf("couln't call service: %v", err)
+	}
+
+	for _, want := range []string{
+		`xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance"`,
+		`xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/"`,
+		`<ns1:GetQuotes xmlns:ns1="urn:quotes" soap:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">`,
+		`<symbols xsi:type="soapenc:Array" soapenc:arrayType="xsd:string[2]">`,
+		`<item xsi:type="xsd:string">INITECH</item>`,
+		`<limit xsi:type="xsd:int">2</limit>`,
+		`<since xsi:nil="true"></since>`,
+	} {
+		assert.Contains(t, gotBody, want)
+	}
+
+	if assert.NotNil(t, reply.First) && assert.NotNil(t, reply.Last) {
+		assert.Equal(t, Price{Symbol: "ACME", Value: 12.5}, *reply.First)
+		assert.Equal(t, Price{Symbol: "ACME", Value: 12.5}, *reply.Last)
+	}
+}
+
+func TestClient_SOAPEncodingTypes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := xml.NewEncoder(buf)
+	prices := struct {
+		XMLName xml.Name `xml:"prices"`
+		Items   []*Price `xml:"items"`
+	}{Items: []*Price{{Symbol: "ACME", Value: 1}}}
+	if err := e.Encode(encodedContent{content: prices}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `<prices soap:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">`+
+		`<items xmlns:ns1="urn:quotes" xsi:type="soapenc:Array" soapenc:arrayType="ns1:Price[1]">`+
+		`<item xsi:type="ns1:Price"><symbol xsi:type="xsd:string">ACME</symbol><value xsi:type="xsd:double">1</value></item>`+
+		`</items></prices>`, buf.String())
+}
+
+type StringArray []string
+
+func (a *StringArray) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return UnmarshalArray(d, start, (*[]string)(a))
+}
+
+func (a StringArray) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return MarshalArray(e, start, []string(a), xml.Name{Space: XmlNsXsd, Local: "string"})
+}
+
+func (a StringArray) MarshalEncoded(enc *Encoder, start xml.StartElement) error {
+	return enc.MarshalArray(start, []string(a), xml.Name{Space: XmlNsXsd, Local: "string"})
+}
+
+func TestMarshalArray(t *testing.T) {
+	type Names struct {
+		XMLName xml.Name    `xml:"names"`
+		Names   StringArray `xml:"list"`
+	}
+
+	b, err := xml.Marshal(Names{Names: StringArray{"a", "b"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<names><list xmlns:xsd="http://www.w3.org/2001/XMLSchema" `+
+		`xmlns:soapenc="http://schemas.xmlsoap.org/soap/encoding/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" `+
+		`xsi:type="soapenc:Array" soapenc:arrayType="xsd:string[2]">`+
+		`<item xsi:type="xsd:string">a</item><item xsi:type="xsd:string">b</item></list></names>`, string(b))
+
+	var names Names
+	if err := xml.Unmarshal(b, &names); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, StringArray{"a", "b"}, names.Names)
+}
+
+type Shape struct {
+	Color string `xml:"color"`
+}
+
+func (Shape) XSDType() xml.Name { return xml.Name{Space: "urn:shapes", Local: "Shape"} }
+
+func (s *Shape) GetShape() *Shape { return s }
+
+type Circle struct {
+	*Shape
+	Radius float64 `xml:"radius"`
+}
+
+func (Circle) XSDType() xml.Name { return xml.Name{Space: "urn:shapes", Local: "Circle"} }
+
+type BaseShape interface {
+	GetShape() *Shape
+}
+
+type AnyShape struct {
+	BaseShape
+}
+
+func (a *AnyShape) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return UnmarshalXSIType(d, start, &a.BaseShape, xml.Name{Space: "urn:shapes", Local: "Shape"})
+}
+
+func (a AnyShape) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return MarshalXSIType(e, start, a.BaseShape, xml.Name{Space: "urn:shapes", Local: "Shape"})
+}
+
+func (a AnyShape) MarshalEncoded(enc *Encoder, start xml.StartElement) error {
+	return enc.MarshalXSIType(start, a.BaseShape, xml.Name{Space: "urn:shapes", Local: "Shape"})
+}
+
+func init() {
+	RegisterType(xml.Name{Space: "urn:shapes", Local: "Shape"}, func() interface{} { return new(Shape) })
+	RegisterType(xml.Name{Space: "urn:shapes", Local: "Circle"}, func() interface{} { return new(Circle) })
+}
+
+func TestEncodedMarshaler(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := xml.NewEncoder(buf)
+	content := struct {
+		XMLName xml.Name    `xml:"urn:shapes drawing"`
+		Names   StringArray `xml:"names"`
+		Border  *AnyShape   `xml:"border"`
+	}{
+		Names:  StringArray{"a"},
+		Border: &AnyShape{&Circle{Shape: &Shape{Color: "blue"}, Radius: 2}},
+	}
+	if err := e.Encode(encodedContent{content: content, version: SOAP12}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The values share the namespaces declared by the content and the SOAP
+	// version of the encoding.
+	assert.Equal(t, `<ns1:drawing xmlns:ns1="urn:shapes" soap:encodingStyle="http://www.w3.org/2003/05/soap-encoding">`+
+		`<names soapenc:itemType="xsd:string" soapenc:arraySize="1"><item xsi:type="xsd:string">a</item></names>`+
+		`<border xsi:type="ns1:Circle"><color xsi:type="xsd:string">blue</color><radius xsi:type="xsd:double">2</radius></border>`+
+		`</ns1:drawing>`, buf.String())
+}
+
+func TestXSIType(t *testing.T) {
+	type Drawing struct {
+		XMLName xml.Name    `xml:"drawing"`
+		Shapes  []*AnyShape `xml:"shape,omitempty"`
+		Border  *AnyShape   `xml:"border,omitempty"`
+	}
+
+	drawing := Drawing{Shapes: []*AnyShape{
+		{&Shape{Color: "red"}},
+		{&Circle{Shape: &Shape{Color: "blue"}, Radius: 2}},
+	}}
+	b, err := xml.Marshal(drawing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<drawing><shape><color>red</color></shape>`+
+		`<shape xmlns:ns1="urn:shapes" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="ns1:Circle">`+
+		`<color>blue</color><radius>2</radius></shape></drawing>`, string(b))
+
+	var decoded Drawing
+	if err := xml.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, decoded.Shapes, 2) {
+		assert.Equal(t, &Shape{Color: "red"}, decoded.Shapes[0].BaseShape)
+		assert.Equal(t, &Circle{Shape: &Shape{Color: "blue"}, Radius: 2}, decoded.Shapes[1].BaseShape)
+	}
+	assert.Nil(t, decoded.Border)
+
+	// Prefixes declared by enclosing elements can't be resolved, so the type
+	// is looked up in the namespace of the declared type.
+	data := `<drawing xmlns:s="urn:shapes" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+		`<border xsi:type="s:Circle"><color>green</color><radius>1</radius></border></drawing>`
+	if err := xml.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, decoded.Border) {
+		assert.Equal(t, &Circle{Shape: &Shape{Color: "green"}, Radius: 1}, decoded.Border.BaseShape)
+	}
+}
+
+type CardPayment struct {
+	XMLName xml.Name `xml:"urn:payments CardPayment"`
+	Number  string   `xml:"number"`
+}
+
+type CashPayment struct {
+	Amount float64 `xml:"amount"`
+}
+
+type AnyPayment struct {
+	Value interface{}
+}
+
+func (a *AnyPayment) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return UnmarshalElement(d, start, &a.Value)
+}
+
+func (a AnyPayment) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return MarshalElement(e, start, a.Value)
+}
+
+func init() {
+	RegisterElement(xml.Name{Space: "urn:payments", Local: "CardPayment"}, func() interface{} { return new(CardPayment) })
+	RegisterElement(xml.Name{Space: "urn:payments", Local: "CashPayment"}, func() interface{} { return new(CashPayment) })
+}
+
+func TestSubstitutionGroup(t *testing.T) {
+	type Order struct {
+		XMLName  xml.Name      `xml:"urn:payments order"`
+		Id       int           `xml:"id"`
+		Payments []*AnyPayment `xml:",any,omitempty"`
+	}
+
+	order := Order{Id: 1, Payments: []*AnyPayment{
+		{&CardPayment{Number: "4111"}},
+		{&CashPayment{Amount: 5}},
+	}}
+	b, err := xml.Marshal(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<order xmlns="urn:payments"><id>1</id>`+
+		`<CardPayment xmlns="urn:payments"><number>4111</number></CardPayment>`+
+		`<CashPayment xmlns="urn:payments"><amount>5</amount></CashPayment></order>`, string(b))
+
+	var decoded Order
+	data := strings.Replace(string(b), "</order>", "<Unknown/></order>", 1)
+	if err := xml.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if assert.Len(t, decoded.Payments, 3) {
+		assert.Equal(t, "4111", decoded.Payments[0].Value.(*CardPayment).Number)
+		assert.Equal(t, &CashPayment{Amount: 5}, decoded.Payments[1].Value)
+		assert.Nil(t, decoded.Payments[2].Value)
+	}
+
+	_, err = xml.Marshal(AnyPayment{Value: new(Order)})
+	assert.Error(t, err)
+}
+
+type PostalAddress struct {
+	Street string `xml:"street"`
+	City   string `xml:"city,omitempty"`
+}
+
+type ContactChoice struct {
+	choice Choice
+}
+
+func (ContactChoice) branches() []ChoiceBranch {
+	return []ChoiceBranch{
+		{Name: "Email", Element: "email", New: func() interface{} { return new(string) }},
+		{Name: "Phone", Element: "phone", New: func() interface{} { return new([]string) }},
+		{Name: "Street", New: func() interface{} { return new(PostalAddress) }},
+	}
+}
+
+func (c *ContactChoice) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return c.choice.Unmarshal(d, start, c.branches())
+}
+
+func (c ContactChoice) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return c.choice.Marshal(e, c.branches())
+}
+
+func TestChoice(t *testing.T) {
+	type Contact struct {
+		XMLName xml.Name       `xml:"contact"`
+		Name    string         `xml:"name"`
+		Choice  *ContactChoice `xml:",any,omitempty"`
+	}
+
+	contact := Contact{Name: "a", Choice: new(ContactChoice)}
+	_, err := xml.Marshal(contact)
+	assert.Error(t, err)
+
+	contact.Choice.choice.Set(1, &[]string{"1", "2"})
+	b, err := xml.Marshal(contact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<contact><name>a</name><phone>1</phone><phone>2</phone></contact>`, string(b))
+
+	contact.Choice.choice.Set(2, &PostalAddress{Street: "Main"})
+	b, err = xml.Marshal(contact)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<contact><name>a</name><street>Main</street></contact>`, string(b))
+
+	var decoded Contact
+	data := `<contact><name>a</name><street>Main</street><city>Springfield</city><unknown/></contact>`
+	if err := xml.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, decoded.Choice) {
+		assert.Equal(t, "Street", decoded.Choice.choice.Branch(decoded.Choice.branches()))
+		assert.Equal(t, &PostalAddress{Street: "Main", City: "Springfield"}, decoded.Choice.choice.Value(2))
+		assert.Nil(t, decoded.Choice.choice.Value(0))
+	}
+
+	decoded = Contact{}
+	data = `<contact><name>a</name><phone>1</phone><phone>2</phone></contact>`
+	if err := xml.Unmarshal([]byte(data), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if assert.NotNil(t, decoded.Choice) {
+		assert.Equal(t, &[]string{"1", "2"}, decoded.Choice.choice.Value(1))
+	}
+
+	// Elements of two branches of the same choice are rejected.
+	data = `<contact><name>a</name><email>a@example.com</email><phone>1</phone></contact>`
+	assert.Error(t, xml.Unmarshal([]byte(data), new(Contact)))
+}
+
+type Quantity int
+
+func (v Quantity) Validate() error {
+	var errs Validation
+	errs.Facet("", v, "minInclusive", "1")
+	errs.Facet("", v, "maxExclusive", "100")
+	return errs.Err()
+}
+
+type OrderLine struct {
+	Sku      string
+	Quantity *Quantity
+	Notes    []string
+}
+
+func (t *OrderLine) Validate() error {
+	var errs Validation
+	errs.Facet("Sku", t.Sku, "pattern", "[A-Z]{3}-[0-9]{4}")
+	errs.Occurs("Quantity", t.Quantity, 1, 1)
+	errs.Check("Quantity", t.Quantity)
+	errs.Occurs("Notes", t.Notes, 0, 2)
+	errs.Facet("Notes", t.Notes, "maxLength", "5")
+	return errs.Err()
+}
+
+func TestValidate(t *testing.T) {
+	ok, tooMany := Quantity(3), Quantity(100)
+	lines := []*OrderLine{
+		{Sku: "ABC-1234", Quantity: &ok},
+		{Sku: "abc", Quantity: &tooMany, Notes: []string{"a", "b", "too long"}},
+		{Sku: "ABC-1234"},
+	}
+	assert.NoError(t, Validate(lines[0]))
+
+	err := Validate(lines)
+	if verr, isValidationErr := err.(*ValidationError); assert.True(t, isValidationErr) {
+		assert.Equal(t, []Violation{
+			{Path: "[1].Sku", Message: `value "abc" does not match pattern [A-Z]{3}-[0-9]{4}`},
+			{Path: "[1].Quantity", Message: "value 100 is not less than 100"},
+			{Path: "[1].Notes", Message: "occurs 3 times, at most 2 expected"},
+			{Path: "[1].Notes[2]", Message: "length 8 is greater than 5"},
+			{Path: "[2].Quantity", Message: "is required"},
+		}, verr.Violations)
+	}
+
+	var errs Validation
+	errs.Enumeration("", 1.0, "1", "2")
+	errs.Enumeration("", "3", "1", "2")
+	errs.Facet("", 12.50, "fractionDigits", "1")
+	errs.Facet("", 123.4, "totalDigits", "3")
+	errs.Facet("", []byte("abc"), "length", "2")
+	assert.EqualError(t, errs.Err(), `soap: invalid value: value "3" is not one of 1, 2; `+
+		`value 123.4 has more than 3 digits; length 3 is not 2`)
+
+	errs = Validation{}
+	errs.OccursChoice("Mobile|Landline", 1, -1, []string(nil), []string{})
+	errs.OccursChoice("Email|Phone", 1, 1, new(string), new(string))
+	errs.OccursChoice("Fax|Pager", 1, 1, (*string)(nil), []string{"1"})
+	assert.EqualError(t, errs.Err(), "soap: invalid value: Mobile|Landline: is required; "+
+		"Email|Phone: occurs 2 times, at most 1 expected")
+
+	choice := new(ContactChoice)
+	assert.EqualError(t, choice.choice.Validate(choice.branches()),
+		"soap: invalid value: no branch of the choice between Email, Phone, Street is set")
+	choice.choice.Set(0, new(string))
+	assert.NoError(t, choice.choice.Validate(choice.branches()))
+}
+
+func TestClient_Validation(t *testing.T) {
+	var requests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte(`<Envelope xmlns="http://schemas.xmlsoap.org/soap/envelope/"><Body></Body></Envelope>`))
+	}))
+	defer ts.Close()
+
+	client := NewClient(ts.URL, WithValidation())
+	err := client.Call("GetData", &OrderLine{Sku: "abc"}, &struct{}{})
+	assert.IsType(t, &ValidationError{}, err)
+	assert.Equal(t, 0, requests)
+
+	quantity := Quantity(1)
+	assert.NoError(t, client.Call("GetData", &OrderLine{Sku: "ABC-1234", Quantity: &quantity}, &struct{}{}))
+	assert.Equal(t, 1, requests)
+}
+
+type Priority int32
+
+func (v Priority) IsValid() bool {
+	return v == 1 || v == 2 || v == 10
+}
+
+type State string
+
+func (v State) IsValid() bool {
+	return v == "open" || v == "closed"
+}
+
+type Level Integer
+
+func (v Level) IsValid() bool {
+	return Integer(v).Cmp(MustParseInteger("123456789012345678901234567890")) == 0
+}
+
+func (v *Level) UnmarshalText(text []byte) error {
+	return UnmarshalEnum(string(text), v)
+}
+
+func TestUnmarshalEnum(t *testing.T) {
+	var p Priority
+	assert.NoError(t, UnmarshalEnum(" 010 ", &p))
+	assert.Equal(t, Priority(10), p)
+	assert.Equal(t, "10", FormatEnum(p))
+
+	assert.EqualError(t, UnmarshalEnum("3", &p), `soap: "3" is not a value of soap.Priority`)
+	assert.Error(t, UnmarshalEnum("high", &p))
+	assert.Equal(t, Priority(10), p)
+
+	var s State
+	assert.NoError(t, UnmarshalEnum("open", &s))
+	assert.Equal(t, State("open"), s)
+	assert.Error(t, UnmarshalEnum(" open", &s))
+
+	var l Level
+	assert.NoError(t, UnmarshalEnum("+123456789012345678901234567890", &l))
+	assert.Equal(t, "123456789012345678901234567890", Integer(l).String())
+	assert.Error(t, UnmarshalEnum("1", &l))
+	assert.Error(t, UnmarshalEnum("1.5", &l))
+	assert.Error(t, l.UnmarshalText([]byte("1")))
+	assert.Equal(t, "123456789012345678901234567890", Integer(l).String())
+}
+
+func TestUnmarshalJSONEnum(t *testing.T) {
+	var p Priority
+	assert.NoError(t, UnmarshalJSONEnum([]byte("10"), &p))
+	assert.Equal(t, Priority(10), p)
+	assert.NoError(t, UnmarshalJSONEnum([]byte(`"2"`), &p))
+	assert.Equal(t, Priority(2), p)
+	assert.Error(t, UnmarshalJSONEnum([]byte("3"), &p))
+	assert.Error(t, UnmarshalJSONEnum([]byte(`"3"`), &p))
+	assert.NoError(t, UnmarshalJSONEnum([]byte("null"), &p))
+	assert.Equal(t, Priority(2), p)
+
+	var s State
+	assert.NoError(t, UnmarshalJSONEnum([]byte(`"closed"`), &s))
+	assert.Equal(t, State("closed"), s)
+	assert.Error(t, UnmarshalJSONEnum([]byte(`"pending"`), &s))
+	assert.Error(t, UnmarshalJSONEnum([]byte("1"), &s))
+
+	var l Level
+	assert.NoError(t, UnmarshalJSONEnum([]byte("123456789012345678901234567890"), &l))
+	assert.Error(t, UnmarshalJSONEnum([]byte(`"1"`), &l))
+}
+
+func TestResolveMultiRefs_Cycle(t *testing.T) {
+	data := []byte(`<Envelope><Body><node><next href="#id0"/></node>` +
+		`<multiRef id="id0"><next href="#id0"/></multiRef></Body></Envelope>`)
+
+	resolved, err := resolveMultiRefs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<Envelope><Body><node><next><next href="#id0"></next></next></node></Body></Envelope>`, string(resolved))
+}
+
+func TestResolveMultiRefs_IDs(t *testing.T) {
+	// Only the children of the Body are multi-reference values: the id
+	// attributes of the content are data.
+	data := []byte(`<Envelope><Body><order><line id="id0">a</line><first href="#id0"></first><last href="#id1"></last></order>` +
+		`<multiRef x:id="id1" xmlns:x="urn:other">b</multiRef></Body></Envelope>`)
+	resolved, err := resolveMultiRefs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, string(data), string(resolved))
+
+	// The values of the SOAP 1.2 encoding are identified by enc:id anywhere.
+	data = []byte(`<Envelope xmlns:enc="` + XmlNsSoapEnc12 + `"><Body><order><line enc:id="id0">a</line><first enc:ref="id0"/></order></Body></Envelope>`)
+	resolved, err = resolveMultiRefs(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<Envelope xmlns:enc="`+XmlNsSoapEnc12+`"><Body><order><line enc:id="id0">a</line><first>a</first></order></Body></Envelope>`, string(resolved))
+}
+
 // TestXsdDateTime checks the marshalled xsd datetime
 func TestXsdDateTime(t *testing.T) {
 	type TestDateTime struct {
@@ -775,6 +1434,444 @@ func TestXsdTime(t *testing.T) {
 	}
 }
 
+func TestDecimal(t *testing.T) {
+	for text, expected := range map[string]string{
+		"19.90":                          "19.90",
+		"+.5":                            "0.5",
+		"-0.001":                         "-0.001",
+		" 42 ":                           "42",
+		"12345678901234567890.123456789": "12345678901234567890.123456789",
+	} {
+		d, err := ParseDecimal(text)
+		if assert.NoError(t, err, text) {
+			assert.Equal(t, expected, d.String(), text)
+		}
+	}
+	for _, text := range []string{"", ".", "1e3", "1.2.3", "--1", "NaN"} {
+		_, err := ParseDecimal(text)
+		assert.Error(t, err, text)
+	}
+
+	assert.Equal(t, "19.90", NewDecimal(1990, 2).String())
+	assert.Equal(t, "1500", NewDecimal(15, -2).String())
+	assert.Equal(t, 0, MustParseDecimal("1.5").Cmp(MustParseDecimal("1.50")))
+	assert.Equal(t, -1, MustParseDecimal("0.1").Cmp(MustParseDecimal("0.11")))
+	assert.Equal(t, 0.1, MustParseDecimal("0.1").Float64())
+	assert.Equal(t, "0", Decimal{}.String())
+
+	type Item struct {
+		XMLName xml.Name `xml:"item" json:"-"`
+		Price   Decimal  `xml:"price,attr" json:"price"`
+		Total   Decimal  `xml:"total" json:"total"`
+	}
+	item := Item{Price: MustParseDecimal("0.10"), Total: MustParseDecimal("123456789012345678.99")}
+	data, err := xml.Marshal(item)
+	assert.NoError(t, err)
+	assert.Equal(t, `<item price="0.10"><total>123456789012345678.99</total></item>`, string(data))
+	var decoded Item
+	assert.NoError(t, xml.Unmarshal(data, &decoded))
+	assert.Equal(t, "123456789012345678.99", decoded.Total.String())
+
+	data, err = json.Marshal(item)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"price":0.10,"total":123456789012345678.99}`, string(data))
+	assert.NoError(t, json.Unmarshal([]byte(`{"price":"2.5","total":1.5e2}`), &decoded))
+	assert.Equal(t, "2.5", decoded.Price.String())
+	assert.Equal(t, "150", decoded.Total.String())
+	assert.NoError(t, json.Unmarshal([]byte(`{"total":1e-324}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"total":1e999999999}`), &decoded))
+	assert.Error(t, json.Unmarshal([]byte(`{"total":1e-999999999}`), &decoded))
+}
+
+func TestInteger(t *testing.T) {
+	i, err := ParseInteger(" +0012345678901234567890 ")
+	assert.NoError(t, err)
+	assert.Equal(t, "12345678901234567890", i.String())
+	_, fits := i.Int64()
+	assert.False(t, fits)
+	n, fits := NewInteger(-7).Int64()
+	assert.True(t, fits)
+	assert.Equal(t, int64(-7), n)
+	assert.Equal(t, 1, i.Cmp(NewInteger(1)))
+
+	for _, text := range []string{"", "+", "1.0", "0x10", "1_000"} {
+		_, err := ParseInteger(text)
+		assert.Error(t, err, text)
+	}
+
+	var decoded struct {
+		ID Integer `xml:"id" json:"id"`
+	}
+	assert.NoError(t, xml.Unmarshal([]byte(`<item><id>98765432109876543210</id></item>`), &decoded))
+	assert.Equal(t, "98765432109876543210", decoded.ID.String())
+	data, err := json.Marshal(decoded)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"id":98765432109876543210}`, string(data))
+	assert.Error(t, json.Unmarshal([]byte(`{"id":1.5}`), &decoded))
+}
+
+func TestDuration(t *testing.T) {
+	for text, expected := range map[string]Duration{
+		"P1Y2M3DT4H5M6.7S": {Years: 1, Months: 2, Days: 3, Hours: 4, Minutes: 5, Seconds: 6, Nanoseconds: 700000000},
+		"-P10D":            {Negative: true, Days: 10},
+		"PT36H":            {Hours: 36},
+		"PT0S":             {},
+		"PT0.000000001S":   {Nanoseconds: 1},
+	} {
+		d, err := ParseDuration(text)
+		if assert.NoError(t, err, text) {
+			assert.Equal(t, expected, d, text)
+			assert.Equal(t, text, d.String())
+		}
+	}
+	for _, text := range []string{"", "P", "PT", "P1H", "1D", "P-1D", "PT1.S"} {
+		_, err := ParseDuration(text)
+		assert.Error(t, err, text)
+	}
+
+	d := NewDuration(-(90*time.Minute + 500*time.Millisecond))
+	assert.Equal(t, "-PT1H30M0.5S", d.String())
+	goDuration, ok := d.ToGoDuration()
+	assert.True(t, ok)
+	assert.Equal(t, -(90*time.Minute + 500*time.Millisecond), goDuration)
+	_, ok = Duration{Months: 1}.ToGoDuration()
+	assert.False(t, ok)
+}
+
+func TestGregorianTypes(t *testing.T) {
+	for _, test := range []struct {
+		text  string
+		value interface {
+			encoding.TextMarshaler
+		}
+		decode encoding.TextUnmarshaler
+	}{
+		{"2024", GYear{Year: 2024}, new(GYear)},
+		{"-0044Z", GYear{Year: -44, Location: time.UTC}, new(GYear)},
+		{"2024-02+05:30", GYearMonth{Year: 2024, Month: time.February, Location: time.FixedZone("", 19800)}, new(GYearMonth)},
+		{"--02-29", GMonthDay{Month: time.February, Day: 29}, new(GMonthDay)},
+		{"---15-01:00", GDay{Day: 15, Location: time.FixedZone("", -3600)}, new(GDay)},
+		{"--12", GMonth{Month: time.December}, new(GMonth)},
+	} {
+		text, err := test.value.MarshalText()
+		assert.NoError(t, err)
+		assert.Equal(t, test.text, string(text))
+		if assert.NoError(t, test.decode.UnmarshalText([]byte(test.text)), test.text) {
+			text, _ := test.decode.(encoding.TextMarshaler).MarshalText()
+			assert.Equal(t, test.text, string(text))
+		}
+	}
+
+	for _, test := range []struct {
+		text   string
+		decode encoding.TextUnmarshaler
+	}{
+		{"24", new(GYear)},
+		{"02024", new(GYear)},
+		{"2024-13", new(GYearMonth)},
+		{"--02-30", new(GMonthDay)},
+		{"--2-3", new(GMonthDay)},
+		{"---32", new(GDay)},
+		{"--00", new(GMonth)},
+		{"--12+15:00", new(GMonth)},
+	} {
+		assert.Error(t, test.decode.UnmarshalText([]byte(test.text)), test.text)
+	}
+}
+
+func TestQName(t *testing.T) {
+	type Fault struct {
+		XMLName xml.Name `xml:"fault"`
+		Code    QName    `xml:"code"`
+		Subcode QName    `xml:"subcode,attr"`
+	}
+
+	var fault Fault
+	data := `<fault xmlns:env="urn:envelope" subcode="env:Timeout"><code xmlns:t="urn:tns">t:Server</code></fault>`
+	assert.NoError(t, xml.Unmarshal([]byte(data), &fault))
+	assert.Equal(t, QName{Space: "urn:tns", Local: "Server", Prefix: "t"}, fault.Code)
+	assert.Equal(t, QName{Local: "Timeout", Prefix: "env"}, fault.Subcode)
+	assert.Equal(t, "{urn:tns}Server", fault.Code.String())
+
+	out, err := xml.Marshal(Fault{Code: QName{Space: "urn:tns", Local: "Client"}, Subcode: QName{Local: "Timeout", Prefix: "env"}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<fault subcode="env:Timeout"><code xmlns:ns="urn:tns">ns:Client</code></fault>`, string(out))
+}
+
+type Sizes []int
+
+func (v Sizes) MarshalText() ([]byte, error) {
+	return MarshalList([]int(v))
+}
+
+func (v *Sizes) UnmarshalText(text []byte) error {
+	return UnmarshalList(text, (*[]int)(v))
+}
+
+type Days []XSDDate
+
+func (v Days) MarshalText() ([]byte, error) {
+	return MarshalList([]XSDDate(v))
+}
+
+func (v *Days) UnmarshalText(text []byte) error {
+	return UnmarshalList(text, (*[]XSDDate)(v))
+}
+
+func (v Days) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	return MarshalListAttr(name, []XSDDate(v))
+}
+
+func (v *Days) UnmarshalXMLAttr(attr xml.Attr) error {
+	return UnmarshalList([]byte(attr.Value), (*[]XSDDate)(v))
+}
+
+func TestMarshalList(t *testing.T) {
+	type Calendar struct {
+		XMLName  xml.Name `xml:"calendar"`
+		Sizes    Sizes    `xml:"sizes"`
+		Holidays Days     `xml:"holidays,attr,omitempty"`
+		Closed   Days     `xml:"closed,attr,omitempty"`
+	}
+
+	days := Days{
+		CreateXsdDate(time.Date(2024, 12, 25, 0, 0, 0, 0, time.UTC), false),
+		CreateXsdDate(time.Date(2024, 12, 26, 0, 0, 0, 0, time.UTC), false),
+	}
+	out, err := xml.Marshal(Calendar{Sizes: Sizes{1, 2, 3}, Holidays: days})
+	assert.NoError(t, err)
+	assert.Equal(t, `<calendar holidays="2024-12-25 2024-12-26"><sizes>1 2 3</sizes></calendar>`, string(out))
+
+	var c Calendar
+	assert.NoError(t, xml.Unmarshal([]byte(`<calendar holidays=" 2024-12-25`+"\t"+`2024-12-26 "><sizes>
+		4  5
+	</sizes></calendar>`), &c))
+	assert.Equal(t, Sizes{4, 5}, c.Sizes)
+	assert.Equal(t, days, c.Holidays)
+	assert.Nil(t, c.Closed)
+
+	assert.EqualError(t, xml.Unmarshal([]byte(`<calendar><sizes>1 two</sizes></calendar>`), &c),
+		`soap: cannot decode item "two" of a list of int: strconv.ParseInt: parsing "two": invalid syntax`)
+
+	// The length facets of lists count their items.
+	var errs Validation
+	errs.Facet("Sizes", Sizes{1, 20, 300}, "maxLength", "2")
+	assert.EqualError(t, errs.Err(), "soap: invalid value: Sizes: length 3 is greater than 2")
+
+	buf := new(bytes.Buffer)
+	e := xml.NewEncoder(buf)
+	if err := e.Encode(encodedContent{content: struct {
+		XMLName xml.Name `xml:"order"`
+		Sizes   Sizes    `xml:"sizes"`
+	}{Sizes: Sizes{1, 2}}}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, `<order soap:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/"><sizes>1 2</sizes></order>`, buf.String())
+}
+
+type Code string
+
+func (v Code) Validate() error {
+	var errs Validation
+	errs.Enumeration("", v, "LOCAL", "POST")
+	return errs.Err()
+}
+
+type Carrier struct {
+	union Union
+}
+
+func (Carrier) members() []UnionMember {
+	return []UnionMember{
+		{Name: "Int", New: func() interface{} { return new(int32) }},
+		{Name: "Code", New: func() interface{} { return new(Code) }},
+		{Name: "Date", New: func() interface{} { return new(XSDDate) }},
+	}
+}
+
+func (u Carrier) MarshalText() ([]byte, error) {
+	return u.union.MarshalText()
+}
+
+func (u *Carrier) UnmarshalText(text []byte) error {
+	return u.union.UnmarshalText(text, u.members())
+}
+
+func (u *Carrier) Validate() error {
+	return u.union.Validate(u.members())
+}
+
+func TestUnion(t *testing.T) {
+	type Shipment struct {
+		XMLName xml.Name `xml:"shipment"`
+		Carrier Carrier  `xml:"carrier"`
+		Backup  *Carrier `xml:"backup,attr,omitempty"`
+	}
+
+	// Values are decoded as the first member type they are valid for.
+	var s Shipment
+	assert.NoError(t, xml.Unmarshal([]byte(`<shipment backup="2024-12-25"><carrier>POST</carrier></shipment>`), &s))
+	assert.Equal(t, "Code", s.Carrier.union.Member(s.Carrier.members()))
+	assert.Equal(t, Code("POST"), *s.Carrier.union.Value(1).(*Code))
+	assert.Nil(t, s.Carrier.union.Value(0))
+	assert.Equal(t, "Date", s.Backup.union.Member(s.Backup.members()))
+
+	assert.NoError(t, xml.Unmarshal([]byte(`<shipment><carrier> 42 </carrier></shipment>`), &s))
+	assert.Equal(t, int32(42), *s.Carrier.union.Value(0).(*int32))
+
+	assert.EqualError(t, xml.Unmarshal([]byte(`<shipment><carrier>UPS</carrier></shipment>`), &s),
+		`soap: "UPS" is not a value of any of the member types Int, Code, Date`)
+
+	code := Code("LOCAL")
+	s = Shipment{}
+	s.Carrier.union.Set(1, &code)
+	out, err := xml.Marshal(s)
+	assert.NoError(t, err)
+	assert.Equal(t, `<shipment><carrier>LOCAL</carrier></shipment>`, string(out))
+
+	assert.NoError(t, s.Carrier.Validate())
+	code = "UPS"
+	assert.EqualError(t, s.Carrier.Validate(), `soap: invalid value: value "UPS" is not one of LOCAL, POST`)
+	assert.EqualError(t, (&Carrier{}).Validate(), "soap: invalid value: no value of the member types Int, Code, Date is set")
+}
+
+func TestAnyAttrs(t *testing.T) {
+	type Item struct {
+		XMLName xml.Name `xml:"item"`
+		ID      string   `xml:"id,attr"`
+		Attrs   AnyAttrs `xml:",any,attr"`
+	}
+
+	var item Item
+	assert.NoError(t, xml.Unmarshal([]byte(`<item xmlns:x="urn:ext" xmlns:xsi="`+XmlNsXsi+`" id="1" x:color="red" xsi:type="Item" size="L"/>`), &item))
+	assert.Equal(t, AnyAttrs{
+		{Name: xml.Name{Space: "urn:ext", Local: "color"}, Value: "red"},
+		{Name: xml.Name{Local: "size"}, Value: "L"},
+	}, item.Attrs)
+	size, ok := item.Attrs.Get(xml.Name{Local: "size"})
+	assert.True(t, ok)
+	assert.Equal(t, "L", size)
+
+	out, err := xml.Marshal(item)
+	assert.NoError(t, err)
+	assert.Equal(t, `<item id="1" xmlns:_="urn:ext" _:color="red" size="L"></item>`, string(out))
+}
+
+type Note struct {
+	XMLName xml.Name `xml:"urn:notes note"`
+	Lang    string   `xml:"lang,attr,omitempty"`
+	Attrs   AnyAttrs `xml:",any,attr"`
+	Bold    []string `xml:"b,omitempty"`
+	Link    *string  `xml:"a,omitempty"`
+	Mixed   Mixed    `xml:"-"`
+}
+
+func (t *Note) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	return UnmarshalMixed(d, start, t, &t.Mixed)
+}
+
+func (t Note) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	return MarshalMixed(e, start, &t, t.Mixed)
+}
+
+func TestMixed(t *testing.T) {
+	data := `<note xmlns="urn:notes" xmlns:x="urn:ext" lang="en" x:color="red">Call <b>Ann</b> at <a>noon</a>, not <b>Bob</b>.</note>`
+	var note Note
+	assert.NoError(t, xml.Unmarshal([]byte(data), &note))
+	assert.Equal(t, "en", note.Lang)
+	assert.Equal(t, AnyAttrs{{Name: xml.Name{Space: "urn:ext", Local: "color"}, Value: "red"}}, note.Attrs)
+	assert.Equal(t, []string{"Ann", "Bob"}, note.Bold)
+	assert.Equal(t, "Call  at , not .", note.Mixed.Text())
+
+	out, err := xml.Marshal(note)
+	assert.NoError(t, err)
+	assert.Equal(t, `<note xmlns="urn:notes" lang="en" xmlns:_="urn:ext" _:color="red">Call <b>Ann</b> at <a>noon</a>, not <b>Bob</b>.</note>`, string(out))
+
+	// The elements the content doesn't mention come last.
+	link := "here"
+	out, err = xml.Marshal(Note{Bold: []string{"Hi"}, Link: &link, Mixed: Mixed{{Text: "See "}, {Element: "a"}}})
+	assert.NoError(t, err)
+	assert.Equal(t, `<note xmlns="urn:notes">See <a>here</a><b>Hi</b></note>`, string(out))
+}
+
+type Gift struct {
+	XMLName xml.Name `xml:"urn:gifts gift"`
+	Message string   `xml:"message"`
+}
+
+func TestAnyElement(t *testing.T) {
+	type Order struct {
+		XMLName    xml.Name     `xml:"urn:orders order"`
+		Item       string       `xml:"item"`
+		Extensions []LaxElement `xml:",any"`
+	}
+	RegisterElement(xml.Name{Space: "urn:gifts", Local: "gift"}, func() interface{} { return new(Gift) })
+
+	data := `<order xmlns="urn:orders" xmlns:g="urn:gifts" xmlns:v="urn:vendor">` +
+		`<item>pen</item>` +
+		`<g:gift><g:message>Enjoy</g:message></g:gift>` +
+		`<v:note v:lang="en" xmlns:w="urn:other">Wrap <w:paper color="red"/> &amp; ship<!-- soon --></v:note>` +
+		`</order>`
+	var order Order
+	assert.NoError(t, xml.Unmarshal([]byte(data), &order))
+	if assert.Len(t, order.Extensions, 2) {
+		assert.Equal(t, &Gift{XMLName: xml.Name{Space: "urn:gifts", Local: "gift"}, Message: "Enjoy"}, order.Extensions[0].Value)
+
+		// The elements which aren't registered are kept as they were read,
+		// declaring the namespaces they use.
+		note := order.Extensions[1]
+		assert.Nil(t, note.Value)
+		assert.Equal(t, xml.Name{Space: "urn:vendor", Local: "note"}, note.XMLName)
+		assert.Equal(t, []xml.Attr{
+			{Name: xml.Name{Space: "urn:vendor", Local: "lang"}, Value: "en"},
+			{Name: xml.Name{Space: "xmlns", Local: "w"}, Value: "urn:other"},
+		}, note.Attrs)
+		assert.Equal(t, `Wrap <w:paper color="red"></w:paper> &amp; ship<!-- soon -->`, note.InnerXML)
+	}
+
+	out, err := xml.Marshal(order)
+	assert.NoError(t, err)
+	assert.Equal(t, `<order xmlns="urn:orders"><item>pen</item>`+
+		`<gift xmlns="urn:gifts"><message>Enjoy</message></gift>`+
+		`<note xmlns="urn:vendor" xmlns:ns1="urn:vendor" ns1:lang="en" xmlns:w="urn:other">Wrap <w:paper color="red"></w:paper> &amp; ship<!-- soon --></note>`+
+		`</order>`, string(out))
+
+	var again Order
+	assert.NoError(t, xml.Unmarshal(out, &again))
+	assert.Equal(t, order.Extensions[0].Value, again.Extensions[0].Value)
+	outAgain, err := xml.Marshal(again)
+	assert.NoError(t, err)
+	assert.Equal(t, string(out), string(outAgain))
+
+	// Set encodes a value as the element its type is registered for.
+	var gift StrictElement
+	assert.NoError(t, gift.Set(&Gift{Message: "Thanks"}))
+	assert.Equal(t, xml.Name{Space: "urn:gifts", Local: "gift"}, gift.XMLName)
+	assert.Equal(t, "<message>Thanks</message>", gift.InnerXML)
+	assert.NoError(t, gift.Validate())
+
+	// Strict wildcards only allow the elements which are registered.
+	unknown := StrictElement{LaxElement{AnyElement: AnyElement{XMLName: xml.Name{Space: "urn:vendor", Local: "note"}}}}
+	assert.EqualError(t, Validate([]StrictElement{gift, unknown}),
+		"soap: invalid value: [1]: element {urn:vendor}note is not declared")
+}
+
+func TestClient_SOAPEncodingBuiltinTypes(t *testing.T) {
+	buf := new(bytes.Buffer)
+	e := xml.NewEncoder(buf)
+	order := struct {
+		XMLName xml.Name `xml:"order"`
+		Total   Decimal  `xml:"total"`
+		Period  Duration `xml:"period"`
+	}{Total: MustParseDecimal("9.90"), Period: Duration{Months: 1}}
+	if err := e.Encode(encodedContent{content: order}); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, `<order soap:encodingStyle="http://schemas.xmlsoap.org/soap/encoding/">`+
+		`<total xsi:type="xsd:decimal">9.90</total><period xsi:type="xsd:duration">P1M</period></order>`, buf.String())
+}
+
 func TestHTTPError(t *testing.T) {
 	type httpErrorTest struct {
 		name         string
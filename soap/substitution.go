@@ -0,0 +1,91 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// elements maps the global elements registered with RegisterElement to the
+// functions allocating the Go values they are decoded into, and these Go
+// types back to the elements.
+var elements = struct {
+	sync.RWMutex
+	byName map[xml.Name]func() interface{}
+	byType map[reflect.Type]xml.Name
+}{
+	byName: make(map[xml.Name]func() interface{}),
+	byType: make(map[reflect.Type]xml.Name),
+}
+
+// RegisterElement registers the Go type the global element name is decoded
+// into when it stands for the head of its substitution group. alloc returns a
+// pointer to a new value of the Go type. It is meant to be called by the init
+// functions of generated code.
+func RegisterElement(name xml.Name, alloc func() interface{}) {
+	elements.Lock()
+	defer elements.Unlock()
+
+	elements.byName[name] = alloc
+	elements.byType[reflect.TypeOf(alloc())] = name
+}
+
+// UnmarshalElement decodes the element start into a new value of the Go type
+// registered for its name, and stores it in the interface v points to.
+// Elements which aren't registered are skipped, leaving the interface nil. It
+// is meant to be called by the UnmarshalXML methods of generated substitution
+// group types.
+func UnmarshalElement(d *xml.Decoder, start xml.StartElement, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("soap: UnmarshalElement expects a pointer to an interface")
+	}
+
+	elements.RLock()
+	alloc, ok := elements.byName[start.Name]
+	elements.RUnlock()
+	if !ok {
+		return d.Skip()
+	}
+
+	value := alloc()
+	if err := d.DecodeElement(value, &start); err != nil {
+		return err
+	}
+	rv.Elem().Set(reflect.ValueOf(value))
+	return nil
+}
+
+// MarshalElement encodes v as the element its Go type is registered for.
+// Nothing is encoded for nil values. It is meant to be called by the
+// MarshalXML methods of generated substitution group types.
+func MarshalElement(e *xml.Encoder, start xml.StartElement, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+
+	name, err := registeredElement(rv.Type())
+	if err != nil {
+		return err
+	}
+	start.Name = name
+	return e.EncodeElement(v, start)
+}
+
+// registeredElement returns the name of the element the Go type t is
+// registered for.
+func registeredElement(t reflect.Type) (xml.Name, error) {
+	elements.RLock()
+	name, ok := elements.byType[t]
+	elements.RUnlock()
+	if !ok {
+		return xml.Name{}, fmt.Errorf("soap: no element is registered for %s", t)
+	}
+	return name, nil
+}
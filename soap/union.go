@@ -0,0 +1,104 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// UnionMember describes a member type of a union held by a Union.
+type UnionMember struct {
+	// Name is the name of the member type.
+	Name string
+	// New returns a pointer to a new Go value of the member type.
+	New func() interface{}
+}
+
+// Union holds a value of one of the member types of a union, set or decoded
+// last, among the member types described by the generated union type
+// embedding it.
+type Union struct {
+	// member is the index of the member type plus one, zero when there is
+	// none.
+	member int
+	value  interface{}
+}
+
+// Member returns the name of the member type the value u holds is of, or an
+// empty string when it holds none.
+func (u *Union) Member(members []UnionMember) string {
+	if u.member == 0 {
+		return ""
+	}
+	return members[u.member-1].Name
+}
+
+// Value returns the pointer to the value of the member type i, or nil when u
+// holds a value of another member type.
+func (u *Union) Value(i int) interface{} {
+	if u.member != i+1 {
+		return nil
+	}
+	return u.value
+}
+
+// Set makes u hold the value of the member type i value points to.
+func (u *Union) Set(i int, value interface{}) {
+	u.member, u.value = i+1, value
+}
+
+// UnmarshalText decodes the lexical value text as a value of the first member
+// type, in declaration order, it is valid for: a value it can be decoded into
+// and whose Validate method, if any, succeeds.
+func (u *Union) UnmarshalText(text []byte, members []UnionMember) error {
+	for i, member := range members {
+		value := member.New()
+		if err := parseText(string(text), reflect.ValueOf(value).Elem()); err != nil {
+			continue
+		}
+		if validator, ok := value.(Validator); ok && validator.Validate() != nil {
+			continue
+		}
+		u.Set(i, value)
+		return nil
+	}
+	return fmt.Errorf("soap: %q is not a value of any of the member types %s", text, memberNames(members))
+}
+
+// MarshalText returns the lexical value of the value u holds, or an empty
+// text when it holds none.
+func (u *Union) MarshalText() ([]byte, error) {
+	if u.member == 0 {
+		return nil, nil
+	}
+	attr, err := formatAttr(reflect.ValueOf(u.value), xml.Name{Local: "value"})
+	if err != nil || attr == nil {
+		return nil, err
+	}
+	return []byte(attr.Value), nil
+}
+
+// Validate checks that u holds a value, and validates it with its Validate
+// method, if it has one.
+func (u *Union) Validate(members []UnionMember) error {
+	var errs Validation
+	if u.member == 0 {
+		errs.Add("", "no value of the member types %s is set", memberNames(members))
+	} else {
+		errs.Check("", u.value)
+	}
+	return errs.Err()
+}
+
+func memberNames(members []UnionMember) string {
+	names := make([]string, len(members))
+	for i, member := range members {
+		names[i] = member.Name
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,344 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Validator is implemented by the generated types, whose Validate method
+// checks that a value satisfies the constraints of the schema it was
+// generated from.
+type Validator interface {
+	Validate() error
+}
+
+var validatorTyp = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// Violation is a constraint of a schema a value doesn't satisfy.
+type Violation struct {
+	// Path is the path of the field holding the value, made of field names
+	// and slice indexes relative to the value validated, such as
+	// "Items[2].Price". It is empty for the value validated itself.
+	Path    string
+	Message string
+}
+
+// ValidationError lists the violations found by Validate methods.
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		if v.Path == "" {
+			messages[i] = v.Message
+		} else {
+			messages[i] = v.Path + ": " + v.Message
+		}
+	}
+	return "soap: invalid value: " + strings.Join(messages, "; ")
+}
+
+// Validate validates v and the values it holds with their Validate method,
+// if they have one. The errors found are returned as a *ValidationError.
+func Validate(v interface{}) error {
+	var errs Validation
+	errs.Check("", v)
+	return errs.Err()
+}
+
+// Validation collects the violations found by a generated Validate method.
+// Its zero value is ready to use.
+type Validation struct {
+	violations []Violation
+}
+
+// Err returns the violations found as a *ValidationError, or nil when there
+// are none.
+func (v *Validation) Err() error {
+	if len(v.violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Violations: v.violations}
+}
+
+// Add records the violation of a constraint by the value at path.
+func (v *Validation) Add(path, format string, args ...interface{}) {
+	v.violations = append(v.violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Check validates the value at path with its Validate method, if it has one,
+// recording the violations found relative to path. The values of slices are
+// validated one by one, and nil values aren't validated.
+func (v *Validation) Check(path string, value interface{}) {
+	v.check(path, reflect.ValueOf(value))
+}
+
+func (v *Validation) check(path string, rv reflect.Value) {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return
+	case reflect.Ptr, reflect.Interface:
+		if rv.IsNil() {
+			return
+		}
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			if _, ok := rv.Interface().(Validator); ok {
+				break
+			}
+			for i := 0; i < rv.Len(); i++ {
+				v.check(fmt.Sprintf("%s[%d]", path, i), rv.Index(i))
+			}
+			return
+		}
+	}
+
+	validator, ok := implements(rv, validatorTyp)
+	if !ok {
+		if rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			v.check(path, rv.Elem())
+		}
+		return
+	}
+
+	err := validator.(Validator).Validate()
+	if err == nil {
+		return
+	}
+	if verr, ok := err.(*ValidationError); ok {
+		for _, violation := range verr.Violations {
+			v.violations = append(v.violations, Violation{Path: joinPath(path, violation.Path), Message: violation.Message})
+		}
+		return
+	}
+	v.Add(path, "%v", err)
+}
+
+// Occurs checks that the field at path holds between minOccurs and maxOccurs
+// values, maxOccurs being negative when unbounded: the length of slices, or
+// one for other values but nil ones.
+func (v *Validation) Occurs(path string, value interface{}, minOccurs, maxOccurs int) {
+	v.occurs(path, occurrences(value), minOccurs, maxOccurs)
+}
+
+// OccursChoice checks that the fields at path, holding the branches of a
+// choice, hold between minOccurs and maxOccurs values in all, maxOccurs
+// being negative when unbounded. Values are counted as Occurs counts them.
+func (v *Validation) OccursChoice(path string, minOccurs, maxOccurs int, values ...interface{}) {
+	n := 0
+	for _, value := range values {
+		n += occurrences(value)
+	}
+	v.occurs(path, n, minOccurs, maxOccurs)
+}
+
+// occurrences returns the number of values value holds.
+func occurrences(value interface{}) int {
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return 0
+	case reflect.Ptr, reflect.Interface, reflect.Map:
+		if rv.IsNil() {
+			return 0
+		}
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return rv.Len()
+		}
+		if rv.IsNil() {
+			return 0
+		}
+	}
+	return 1
+}
+
+func (v *Validation) occurs(path string, n, minOccurs, maxOccurs int) {
+	switch {
+	case n == 0 && minOccurs == 1:
+		v.Add(path, "is required")
+	case n < minOccurs:
+		v.Add(path, "occurs %d times, at least %d expected", n, minOccurs)
+	case maxOccurs >= 0 && n > maxOccurs:
+		v.Add(path, "occurs %d times, at most %d expected", n, maxOccurs)
+	}
+}
+
+// Facet checks that the value at path satisfies the facet of XML Schema
+// named facet, whose value is limit. The values of slices other than byte
+// slices and lists are checked one by one, and nil values aren't checked.
+// Lengths are counted in characters, in bytes for binary values, or in items
+// for lists, and only numbers are checked against bounds and digits facets.
+func (v *Validation) Facet(path string, value interface{}, facet, limit string) {
+	rv, ok := indirect(reflect.ValueOf(value))
+	if !ok {
+		return
+	}
+	_, isList := implements(rv, textMarshalerTyp)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 && !isList {
+		for i := 0; i < rv.Len(); i++ {
+			v.Facet(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface(), facet, limit)
+		}
+		return
+	}
+
+	text, err := formatValue(rv)
+	if err != nil {
+		return
+	}
+
+	switch facet {
+	case "pattern":
+		if re := facetPattern(limit); re != nil && !re.MatchString(text) {
+			v.Add(path, "value %q does not match pattern %s", text, limit)
+		}
+	case "length", "minLength", "maxLength":
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return
+		}
+		length := utf8.RuneCountInString(text)
+		if rv.Kind() == reflect.Slice {
+			length = rv.Len()
+		}
+		switch {
+		case facet == "length" && length != n:
+			v.Add(path, "length %d is not %d", length, n)
+		case facet == "minLength" && length < n:
+			v.Add(path, "length %d is less than %d", length, n)
+		case facet == "maxLength" && length > n:
+			v.Add(path, "length %d is greater than %d", length, n)
+		}
+	case "minInclusive", "maxInclusive", "minExclusive", "maxExclusive":
+		x, errX := strconv.ParseFloat(text, 64)
+		bound, errBound := strconv.ParseFloat(limit, 64)
+		if errX != nil || errBound != nil {
+			return
+		}
+		switch {
+		case facet == "minInclusive" && x < bound:
+			v.Add(path, "value %s is less than %s", text, limit)
+		case facet == "maxInclusive" && x > bound:
+			v.Add(path, "value %s is greater than %s", text, limit)
+		case facet == "minExclusive" && x <= bound:
+			v.Add(path, "value %s is not greater than %s", text, limit)
+		case facet == "maxExclusive" && x >= bound:
+			v.Add(path, "value %s is not less than %s", text, limit)
+		}
+	case "totalDigits", "fractionDigits":
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return
+		}
+		total, fraction, ok := digits(text)
+		switch {
+		case !ok:
+		case facet == "totalDigits" && total > n:
+			v.Add(path, "value %s has more than %d digits", text, n)
+		case facet == "fractionDigits" && fraction > n:
+			v.Add(path, "value %s has more than %d fraction digits", text, n)
+		}
+	}
+}
+
+// Enumeration checks that the value at path is one of values. Numbers are
+// compared by value, so that 1.0 is 1. Nil values aren't checked.
+func (v *Validation) Enumeration(path string, value interface{}, values ...string) {
+	rv, ok := indirect(reflect.ValueOf(value))
+	if !ok {
+		return
+	}
+	text, err := formatValue(rv)
+	if err != nil {
+		return
+	}
+
+	x, errX := strconv.ParseFloat(text, 64)
+	for _, allowed := range values {
+		if text == allowed {
+			return
+		}
+		if y, err := strconv.ParseFloat(allowed, 64); errX == nil && err == nil && x == y {
+			return
+		}
+	}
+	v.Add(path, "value %q is not one of %s", text, strings.Join(values, ", "))
+}
+
+// indirect returns the value pointers and interfaces hold, and false for nil
+// values.
+func indirect(rv reflect.Value) (reflect.Value, bool) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
+	}
+	return rv, rv.IsValid()
+}
+
+// joinPath returns the path of a value at path relative to the value at
+// parent.
+func joinPath(parent, path string) string {
+	switch {
+	case parent == "":
+		return path
+	case path == "":
+		return parent
+	case path[0] == '[':
+		return parent + path
+	}
+	return parent + "." + path
+}
+
+// digits returns the numbers of digits and fraction digits of the decimal
+// number text, leading and trailing zeros aside, or false if text isn't a
+// decimal number.
+func digits(text string) (total, fraction int, ok bool) {
+	text = strings.TrimLeft(text, "+-")
+	integer, frac := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		integer, frac = text[:i], text[i+1:]
+	}
+	if integer == "" && frac == "" {
+		return 0, 0, false
+	}
+	for _, r := range integer + frac {
+		if r < '0' || r > '9' {
+			return 0, 0, false
+		}
+	}
+
+	integer = strings.TrimLeft(integer, "0")
+	frac = strings.TrimRight(frac, "0")
+	return len(integer) + len(frac), len(frac), true
+}
+
+// facetPatterns caches the regular expressions of pattern facets.
+var facetPatterns sync.Map
+
+// facetPattern returns the regular expression matching the values matched
+// by the pattern facet of XML Schema pattern, which is anchored, or nil when
+// pattern isn't supported.
+func facetPattern(pattern string) *regexp.Regexp {
+	if re, ok := facetPatterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil
+	}
+	facetPatterns.Store(pattern, re)
+	return re
+}
@@ -0,0 +1,143 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//
+// Duration struct
+//
+
+// Duration is a type for representing xsd:duration, such as P1Y2M3DT4H5M6.7S.
+// Unlike time.Duration, it keeps the years and months a duration is made of,
+// whose lengths vary.
+type Duration struct {
+	Negative                bool
+	Years, Months, Days     int
+	Hours, Minutes, Seconds int
+	Nanoseconds             int
+}
+
+var durationRegexp = regexp.MustCompile(`^(-)?P(?:(\d+)Y)?(?:(\d+)M)?(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+)(?:\.(\d+))?S)?)?$`)
+
+// NewDuration returns the Duration d, in hours, minutes and seconds.
+func NewDuration(d time.Duration) Duration {
+	var duration Duration
+	if d < 0 {
+		duration.Negative = true
+		d = -d
+	}
+	duration.Hours = int(d / time.Hour)
+	duration.Minutes = int(d % time.Hour / time.Minute)
+	duration.Seconds = int(d % time.Minute / time.Second)
+	duration.Nanoseconds = int(d % time.Second)
+	return duration
+}
+
+// ParseDuration parses the lexical value s of an xsd:duration.
+func ParseDuration(s string) (Duration, error) {
+	text := strings.TrimSpace(s)
+	m := durationRegexp.FindStringSubmatch(text)
+	if m == nil || strings.HasSuffix(text, "P") || strings.HasSuffix(text, "T") {
+		return Duration{}, fmt.Errorf("soap: invalid duration %q", s)
+	}
+
+	d := Duration{Negative: m[1] != ""}
+	for i, field := range []*int{&d.Years, &d.Months, &d.Days, &d.Hours, &d.Minutes, &d.Seconds} {
+		if m[i+2] == "" {
+			continue
+		}
+		n, err := strconv.Atoi(m[i+2])
+		if err != nil {
+			return Duration{}, fmt.Errorf("soap: invalid duration %q: %v", s, err)
+		}
+		*field = n
+	}
+	if fraction := m[8]; fraction != "" {
+		if len(fraction) > 9 {
+			fraction = fraction[:9]
+		}
+		d.Nanoseconds, _ = strconv.Atoi(fraction + strings.Repeat("0", 9-len(fraction)))
+	}
+	return d, nil
+}
+
+// ToGoDuration converts d to a time.Duration, which it can't be if it has
+// years or months.
+func (d Duration) ToGoDuration() (time.Duration, bool) {
+	if d.Years != 0 || d.Months != 0 {
+		return 0, false
+	}
+	duration := time.Duration(d.Days)*24*time.Hour +
+		time.Duration(d.Hours)*time.Hour +
+		time.Duration(d.Minutes)*time.Minute +
+		time.Duration(d.Seconds)*time.Second +
+		time.Duration(d.Nanoseconds)
+	if d.Negative {
+		duration = -duration
+	}
+	return duration, true
+}
+
+// String returns the lexical value of d.
+func (d Duration) String() string {
+	var b strings.Builder
+	if d.Negative {
+		b.WriteByte('-')
+	}
+	b.WriteByte('P')
+	for _, c := range []struct {
+		n    int
+		unit byte
+	}{{d.Years, 'Y'}, {d.Months, 'M'}, {d.Days, 'D'}} {
+		if c.n != 0 {
+			b.WriteString(strconv.Itoa(c.n))
+			b.WriteByte(c.unit)
+		}
+	}
+
+	if d.Hours == 0 && d.Minutes == 0 && d.Seconds == 0 && d.Nanoseconds == 0 {
+		if d.Years == 0 && d.Months == 0 && d.Days == 0 {
+			b.WriteString("T0S")
+		}
+		return b.String()
+	}
+	b.WriteByte('T')
+	if d.Hours != 0 {
+		b.WriteString(strconv.Itoa(d.Hours) + "H")
+	}
+	if d.Minutes != 0 {
+		b.WriteString(strconv.Itoa(d.Minutes) + "M")
+	}
+	if d.Seconds != 0 || d.Nanoseconds != 0 {
+		b.WriteString(strconv.Itoa(d.Seconds))
+		if d.Nanoseconds != 0 {
+			b.WriteString(strings.TrimRight(fmt.Sprintf(".%09d", d.Nanoseconds), "0"))
+		}
+		b.WriteByte('S')
+	}
+	return b.String()
+}
+
+// MarshalText implements encoding.TextMarshaler on Duration
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on Duration
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
@@ -0,0 +1,272 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The types of the recurring and partial dates of the Gregorian calendar
+// hold the timezone they are written with in Location, which is nil when
+// they have none.
+
+//
+// GYear struct
+//
+
+// GYear is a type for representing xsd:gYear, such as 2024.
+type GYear struct {
+	Year     int
+	Location *time.Location
+}
+
+// String returns the lexical value of y.
+func (y GYear) String() string {
+	return formatYear(y.Year) + formatTimezone(y.Location, y.Year, time.January, 1)
+}
+
+// MarshalText implements encoding.TextMarshaler on GYear
+func (y GYear) MarshalText() ([]byte, error) {
+	return []byte(y.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on GYear
+func (y *GYear) UnmarshalText(text []byte) error {
+	value, loc, err := splitTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	year, ok := parseYear(value)
+	if !ok {
+		return fmt.Errorf("soap: invalid gYear %q", text)
+	}
+	*y = GYear{Year: year, Location: loc}
+	return nil
+}
+
+//
+// GYearMonth struct
+//
+
+// GYearMonth is a type for representing xsd:gYearMonth, such as 2024-02.
+type GYearMonth struct {
+	Year     int
+	Month    time.Month
+	Location *time.Location
+}
+
+// String returns the lexical value of ym.
+func (ym GYearMonth) String() string {
+	return fmt.Sprintf("%s-%02d", formatYear(ym.Year), ym.Month) + formatTimezone(ym.Location, ym.Year, ym.Month, 1)
+}
+
+// MarshalText implements encoding.TextMarshaler on GYearMonth
+func (ym GYearMonth) MarshalText() ([]byte, error) {
+	return []byte(ym.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on GYearMonth
+func (ym *GYearMonth) UnmarshalText(text []byte) error {
+	value, loc, err := splitTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	i := strings.LastIndexByte(value, '-')
+	if i <= 0 {
+		return fmt.Errorf("soap: invalid gYearMonth %q", text)
+	}
+	year, okYear := parseYear(value[:i])
+	month, okMonth := parseField(value[i+1:], 1, 12)
+	if !okYear || !okMonth {
+		return fmt.Errorf("soap: invalid gYearMonth %q", text)
+	}
+	*ym = GYearMonth{Year: year, Month: time.Month(month), Location: loc}
+	return nil
+}
+
+//
+// GMonthDay struct
+//
+
+// GMonthDay is a type for representing xsd:gMonthDay, a day recurring every
+// year, such as --02-29.
+type GMonthDay struct {
+	Month    time.Month
+	Day      int
+	Location *time.Location
+}
+
+// String returns the lexical value of md.
+func (md GMonthDay) String() string {
+	return fmt.Sprintf("--%02d-%02d", md.Month, md.Day) + formatTimezone(md.Location, 2000, md.Month, md.Day)
+}
+
+// MarshalText implements encoding.TextMarshaler on GMonthDay
+func (md GMonthDay) MarshalText() ([]byte, error) {
+	return []byte(md.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on GMonthDay
+func (md *GMonthDay) UnmarshalText(text []byte) error {
+	value, loc, err := splitTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	if len(value) != 7 || !strings.HasPrefix(value, "--") || value[4] != '-' {
+		return fmt.Errorf("soap: invalid gMonthDay %q", text)
+	}
+	month, okMonth := parseField(value[2:4], 1, 12)
+	day, okDay := parseField(value[5:], 1, 31)
+	// 2000 is a leap year, so that --02-29 is valid.
+	if !okMonth || !okDay || day > daysIn(time.Month(month), 2000) {
+		return fmt.Errorf("soap: invalid gMonthDay %q", text)
+	}
+	*md = GMonthDay{Month: time.Month(month), Day: day, Location: loc}
+	return nil
+}
+
+//
+// GDay struct
+//
+
+// GDay is a type for representing xsd:gDay, a day recurring every month,
+// such as ---15.
+type GDay struct {
+	Day      int
+	Location *time.Location
+}
+
+// String returns the lexical value of d.
+func (d GDay) String() string {
+	return fmt.Sprintf("---%02d", d.Day) + formatTimezone(d.Location, 2000, time.January, d.Day)
+}
+
+// MarshalText implements encoding.TextMarshaler on GDay
+func (d GDay) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on GDay
+func (d *GDay) UnmarshalText(text []byte) error {
+	value, loc, err := splitTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	day, ok := parseField(strings.TrimPrefix(value, "---"), 1, 31)
+	if !ok || !strings.HasPrefix(value, "---") {
+		return fmt.Errorf("soap: invalid gDay %q", text)
+	}
+	*d = GDay{Day: day, Location: loc}
+	return nil
+}
+
+//
+// GMonth struct
+//
+
+// GMonth is a type for representing xsd:gMonth, a month recurring every
+// year, such as --12.
+type GMonth struct {
+	Month    time.Month
+	Location *time.Location
+}
+
+// String returns the lexical value of m.
+func (m GMonth) String() string {
+	return fmt.Sprintf("--%02d", m.Month) + formatTimezone(m.Location, 2000, m.Month, 1)
+}
+
+// MarshalText implements encoding.TextMarshaler on GMonth
+func (m GMonth) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on GMonth
+func (m *GMonth) UnmarshalText(text []byte) error {
+	value, loc, err := splitTimezone(string(text))
+	if err != nil {
+		return err
+	}
+	month, ok := parseField(strings.TrimPrefix(value, "--"), 1, 12)
+	if !ok || !strings.HasPrefix(value, "--") {
+		return fmt.Errorf("soap: invalid gMonth %q", text)
+	}
+	*m = GMonth{Month: time.Month(month), Location: loc}
+	return nil
+}
+
+// splitTimezone splits the timezone, Z or ±hh:mm, off the end of the lexical
+// value text.
+func splitTimezone(text string) (string, *time.Location, error) {
+	text = strings.TrimSpace(text)
+	switch n := len(text); {
+	case strings.HasSuffix(text, "Z"):
+		return text[:n-1], time.UTC, nil
+	case n > 6 && (text[n-6] == '+' || text[n-6] == '-') && text[n-3] == ':':
+		hours, okHours := parseField(text[n-5:n-3], 0, 14)
+		minutes, okMinutes := parseField(text[n-2:], 0, 59)
+		if !okHours || !okMinutes {
+			return "", nil, fmt.Errorf("soap: invalid timezone in %q", text)
+		}
+		offset := hours*3600 + minutes*60
+		if text[n-6] == '-' {
+			offset = -offset
+		}
+		return text[:n-6], time.FixedZone("", offset), nil
+	}
+	return text, nil, nil
+}
+
+// formatTimezone returns the timezone of loc as written on the given day, or
+// an empty string for a nil loc.
+func formatTimezone(loc *time.Location, year int, month time.Month, day int) string {
+	if loc == nil {
+		return ""
+	}
+	_, offset := time.Date(year, month, day, 0, 0, 0, 0, loc).Zone()
+	if offset == 0 {
+		return "Z"
+	}
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// parseYear parses a year of at least four digits, without leading zeros
+// beyond four digits, and possibly negative.
+func parseYear(text string) (int, bool) {
+	digits := strings.TrimPrefix(text, "-")
+	if len(digits) < 4 || len(digits) > 4 && digits[0] == '0' || !isDigits(digits) {
+		return 0, false
+	}
+	year, err := strconv.Atoi(text)
+	return year, err == nil
+}
+
+func formatYear(year int) string {
+	if year < 0 {
+		return fmt.Sprintf("-%04d", -year)
+	}
+	return fmt.Sprintf("%04d", year)
+}
+
+// parseField parses a field of two digits between min and max.
+func parseField(text string, min, max int) (int, bool) {
+	if len(text) != 2 || !isDigits(text) {
+		return 0, false
+	}
+	n, _ := strconv.Atoi(text)
+	return n, n >= min && n <= max
+}
+
+// daysIn returns the number of days of month in year.
+func daysIn(month time.Month, year int) int {
+	return time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
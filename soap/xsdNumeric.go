@@ -0,0 +1,303 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+//
+// Integer struct
+//
+
+// Integer is a type for representing xsd:integer, and the types derived from
+// it without bounds such as xsd:positiveInteger, whose values Go integers
+// can't all hold. The zero value is 0.
+type Integer struct {
+	i *big.Int
+}
+
+// NewInteger returns the Integer x.
+func NewInteger(x int64) Integer {
+	return Integer{big.NewInt(x)}
+}
+
+// IntegerFromBig returns the Integer x.
+func IntegerFromBig(x *big.Int) Integer {
+	return Integer{new(big.Int).Set(x)}
+}
+
+// ParseInteger parses the lexical value s of an xsd:integer.
+func ParseInteger(s string) (Integer, error) {
+	text := strings.TrimSpace(s)
+	if !isInteger(text) {
+		return Integer{}, fmt.Errorf("soap: invalid integer %q", s)
+	}
+	i, _ := new(big.Int).SetString(text, 10)
+	return Integer{i}, nil
+}
+
+// MustParseInteger is like ParseInteger but panics if s is not an integer.
+// It is used by the generated enumerations.
+func MustParseInteger(s string) Integer {
+	i, err := ParseInteger(s)
+	if err != nil {
+		panic(err)
+	}
+	return i
+}
+
+// Big returns i as a big.Int.
+func (i Integer) Big() *big.Int {
+	if i.i == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(i.i)
+}
+
+// Int64 returns i as an int64, and whether it fits.
+func (i Integer) Int64() (int64, bool) {
+	if i.i == nil {
+		return 0, true
+	}
+	return i.i.Int64(), i.i.IsInt64()
+}
+
+// Cmp compares i and j, returning -1, 0 or +1 as i is less than, equal to or
+// greater than j.
+func (i Integer) Cmp(j Integer) int {
+	return i.Big().Cmp(j.Big())
+}
+
+// String returns the canonical lexical value of i.
+func (i Integer) String() string {
+	if i.i == nil {
+		return "0"
+	}
+	return i.i.String()
+}
+
+// MarshalText implements encoding.TextMarshaler on Integer
+func (i Integer) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on Integer
+func (i *Integer) UnmarshalText(text []byte) error {
+	v, err := ParseInteger(string(text))
+	if err != nil {
+		return err
+	}
+	*i = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler on Integer, as a JSON number
+func (i Integer) MarshalJSON() ([]byte, error) {
+	return i.MarshalText()
+}
+
+// UnmarshalJSON implements json.Unmarshaler on Integer, from a JSON number
+// or string
+func (i *Integer) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonNumber(data)
+	if !ok {
+		return err
+	}
+	return i.UnmarshalText(text)
+}
+
+//
+// Decimal struct
+//
+
+// Decimal is a type for representing xsd:decimal without the rounding errors
+// of floats: its value is an unscaled integer times ten to the power of minus
+// its scale, so that 19.90 keeps its two fraction digits. The zero value is
+// 0.
+type Decimal struct {
+	unscaled *big.Int
+	scale    int
+}
+
+// NewDecimal returns the Decimal unscaled × 10^-scale, such as 19.90 for
+// NewDecimal(1990, 2).
+func NewDecimal(unscaled int64, scale int) Decimal {
+	if scale < 0 {
+		return Decimal{new(big.Int).Mul(big.NewInt(unscaled), pow10(-scale)), 0}
+	}
+	return Decimal{big.NewInt(unscaled), scale}
+}
+
+// ParseDecimal parses the lexical value s of an xsd:decimal.
+func ParseDecimal(s string) (Decimal, error) {
+	d, ok := parseDecimal(strings.TrimSpace(s), false)
+	if !ok {
+		return Decimal{}, fmt.Errorf("soap: invalid decimal %q", s)
+	}
+	return d, nil
+}
+
+// MustParseDecimal is like ParseDecimal but panics if s is not a decimal. It
+// is used by the generated enumerations.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// maxDecimalExponent bounds the exponents of the decimals parsed from JSON
+// numbers, whose digits are all kept: it is beyond those of float64 values.
+const maxDecimalExponent = 1000
+
+// parseDecimal parses the decimal text, which may have an exponent if
+// allowed, as JSON numbers may.
+func parseDecimal(text string, exponents bool) (Decimal, bool) {
+	exponent := 0
+	if i := strings.IndexAny(text, "eE"); i >= 0 && exponents {
+		e, err := strconv.Atoi(text[i+1:])
+		if err != nil || e > maxDecimalExponent || e < -maxDecimalExponent {
+			return Decimal{}, false
+		}
+		text, exponent = text[:i], e
+	}
+
+	sign := ""
+	if text != "" && (text[0] == '+' || text[0] == '-') {
+		sign, text = text[:1], text[1:]
+	}
+	integer, fraction := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		integer, fraction = text[:i], text[i+1:]
+	}
+	if integer+fraction == "" || !isDigits(integer) || !isDigits(fraction) {
+		return Decimal{}, false
+	}
+
+	unscaled, _ := new(big.Int).SetString(sign+integer+fraction, 10)
+	scale := len(fraction) - exponent
+	if scale < 0 {
+		return Decimal{unscaled.Mul(unscaled, pow10(-scale)), 0}, true
+	}
+	return Decimal{unscaled, scale}, true
+}
+
+// Rat returns d as a big.Rat.
+func (d Decimal) Rat() *big.Rat {
+	if d.unscaled == nil {
+		return new(big.Rat)
+	}
+	return new(big.Rat).SetFrac(d.unscaled, pow10(d.scale))
+}
+
+// Float64 returns the float64 nearest to d.
+func (d Decimal) Float64() float64 {
+	f, _ := d.Rat().Float64()
+	return f
+}
+
+// Cmp compares d and e by value, returning -1, 0 or +1 as d is less than,
+// equal to or greater than e.
+func (d Decimal) Cmp(e Decimal) int {
+	return d.Rat().Cmp(e.Rat())
+}
+
+// String returns the lexical value of d, with as many fraction digits as its
+// scale.
+func (d Decimal) String() string {
+	if d.unscaled == nil {
+		return "0"
+	}
+
+	digits := new(big.Int).Abs(d.unscaled).String()
+	if d.scale > 0 {
+		if len(digits) <= d.scale {
+			digits = strings.Repeat("0", d.scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-d.scale] + "." + digits[len(digits)-d.scale:]
+	}
+	if d.unscaled.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// MarshalText implements encoding.TextMarshaler on Decimal
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler on Decimal
+func (d *Decimal) UnmarshalText(text []byte) error {
+	v, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler on Decimal, as a JSON number
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return d.MarshalText()
+}
+
+// UnmarshalJSON implements json.Unmarshaler on Decimal, from a JSON number
+// or string
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	text, ok, err := jsonNumber(data)
+	if !ok {
+		return err
+	}
+	v, valid := parseDecimal(string(text), true)
+	if !valid {
+		return fmt.Errorf("soap: invalid decimal %s", data)
+	}
+	*d = v
+	return nil
+}
+
+// jsonNumber returns the text of the JSON number or string data, and false
+// when data is null or not a number.
+func jsonNumber(data []byte) ([]byte, bool, error) {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		return nil, false, nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		text, err := strconv.Unquote(string(data))
+		if err != nil {
+			return nil, false, fmt.Errorf("soap: invalid JSON number %s", data)
+		}
+		return []byte(text), true, nil
+	}
+	return data, true, nil
+}
+
+func isInteger(text string) bool {
+	if text != "" && (text[0] == '+' || text[0] == '-') {
+		text = text[1:]
+	}
+	return text != "" && isDigits(text)
+}
+
+func isDigits(text string) bool {
+	for _, r := range text {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
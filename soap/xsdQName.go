@@ -0,0 +1,111 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+//
+// QName struct
+//
+
+// QName is a type for representing xsd:QName, a name qualified by a
+// namespace such as tns:Item.
+//
+// Encoding/xml doesn't tell the namespaces declared by the ancestors of an
+// element, so that decoding only resolves the prefix of a name when the
+// element holding it declares the prefix. Otherwise, Space is left empty and
+// the name keeps its Prefix.
+type QName struct {
+	Space string `json:"space,omitempty"`
+	Local string `json:"local"`
+	// Prefix is the prefix the name is written with. Names with a Space but
+	// no Prefix are written with the prefix ns.
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// String returns q as {Space}Local, or as Prefix:Local when its namespace is
+// unknown.
+func (q QName) String() string {
+	switch {
+	case q.Space != "":
+		return "{" + q.Space + "}" + q.Local
+	case q.Prefix != "":
+		return q.Prefix + ":" + q.Local
+	}
+	return q.Local
+}
+
+// lexical returns the lexical value of q, and the prefix it needs declared.
+func (q QName) lexical() (string, string) {
+	prefix := q.Prefix
+	if q.Space != "" && prefix == "" {
+		prefix = "ns"
+	}
+	if prefix == "" {
+		return q.Local, ""
+	}
+	return prefix + ":" + q.Local, prefix
+}
+
+// MarshalXML implements xml.Marshaler on QName, declaring the prefix of its
+// namespace on the element
+func (q QName) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	text, prefix := q.lexical()
+	if q.Space != "" {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: q.Space})
+	}
+	return e.EncodeElement(text, start)
+}
+
+// MarshalXMLAttr implements xml.MarshalerAttr on QName. The prefix of the
+// name must be declared by the element of the attribute, and empty names are
+// not written.
+func (q QName) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+	if q.Local == "" {
+		return xml.Attr{}, nil
+	}
+	text, _ := q.lexical()
+	return xml.Attr{Name: name, Value: text}, nil
+}
+
+// UnmarshalXML implements xml.Unmarshaler on QName
+func (q *QName) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var text string
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return err
+	}
+	*q = parseQName(text, start.Attr)
+	return nil
+}
+
+// UnmarshalXMLAttr implements xml.UnmarshalerAttr on QName
+func (q *QName) UnmarshalXMLAttr(attr xml.Attr) error {
+	*q = parseQName(attr.Value, nil)
+	return nil
+}
+
+// parseQName parses the lexical value text of a QName, resolving its prefix
+// with the namespace declarations of attrs.
+func parseQName(text string, attrs []xml.Attr) QName {
+	text = strings.TrimSpace(text)
+	var q QName
+	if i := strings.IndexByte(text, ':'); i >= 0 {
+		q.Prefix, q.Local = text[:i], text[i+1:]
+	} else {
+		q.Local = text
+	}
+
+	for _, attr := range attrs {
+		switch {
+		case q.Prefix == "" && attr.Name.Space == "" && attr.Name.Local == "xmlns",
+			q.Prefix != "" && attr.Name.Space == "xmlns" && attr.Name.Local == q.Prefix:
+			q.Space = attr.Value
+		}
+	}
+	return q
+}
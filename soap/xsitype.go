@@ -0,0 +1,142 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package soap
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// xsiTypes maps the XML Schema types registered with RegisterType to the
+// functions allocating the Go values they are decoded into.
+var xsiTypes = struct {
+	sync.RWMutex
+	byName  map[xml.Name]func() interface{}
+	byLocal map[string][]xml.Name
+}{
+	byName:  make(map[xml.Name]func() interface{}),
+	byLocal: make(map[string][]xml.Name),
+}
+
+// RegisterType registers the Go type XML Schema type name is decoded into,
+// when an element names it with xsi:type. alloc returns a pointer to a new
+// value of the Go type. It is meant to be called by the init functions of
+// generated code.
+func RegisterType(name xml.Name, alloc func() interface{}) {
+	xsiTypes.Lock()
+	defer xsiTypes.Unlock()
+
+	if _, ok := xsiTypes.byName[name]; !ok {
+		xsiTypes.byLocal[name.Local] = append(xsiTypes.byLocal[name.Local], name)
+	}
+	xsiTypes.byName[name] = alloc
+}
+
+// registeredType returns the allocation function of the type name, which is
+// looked up by its local name when its namespace is unknown.
+func registeredType(name xml.Name) (func() interface{}, bool) {
+	xsiTypes.RLock()
+	defer xsiTypes.RUnlock()
+
+	if alloc, ok := xsiTypes.byName[name]; ok {
+		return alloc, true
+	}
+	if name.Space == "" {
+		if names := xsiTypes.byLocal[name.Local]; len(names) == 1 {
+			return xsiTypes.byName[names[0]], true
+		}
+	}
+	return nil, false
+}
+
+// xsiType returns the type named by the xsi:type attribute of start. The
+// prefix of the type is resolved through the namespace declarations of start
+// only, since the decoder doesn't expose the ones of the enclosing elements:
+// types with another prefix have no namespace.
+func xsiType(start xml.StartElement) (xml.Name, bool) {
+	for _, attr := range start.Attr {
+		if attr.Name.Space != XmlNsXsi || attr.Name.Local != "type" {
+			continue
+		}
+
+		value := strings.TrimSpace(attr.Value)
+		i := strings.Index(value, ":")
+		if i < 0 {
+			return xml.Name{Local: value}, true
+		}
+		name := xml.Name{Local: value[i+1:]}
+		for _, decl := range start.Attr {
+			if decl.Name.Space == "xmlns" && decl.Name.Local == value[:i] {
+				name.Space = decl.Value
+			}
+		}
+		return name, true
+	}
+	return xml.Name{}, false
+}
+
+// UnmarshalXSIType decodes the element start into a new value of the type
+// named by its xsi:type attribute, or of declared when it has none, and
+// stores it in the interface v points to. The types must have been
+// registered with RegisterType. Elements of an unknown type are decoded as
+// declared. It is meant to be called by the UnmarshalXML methods of generated
+// polymorphic types.
+func UnmarshalXSIType(d *xml.Decoder, start xml.StartElement, v interface{}, declared xml.Name) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.Elem().Kind() != reflect.Interface {
+		return fmt.Errorf("soap: UnmarshalXSIType expects a pointer to an interface")
+	}
+	target := rv.Elem()
+
+	alloc, _ := registeredType(declared)
+	if name, found := xsiType(start); found {
+		if name.Space == "" {
+			// Types of the namespace of the declared type are the most
+			// likely ones when the prefix couldn't be resolved.
+			if a, ok := registeredType(xml.Name{Space: declared.Space, Local: name.Local}); ok {
+				alloc = a
+			} else if a, ok := registeredType(name); ok {
+				alloc = a
+			}
+		} else if a, ok := registeredType(name); ok {
+			alloc = a
+		}
+	}
+	if alloc == nil {
+		return fmt.Errorf("soap: type %s %s is not registered", declared.Space, declared.Local)
+	}
+
+	value := alloc()
+	if err := d.DecodeElement(value, &start); err != nil {
+		return err
+	}
+
+	val := reflect.ValueOf(value)
+	if !val.Type().AssignableTo(target.Type()) {
+		return fmt.Errorf("soap: %s does not implement %s", val.Type(), target.Type())
+	}
+	target.Set(val)
+	return nil
+}
+
+// MarshalXSIType encodes v as the element start, annotating it with the
+// xsi:type attribute of its XML Schema type when it differs from declared,
+// as given by its XSDType method. Nothing is encoded for nil values. It is
+// meant to be called by the MarshalXML methods of generated polymorphic
+// types.
+func MarshalXSIType(e *xml.Encoder, start xml.StartElement, v interface{}, declared xml.Name) error {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() || rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil
+	}
+
+	if typer, ok := v.(XSDTyper); ok && typer.XSDType() != declared {
+		newSOAPEncoder(e, SOAP11).setType(&start, typer.XSDType())
+	}
+	return e.EncodeElement(v, start)
+}
@@ -0,0 +1,96 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"log"
+)
+
+// substitutionGroup describes the head of a substitution group, which the
+// elements of the group may stand for.
+type substitutionGroup struct {
+	// Name is the QName of the head element.
+	Name xml.Name
+	// GoName is the name of the Go type generated for the head element.
+	GoName string
+	// Holder is the name of the struct holding the head element or any
+	// element of its group.
+	Holder string
+
+	pkg *goPackage
+}
+
+// collectSubstitutionGroups returns the heads of the substitution groups of
+// all schemas, keyed by QName.
+func (g *GoWSDL) collectSubstitutionGroups(uniqueName func(*goPackage, string) string) map[xml.Name]*substitutionGroup {
+	groups := make(map[xml.Name]*substitutionGroup)
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, elm := range schema.Elements {
+			if elm.SubstitutionGroup == "" {
+				continue
+			}
+
+			decl, goName, ok := g.symbols.lookup(elementSymbol, resolveQName(elm.SubstitutionGroup, schema.Xmlns))
+			if !ok {
+				log.Printf("[WARN] Substitution group head %s of element %s not found", elm.SubstitutionGroup, elm.Name)
+				continue
+			}
+			if groups[decl] == nil {
+				pkg := g.packageOf(decl.Space)
+				groups[decl] = &substitutionGroup{
+					Name:   decl,
+					GoName: goName,
+					Holder: uniqueName(pkg, "Any"+goName),
+					pkg:    pkg,
+				}
+			}
+		}
+	}
+
+	return groups
+}
+
+// findSubstitutionGroup returns the substitution group the global element
+// name of the schema being generated is the head of, if any.
+func (g *GoWSDL) findSubstitutionGroup(name string) *substitutionGroup {
+	return g.substitutionGroups[xml.Name{Space: g.currentNamespace, Local: name}]
+}
+
+// substitutable tells whether the global element elm of the schema being
+// generated may stand for the head of a substitution group: it is either a
+// member of the group, or its head when not abstract.
+func (g *GoWSDL) substitutable(elm *XSDElement) bool {
+	if elm.SubstitutionGroup != "" {
+		decl, _, ok := g.symbols.lookup(elementSymbol, resolveQName(elm.SubstitutionGroup, g.currentSchema.Xmlns))
+		return ok && g.substitutionGroups[decl] != nil
+	}
+	return !elm.Abstract && g.findSubstitutionGroup(elm.Name) != nil
+}
+
+// goGroupType returns the Go type of a reference to the global element name
+// when it is the head of a substitution group, or an empty string otherwise.
+func (g *GoWSDL) goGroupType(name string, xmlns map[string]string, imports *importSet) string {
+	group := g.substitutionGroupOf(name, xmlns)
+	if group == nil {
+		return ""
+	}
+	return "*" + imports.qualify(group.pkg, group.Holder)
+}
+
+// substitutionGroupOf returns the substitution group the global element name
+// is the head of, if any.
+func (g *GoWSDL) substitutionGroupOf(name string, xmlns map[string]string) *substitutionGroup {
+	if len(g.substitutionGroups) == 0 {
+		return nil
+	}
+
+	decl, _, ok := g.symbols.lookup(elementSymbol, resolveQName(name, xmlns))
+	if !ok {
+		return nil
+	}
+	return g.substitutionGroups[decl]
+}
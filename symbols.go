@@ -0,0 +1,282 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// symbolKind tells apart the XSD symbol spaces, which all end up in the
+// single Go type namespace.
+type symbolKind int
+
+const (
+	typeSymbol symbolKind = iota
+	elementSymbol
+)
+
+type symbolKey struct {
+	kind symbolKind
+	name xml.Name
+}
+
+// symbolTable maps the global types and elements of all schemas, keyed by
+// QName, to the names of the Go types generated for them.
+type symbolTable struct {
+	names map[symbolKey]string
+	// locals holds the QNames declared for each local name, used when a
+	// reference can't be resolved to a namespace.
+	locals map[symbolKind]map[string][]xml.Name
+}
+
+// buildSymbolTable names the global types and elements of all schemas. When
+// the same name is declared in several namespaces generated in the same
+// package, the declarations of the first namespace keep it and the others are
+// prefixed with a name derived from their namespace, or the one configured
+// with WithNamespacePrefix. Names still colliding in a package, such as the
+// ones of an element and of a type other than its own, or of foo-bar and
+// foobar, are made unique by the namer: types are named first, and an element
+// of a type of the same name shares its Go type.
+func (g *GoWSDL) buildSymbolTable() *symbolTable {
+	st := &symbolTable{
+		names: make(map[symbolKey]string),
+		locals: map[symbolKind]map[string][]xml.Name{
+			typeSymbol:    make(map[string][]xml.Name),
+			elementSymbol: make(map[string][]xml.Name),
+		},
+	}
+
+	var keys []symbolKey
+	elements := make(map[symbolKey]*XSDElement)
+	schemas := make(map[symbolKey]*XSDSchema)
+	declare := func(kind symbolKind, schema *XSDSchema, name string) symbolKey {
+		key := symbolKey{kind: kind, name: xml.Name{Space: schema.TargetNamespace, Local: name}}
+		if _, ok := st.names[key]; ok {
+			return key
+		}
+		st.names[key] = ""
+		st.locals[kind][name] = append(st.locals[kind][name], key.name)
+		keys = append(keys, key)
+		schemas[key] = schema
+		return key
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, t := range schema.SimpleType {
+			declare(typeSymbol, schema, t.Name)
+		}
+		for _, t := range schema.ComplexTypes {
+			declare(typeSymbol, schema, t.Name)
+		}
+		for _, elm := range schema.Elements {
+			key := declare(elementSymbol, schema, elm.Name)
+			if elements[key] == nil {
+				elements[key] = elm
+			}
+		}
+	}
+
+	// Group the namespaces declaring each Go name of a package, in
+	// declaration order.
+	type scopedName struct {
+		pkg    *goPackage
+		goName string
+	}
+	namespaces := make(map[scopedName][]string)
+	for _, key := range keys {
+		name := scopedName{g.packageOf(key.name.Space), g.makePublicFn(replaceReservedWords(key.name.Local))}
+		if !containsString(namespaces[name], key.name.Space) {
+			namespaces[name] = append(namespaces[name], key.name.Space)
+		}
+	}
+
+	prefixes := g.namespacePrefixes()
+	for _, kind := range []symbolKind{typeSymbol, elementSymbol} {
+		for _, key := range keys {
+			if key.kind != kind {
+				continue
+			}
+
+			pkg := g.packageOf(key.name.Space)
+			goName := g.makePublicFn(replaceReservedWords(key.name.Local))
+			if ns := namespaces[scopedName{pkg, goName}]; ns[0] != key.name.Space {
+				goName = g.makePublicFn(replaceReservedWords(prefixes[key.name.Space] + makePublic(key.name.Local)))
+			}
+
+			if elm := elements[key]; elm != nil && elm.Type != "" {
+				typeKey := symbolKey{kind: typeSymbol, name: resolveQName(elm.Type, schemas[key].Xmlns)}
+				if typeName, ok := st.names[typeKey]; ok && typeName == g.names.identifier(goName) && g.packageOf(typeKey.name.Space) == pkg {
+					st.names[key] = typeName
+					g.names.mappings = append(g.names.mappings, nameMapping{Kind: "element", XSDName: "{" + key.name.Space + "}" + key.name.Local, GoName: typeName, Preferred: typeName})
+					continue
+				}
+			}
+
+			kindName := "type"
+			if kind == elementSymbol {
+				kindName = "element"
+			}
+			st.names[key] = g.names.declareType(pkg, kindName, "{"+key.name.Space+"}"+key.name.Local, goName)
+		}
+	}
+
+	return st
+}
+
+// namespacePrefixes returns the prefix used for the colliding names of each
+// target namespace. Derived prefixes are made unique by a numeric suffix.
+func (g *GoWSDL) namespacePrefixes() map[string]string {
+	prefixes := make(map[string]string)
+	used := make(map[string]bool)
+
+	for _, prefix := range g.nsPrefixes {
+		used[prefix] = true
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		ns := schema.TargetNamespace
+		if _, ok := prefixes[ns]; ok {
+			continue
+		}
+		if prefix, ok := g.nsPrefixes[ns]; ok {
+			prefixes[ns] = prefix
+			continue
+		}
+
+		prefix := namespacePrefix(ns)
+		unique := prefix
+		for i := 2; used[unique]; i++ {
+			unique = prefix + strconv.Itoa(i)
+		}
+		used[unique] = true
+		prefixes[ns] = unique
+	}
+
+	return prefixes
+}
+
+var (
+	versionSegment = regexp.MustCompile(`^[vV]?[0-9][0-9._-]*$`)
+	nonIdentifier  = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+)
+
+// genericSegments are namespace segments telling nothing about the
+// namespace, as in "urn:epcglobal:epcis:xsd:1".
+var genericSegments = map[string]bool{
+	"xsd":     true,
+	"xml":     true,
+	"wsdl":    true,
+	"schema":  true,
+	"schemas": true,
+}
+
+// namespacePrefix derives a Go identifier from the last meaningful segment
+// of a namespace, e.g. "Types" for "http://example.com/inventory/types/v1".
+func namespacePrefix(ns string) string {
+	segments := strings.FieldsFunc(ns, func(r rune) bool {
+		return r == '/' || r == ':' || r == '#'
+	})
+
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := segments[i]
+		if i == 0 && len(segments) > 1 {
+			// URI scheme
+			break
+		}
+		if versionSegment.MatchString(segment) || genericSegments[strings.ToLower(segment)] {
+			continue
+		}
+
+		segment = strings.TrimSuffix(strings.TrimSuffix(segment, ".xsd"), ".wsdl")
+		if strings.Contains(segment, ".") {
+			// Host names are named after their first label.
+			labels := strings.Split(segment, ".")
+			segment = labels[0]
+			if segment == "www" && len(labels) > 1 {
+				segment = labels[1]
+			}
+		}
+
+		var prefix string
+		for _, word := range nonIdentifier.Split(segment, -1) {
+			prefix += makePublic(word)
+		}
+		if prefix != "" && prefix != "EmptyString" {
+			return prefix
+		}
+	}
+
+	return "Ns"
+}
+
+// lookup returns the Go name of the symbol name refers to, along with the
+// QName it is declared with. Names that can't be resolved to a namespace are
+// looked up by their local name, provided it is declared once.
+func (st *symbolTable) lookup(kind symbolKind, name xml.Name) (xml.Name, string, bool) {
+	if goName, ok := st.names[symbolKey{kind: kind, name: name}]; ok {
+		return name, goName, true
+	}
+
+	if candidates := st.locals[kind][name.Local]; len(candidates) == 1 {
+		return candidates[0], st.names[symbolKey{kind: kind, name: candidates[0]}], true
+	}
+	return xml.Name{}, "", false
+}
+
+// resolveQName turns a prefixed name into a QName, resolving its prefix
+// through the given namespace declarations.
+func resolveQName(name string, xmlns map[string]string) xml.Name {
+	prefix, local := splitQName(name)
+	return xml.Name{Space: xmlns[prefix], Local: local}
+}
+
+// goType returns the Go type of a reference to the XSD type name, whose
+// prefix is declared by xmlns. Types generated in another package than the
+// one of imports are qualified by their package name. Unqualified names are
+// built-in types unless a schema without target namespace declares them,
+// since built-in names such as Name or ID are common type names.
+func (g *GoWSDL) goType(name string, xmlns map[string]string, nillable bool, imports *importSet) string {
+	qname := resolveQName(name, xmlns)
+	if qname.Space != xmlschema11 && g.symbols != nil {
+		if decl, goName, ok := g.symbols.lookup(typeSymbol, qname); ok {
+			return "*" + imports.qualify(g.packageOf(decl.Space), goName)
+		}
+	}
+	return toGoType(name, nillable)
+}
+
+// goElementType returns the Go type of a reference to the global element name.
+func (g *GoWSDL) goElementType(name string, xmlns map[string]string, nillable bool, imports *importSet) string {
+	if g.symbols != nil {
+		if decl, goName, ok := g.symbols.lookup(elementSymbol, resolveQName(name, xmlns)); ok {
+			return "*" + imports.qualify(g.packageOf(decl.Space), goName)
+		}
+	}
+	return toGoType(name, nillable)
+}
+
+// goTypeName returns the name of the Go type declared for a global type or
+// element of the schema being generated.
+func (g *GoWSDL) goTypeName(kind symbolKind, name string) string {
+	if g.symbols != nil {
+		key := symbolKey{kind: kind, name: xml.Name{Space: g.currentNamespace, Local: name}}
+		if goName, ok := g.symbols.names[key]; ok {
+			return goName
+		}
+	}
+	return g.makePublicFn(replaceReservedWords(name))
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,8 @@ package gowsdl
 
 import (
 	"encoding/xml"
+	"log"
+	"sort"
 	"strings"
 )
 
@@ -20,6 +22,19 @@ type traverser struct {
 	typeName             string
 	foundElmName         string
 	conflictingTypeUsage bool
+	// graph is the type graph traverseTypeGraph adds references to.
+	graph *typeGraph
+	// inlined records the groups inlined in the complex type whose references
+	// are being resolved, and base holds the ones of its base type, which
+	// aren't inlined again.
+	inlined, base *inlinedGroups
+}
+
+// inlinedGroups holds the names of the groups and of the attribute groups
+// inlined in a complex type.
+type inlinedGroups struct {
+	groups          map[xml.Name]bool
+	attributeGroups map[xml.Name]bool
 }
 
 func newTraverser(c *XSDSchema, all []*XSDSchema) *traverser {
@@ -127,16 +142,296 @@ func (t *traverser) traverseSimpleType(st *XSDSimpleType) {
 }
 
 func (t *traverser) traverseComplexType(ct *XSDComplexType) {
-	t.traverseElements(ct.Sequence)
-	t.traverseElements(ct.Choice)
-	t.traverseElements(ct.SequenceChoice)
-	t.traverseElements(ct.All)
+	if t.tm == refResolution {
+		if ct.inlined != nil {
+			return
+		}
+		inlined, base := t.inlined, t.base
+		defer func() { t.inlined, t.base = inlined, base }()
+		t.inheritGroups(ct)
+	}
+
+	t.traverseContent(&ct.XSDContent)
+	ct.Attributes = append(ct.Attributes, t.inlineAttributeGroups(&ct.AttributeGroups, &ct.AnyAttribute)...)
 	t.traverseAttributes(ct.Attributes)
-	t.traverseAttributes(ct.ComplexContent.Extension.Attributes)
-	t.traverseElements(ct.ComplexContent.Extension.Sequence)
-	t.traverseElements(ct.ComplexContent.Extension.Choice)
-	t.traverseElements(ct.ComplexContent.Extension.SequenceChoice)
-	t.traverseAttributes(ct.SimpleContent.Extension.Attributes)
+
+	ext := &ct.ComplexContent.Extension
+	t.traverseContent(&ext.XSDContent)
+	ext.Attributes = append(ext.Attributes, t.inlineAttributeGroups(&ext.AttributeGroups, &ext.AnyAttribute)...)
+	t.traverseAttributes(ext.Attributes)
+
+	res := &ct.ComplexContent.Restriction
+	t.traverseContent(&res.XSDContent)
+	res.Attributes = append(res.Attributes, t.inlineAttributeGroups(&res.AttributeGroups, &res.AnyAttribute)...)
+	t.traverseAttributes(res.Attributes)
+
+	ext = &ct.SimpleContent.Extension
+	ext.Attributes = append(ext.Attributes, t.inlineAttributeGroups(&ext.AttributeGroups, &ext.AnyAttribute)...)
+	t.traverseAttributes(ext.Attributes)
+
+	res = &ct.SimpleContent.Restriction
+	res.Attributes = append(res.Attributes, t.inlineAttributeGroups(&res.AttributeGroups, &res.AnyAttribute)...)
+	t.traverseAttributes(res.Attributes)
+}
+
+// inheritGroups starts recording the groups inlined in ct, along with the ones
+// of its base type, whose references are resolved first, so that the groups
+// an extension refers to again aren't inlined twice.
+func (t *traverser) inheritGroups(ct *XSDComplexType) {
+	ct.inlined = &inlinedGroups{groups: make(map[xml.Name]bool), attributeGroups: make(map[xml.Name]bool)}
+	t.inlined, t.base = ct.inlined, nil
+
+	if ct.ComplexContent.Extension.Base == "" {
+		return
+	}
+	schema, base := t.getGlobalComplexType(ct.ComplexContent.Extension.Base)
+	if base == nil {
+		return
+	}
+	newTraverser(schema, t.all).traverseComplexType(base)
+	t.base = base.inlined
+	for name := range base.inlined.groups {
+		ct.inlined.groups[name] = true
+	}
+	for name := range base.inlined.attributeGroups {
+		ct.inlined.attributeGroups[name] = true
+	}
+}
+
+// traverseContent traverses the particles of a content model, once the group
+// references it holds are inlined.
+func (t *traverser) traverseContent(c *XSDContent) {
+	t.inlineGroups(c)
+	t.traverseParticle(c.Particle())
+}
+
+func (t *traverser) traverseParticle(p *XSDParticle) {
+	switch {
+	case p == nil:
+	case p.Element != nil:
+		t.traverseElement(p.Element)
+	case p.ModelGroup != nil:
+		for _, child := range p.ModelGroup.Particles {
+			t.traverseParticle(child)
+		}
+	}
+}
+
+// inlineGroups replaces the group references of a content model by copies of
+// the model groups of the groups they refer to.
+func (t *traverser) inlineGroups(c *XSDContent) {
+	// Check if we are in ref resolution mode
+	if t.tm != refResolution {
+		return
+	}
+
+	if c.Group != nil {
+		mg := t.groupModelGroup(c.Group)
+		c.Group = nil
+		if mg != nil {
+			switch mg.XMLName.Local {
+			case "choice":
+				c.Choice = mg
+			case "all":
+				c.All = mg
+			default:
+				c.Sequence = mg
+			}
+		}
+	}
+
+	for _, mg := range []*XSDModelGroup{c.Sequence, c.Choice, c.All} {
+		if mg != nil {
+			t.inlineModelGroup(mg)
+		}
+	}
+}
+
+// inlineModelGroup replaces the group references among the particles of mg,
+// at any depth.
+func (t *traverser) inlineModelGroup(mg *XSDModelGroup) {
+	particles := mg.Particles[:0]
+	for _, p := range mg.Particles {
+		switch {
+		case p.Group != nil:
+			inlined := t.groupModelGroup(p.Group)
+			if inlined == nil {
+				continue
+			}
+			p = &XSDParticle{ModelGroup: inlined}
+		case p.ModelGroup != nil:
+			t.inlineModelGroup(p.ModelGroup)
+		}
+		particles = append(particles, p)
+	}
+	mg.Particles = particles
+}
+
+// groupModelGroup returns a copy of the model group of the group ref refers
+// to, with the group references nested in it inlined and the occurrences of
+// ref.
+func (t *traverser) groupModelGroup(ref *XSDGroup) *XSDModelGroup {
+	schema, group := t.getGlobalGroup(ref.Ref)
+	if group == nil {
+		log.Printf("[WARN] Group %s not found", ref.Ref)
+		return nil
+	}
+	if group.inlining {
+		log.Printf("[WARN] Group %s refers to itself", ref.Ref)
+		return nil
+	}
+	if !t.inlineGroup(xml.Name{Space: schema.TargetNamespace, Local: group.Name}) {
+		return nil
+	}
+	group.inlining = true
+	defer func() { group.inlining = false }()
+
+	content := group.XSDContent
+	content.Sequence = copyModelGroup(content.Sequence)
+	content.Choice = copyModelGroup(content.Choice)
+	content.All = copyModelGroup(content.All)
+	t.nested(schema).inlineGroups(&content)
+
+	p := content.Particle()
+	if p == nil || p.ModelGroup == nil {
+		return nil
+	}
+	mg := p.ModelGroup
+	t.requalifyModelGroup(mg, schema)
+	mg.MinOccurs = ref.MinOccurs
+	mg.MaxOccurs = ref.MaxOccurs
+	return mg
+}
+
+// inlineGroup tells whether the group name is inlined in the complex type
+// whose references are being resolved, which it isn't when its base type
+// holds it already, and records it.
+func (t *traverser) inlineGroup(name xml.Name) bool {
+	if t.base != nil && t.base.groups[name] {
+		return false
+	}
+	if t.inlined != nil {
+		t.inlined.groups[name] = true
+	}
+	return true
+}
+
+// inlineAttributeGroup is inlineGroup for the attribute group name.
+func (t *traverser) inlineAttributeGroup(name xml.Name) bool {
+	if t.base != nil && t.base.attributeGroups[name] {
+		return false
+	}
+	if t.inlined != nil {
+		t.inlined.attributeGroups[name] = true
+	}
+	return true
+}
+
+// nested returns the traverser inlining the groups nested in a group
+// declared in schema, into the complex type t inlines it in.
+func (t *traverser) nested(schema *XSDSchema) *traverser {
+	n := newTraverser(schema, t.all)
+	n.inlined, n.base = t.inlined, t.base
+	return n
+}
+
+// requalifyModelGroup rewrites the names the elements of mg, declared in
+// schema from, refer to so that they resolve in the current schema.
+func (t *traverser) requalifyModelGroup(mg *XSDModelGroup, from *XSDSchema) {
+	for _, p := range mg.Particles {
+		switch {
+		case p.Element != nil:
+			p.Element.Type = t.requalify(p.Element.Type, from)
+			p.Element.Ref = t.requalify(p.Element.Ref, from)
+		case p.ModelGroup != nil:
+			t.requalifyModelGroup(p.ModelGroup, from)
+		}
+	}
+}
+
+// copyModelGroup returns a deep copy of the particles of mg, which can be
+// modified without affecting mg.
+func copyModelGroup(mg *XSDModelGroup) *XSDModelGroup {
+	if mg == nil {
+		return nil
+	}
+
+	c := *mg
+	c.Particles = make([]*XSDParticle, len(mg.Particles))
+	for i, p := range mg.Particles {
+		cp := *p
+		switch {
+		case p.Element != nil:
+			elm := *p.Element
+			cp.Element = &elm
+		case p.ModelGroup != nil:
+			cp.ModelGroup = copyModelGroup(p.ModelGroup)
+		}
+		c.Particles[i] = &cp
+	}
+	return &c
+}
+
+// inlineAttributeGroups returns the attributes of the attribute groups refs
+// refers to, which are cleared so that they are only inlined once. The
+// attribute wildcard of the groups is set as anyAttribute, unless there is
+// one already.
+func (t *traverser) inlineAttributeGroups(refs *[]*XSDAttributeGroup, anyAttribute **XSDAnyAttribute) []*XSDAttribute {
+	// Check if we are in ref resolution mode
+	if t.tm != refResolution {
+		return nil
+	}
+
+	attrs, wildcard := t.attributeGroupsAttributes(*refs)
+	if *anyAttribute == nil {
+		*anyAttribute = wildcard
+	}
+	*refs = nil
+	return attrs
+}
+
+// attributeGroupsAttributes returns copies of the attributes of the attribute
+// groups refs refer to, along with their attribute wildcard, if any.
+func (t *traverser) attributeGroupsAttributes(refs []*XSDAttributeGroup) ([]*XSDAttribute, *XSDAnyAttribute) {
+	var attrs []*XSDAttribute
+	var wildcard *XSDAnyAttribute
+	for _, ref := range refs {
+		schema, group := t.getGlobalAttributeGroup(ref.Ref)
+		if group == nil {
+			log.Printf("[WARN] Attribute group %s not found", ref.Ref)
+			continue
+		}
+		if group.inlining {
+			log.Printf("[WARN] Attribute group %s refers to itself", ref.Ref)
+			continue
+		}
+		if !t.inlineAttributeGroup(xml.Name{Space: schema.TargetNamespace, Local: group.Name}) {
+			continue
+		}
+		group.inlining = true
+
+		for _, attr := range group.Attributes {
+			a := *attr
+			a.Type = t.requalify(a.Type, schema)
+			a.Ref = t.requalify(a.Ref, schema)
+			attrs = append(attrs, &a)
+		}
+		nested, nestedWildcard := t.nested(schema).attributeGroupsAttributes(group.AttributeGroups)
+		for _, attr := range nested {
+			attr.Type = t.requalify(attr.Type, schema)
+			attr.Ref = t.requalify(attr.Ref, schema)
+			attrs = append(attrs, attr)
+		}
+		if wildcard == nil {
+			wildcard = group.AnyAttribute
+		}
+		if wildcard == nil {
+			wildcard = nestedWildcard
+		}
+
+		group.inlining = false
+	}
+	return attrs, wildcard
 }
 
 func (t *traverser) traverseAttributes(attrs []*XSDAttribute) {
@@ -185,6 +480,83 @@ func (t *traverser) getGlobalAttribute(name string) *XSDAttribute {
 	return nil
 }
 
+func (t *traverser) getGlobalGroup(name string) (*XSDSchema, *XSDGroup) {
+	ref := resolveQName(name, t.c.Xmlns)
+
+	for _, schema := range t.all {
+		if schema.TargetNamespace == ref.Space {
+			for _, group := range schema.Groups {
+				if group.Name == ref.Local {
+					return schema, group
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (t *traverser) getGlobalComplexType(name string) (*XSDSchema, *XSDComplexType) {
+	ref := resolveQName(name, t.c.Xmlns)
+
+	for _, schema := range t.all {
+		if schema.TargetNamespace == ref.Space {
+			for _, ct := range schema.ComplexTypes {
+				if ct.Name == ref.Local {
+					return schema, ct
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+func (t *traverser) getGlobalAttributeGroup(name string) (*XSDSchema, *XSDAttributeGroup) {
+	ref := resolveQName(name, t.c.Xmlns)
+
+	for _, schema := range t.all {
+		if schema.TargetNamespace == ref.Space {
+			for _, group := range schema.AttributeGroups {
+				if group.Name == ref.Local {
+					return schema, group
+				}
+			}
+		}
+	}
+
+	return nil, nil
+}
+
+// requalify rewrites a prefixed name declared in schema from, so that it
+// resolves to the same QName in the current schema.
+func (t *traverser) requalify(name string, from *XSDSchema) string {
+	if name == "" || from == t.c {
+		return name
+	}
+
+	prefix, local := splitQName(name)
+	ns, ok := from.Xmlns[prefix]
+	if !ok || t.c.Xmlns[prefix] == ns {
+		return name
+	}
+
+	prefixes := make([]string, 0, len(t.c.Xmlns))
+	for p := range t.c.Xmlns {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+	for _, p := range prefixes {
+		if t.c.Xmlns[p] == ns {
+			if p == "" {
+				return local
+			}
+			return p + ":" + local
+		}
+	}
+	return name
+}
+
 // qname resolves QName into xml.Name.
 func (t *traverser) qname(name string) (qname xml.Name) {
 	x := strings.SplitN(name, ":", 2)
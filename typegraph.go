@@ -0,0 +1,384 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// indirection tells how the Go type of a type or element holds a type or
+// element it refers to.
+type indirection int
+
+const (
+	// byValue is the reference of a type to its base, or of an element to
+	// its type, which the Go type is declared as.
+	byValue indirection = iota
+	// byEmbedding is the reference of a complex type to the type it extends,
+	// embedded as a pointer whose fields encoding/xml flattens.
+	byEmbedding
+	byPointer
+	bySlice
+)
+
+func (i indirection) String() string {
+	switch i {
+	case byValue:
+		return "value"
+	case byEmbedding:
+		return "embedding"
+	case byPointer:
+		return "pointer"
+	}
+	return "slice"
+}
+
+// derivation tells whether a reference is part of the Go type itself, so that
+// a cycle of such references can't be declared or encoded.
+func (i indirection) derivation() bool {
+	return i == byValue || i == byEmbedding
+}
+
+// typeGraph is the graph of the references between the global types and
+// elements of the schemas, as held by their Go types.
+type typeGraph struct {
+	nodes []*typeNode
+	byKey map[symbolKey]*typeNode
+}
+
+type typeNode struct {
+	key   symbolKey
+	edges []*typeEdge
+	// Fields used to find the strongly connected components.
+	index, lowLink int
+	onStack        bool
+}
+
+// typeEdge is a reference from a type or element to another one.
+type typeEdge struct {
+	to *typeNode
+	// path is the path of the field or the name of the declaration holding
+	// the reference, such as Category.children.category.
+	path        string
+	indirection indirection
+	// cut breaks the derivation the reference stands for, when it can be.
+	cut func()
+}
+
+// newTypeGraph builds the type graph of schemas, once their references are
+// resolved.
+func newTypeGraph(schemas []*XSDSchema) *typeGraph {
+	graph := &typeGraph{byKey: make(map[symbolKey]*typeNode)}
+	for _, schema := range schemas {
+		for _, ct := range schema.ComplexTypes {
+			graph.add(typeSymbol, schema.TargetNamespace, ct.Name)
+		}
+		for _, st := range schema.SimpleType {
+			graph.add(typeSymbol, schema.TargetNamespace, st.Name)
+		}
+		for _, elm := range schema.Elements {
+			graph.add(elementSymbol, schema.TargetNamespace, elm.Name)
+		}
+	}
+	for _, schema := range schemas {
+		t := newTraverser(schema, schemas)
+		t.graph = graph
+		t.traverseTypeGraph()
+	}
+	return graph
+}
+
+func (g *typeGraph) add(kind symbolKind, ns, name string) {
+	key := symbolKey{kind: kind, name: xml.Name{Space: ns, Local: name}}
+	if g.byKey[key] == nil {
+		node := &typeNode{key: key}
+		g.nodes = append(g.nodes, node)
+		g.byKey[key] = node
+	}
+}
+
+// components returns the strongly connected components of the graph made of
+// the edges follow accepts, which refer to themselves, in declaration order.
+func (g *typeGraph) components(follow func(*typeEdge) bool) [][]*typeNode {
+	for _, node := range g.nodes {
+		node.index, node.lowLink, node.onStack = 0, 0, false
+	}
+
+	var components [][]*typeNode
+	var stack []*typeNode
+	index := 0
+	var visit func(node *typeNode)
+	visit = func(node *typeNode) {
+		index++
+		node.index, node.lowLink = index, index
+		stack = append(stack, node)
+		node.onStack = true
+
+		cyclic := false
+		for _, edge := range node.edges {
+			if !follow(edge) {
+				continue
+			}
+			cyclic = cyclic || edge.to == node
+			switch {
+			case edge.to.index == 0:
+				visit(edge.to)
+				if edge.to.lowLink < node.lowLink {
+					node.lowLink = edge.to.lowLink
+				}
+			case edge.to.onStack && edge.to.index < node.lowLink:
+				node.lowLink = edge.to.index
+			}
+		}
+		if node.lowLink != node.index {
+			return
+		}
+
+		var component []*typeNode
+		for {
+			top := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			top.onStack = false
+			component = append(component, top)
+			if top == node {
+				break
+			}
+		}
+		if cyclic || len(component) > 1 {
+			components = append(components, component)
+		}
+	}
+	for _, node := range g.nodes {
+		if node.index == 0 {
+			visit(node)
+		}
+	}
+
+	order := make(map[*typeNode]int, len(g.nodes))
+	for i, node := range g.nodes {
+		order[node] = i
+	}
+	for _, component := range components {
+		sort.Slice(component, func(i, j int) bool { return order[component[i]] < order[component[j]] })
+	}
+	sort.Slice(components, func(i, j int) bool { return order[components[i][0]] < order[components[j][0]] })
+	return components
+}
+
+// breakDerivationCycles ignores the base of the first declared type of each
+// cycle of types derived from each other, whose Go types would be declared as
+// each other or embed each other.
+func (g *typeGraph) breakDerivationCycles() {
+	for _, component := range g.components(func(edge *typeEdge) bool { return edge.indirection.derivation() }) {
+		// Types derive from a single base, so components are simple cycles.
+		node := component[0]
+		cycle := []string{node.key.name.Local}
+		var first *typeEdge
+		for n := node; first == nil || n != node; {
+			for _, edge := range n.edges {
+				if edge.indirection.derivation() {
+					if first == nil {
+						first = edge
+					}
+					n = edge.to
+					break
+				}
+			}
+			cycle = append(cycle, n.key.name.Local)
+		}
+
+		log.Printf("[WARN] Type %s derives from itself (%s), ignoring its base %s", node.key.name.Local, strings.Join(cycle, " -> "), first.to.key.name.Local)
+		if first.cut != nil {
+			first.cut()
+		}
+		for i, edge := range node.edges {
+			if edge == first {
+				node.edges = append(node.edges[:i], node.edges[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// reportRecursiveTypes logs the types and elements referring to themselves,
+// along with the references they do it through.
+func (g *typeGraph) reportRecursiveTypes() {
+	for _, component := range g.components(func(*typeEdge) bool { return true }) {
+		in := make(map[*typeNode]bool, len(component))
+		var names []string
+		for _, node := range component {
+			in[node] = true
+			names = append(names, node.key.name.Local)
+		}
+
+		var through []string
+		for _, node := range component {
+			for _, edge := range node.edges {
+				if in[edge.to] {
+					through = append(through, fmt.Sprintf("%s (%s)", edge.path, edge.indirection))
+				}
+			}
+		}
+
+		kind := "type"
+		if len(names) > 1 {
+			kind = "types"
+		}
+		log.Printf("Recursive %s %s, through %s", kind, strings.Join(names, ", "), strings.Join(through, ", "))
+	}
+}
+
+// traverseTypeGraph adds the references of the types and elements of the
+// schema being traversed to the type graph.
+func (t *traverser) traverseTypeGraph() {
+	ns := t.c.TargetNamespace
+	for _, ct := range t.c.ComplexTypes {
+		t.complexTypeEdges(t.graph.byKey[symbolKey{kind: typeSymbol, name: xml.Name{Space: ns, Local: ct.Name}}], ct.Name, ct, 0)
+	}
+	for _, st := range t.c.SimpleType {
+		t.simpleTypeEdges(t.graph.byKey[symbolKey{kind: typeSymbol, name: xml.Name{Space: ns, Local: st.Name}}], st.Name, st, byValue)
+	}
+	for _, elm := range t.c.Elements {
+		from := t.graph.byKey[symbolKey{kind: elementSymbol, name: xml.Name{Space: ns, Local: elm.Name}}]
+		switch {
+		case elm.Type != "":
+			t.refer(from, typeSymbol, elm.Type, elm.Name, byValue)
+		case elm.ComplexType != nil:
+			t.complexTypeEdges(from, elm.Name, elm.ComplexType, 0)
+		case elm.SimpleType != nil:
+			t.simpleTypeEdges(from, elm.Name, elm.SimpleType, byValue)
+		}
+	}
+}
+
+// complexTypeEdges adds the references of the complex type ct, declared by
+// the type or element from at path, to the type graph. The fields of
+// anonymous complex types are fields of the type declaring them.
+func (t *traverser) complexTypeEdges(from *typeNode, path string, ct *XSDComplexType, depth uint8) {
+	if depth >= maxRecursion {
+		return
+	}
+
+	for _, attrs := range [][]*XSDAttribute{
+		ct.Attributes,
+		ct.ComplexContent.Extension.Attributes,
+		ct.ComplexContent.Restriction.Attributes,
+		ct.SimpleContent.Extension.Attributes,
+		ct.SimpleContent.Restriction.Attributes,
+	} {
+		for _, attr := range attrs {
+			switch {
+			case attr.SimpleType != nil:
+				t.simpleTypeEdges(from, path+"."+attr.Name, attr.SimpleType, byPointer)
+			case attr.Type != "":
+				t.refer(from, typeSymbol, attr.Type, path+"."+attr.Name, byPointer)
+			}
+		}
+	}
+
+	if ext := &ct.ComplexContent.Extension; ext.Base != "" {
+		if edge := t.refer(from, typeSymbol, ext.Base, path, byEmbedding); edge != nil {
+			// The type keeps the particles and attributes it adds.
+			edge.cut = func() {
+				ct.XSDContent = ext.XSDContent
+				ct.Attributes = append(ct.Attributes, ext.Attributes...)
+				if ct.AnyAttribute == nil {
+					ct.AnyAttribute = ext.AnyAttribute
+				}
+				*ext = XSDExtension{}
+			}
+		}
+	}
+	for _, base := range []*string{&ct.SimpleContent.Extension.Base, &ct.SimpleContent.Restriction.Base} {
+		if *base != "" {
+			if edge := t.refer(from, typeSymbol, *base, path, byValue); edge != nil {
+				base, anySimpleType := base, t.xsdName("anySimpleType")
+				edge.cut = func() { *base = anySimpleType }
+			}
+		}
+	}
+
+	for _, content := range []XSDContent{ct.XSDContent, ct.ComplexContent.Extension.XSDContent, ct.ComplexContent.Restriction.XSDContent} {
+		for _, elm := range particleElements(content.Particle()) {
+			indirection := byPointer
+			if repeated(elm.MaxOccurs) {
+				indirection = bySlice
+			}
+
+			switch {
+			case elm.Ref != "":
+				_, local := splitQName(elm.Ref)
+				t.refer(from, elementSymbol, elm.Ref, path+"."+local, indirection)
+			case elm.Type != "":
+				t.refer(from, typeSymbol, elm.Type, path+"."+elm.Name, indirection)
+			case elm.ComplexType != nil:
+				t.complexTypeEdges(from, path+"."+elm.Name, elm.ComplexType, depth+1)
+			case elm.SimpleType != nil:
+				t.simpleTypeEdges(from, path+"."+elm.Name, elm.SimpleType, indirection)
+			}
+		}
+	}
+}
+
+// simpleTypeEdges adds the references of the simple type st, declared by the
+// type or element from at path, to the type graph. The value of st refers to
+// its base by indirection.
+func (t *traverser) simpleTypeEdges(from *typeNode, path string, st *XSDSimpleType, indirection indirection) {
+	switch {
+	case isList(st):
+		if st.List.SimpleType != nil {
+			t.simpleTypeEdges(from, path, st.List.SimpleType, bySlice)
+		} else {
+			t.refer(from, typeSymbol, st.List.ItemType, path, bySlice)
+		}
+	case isUnion(st):
+		for _, member := range strings.Fields(st.Union.MemberTypes) {
+			t.refer(from, typeSymbol, member, path, byPointer)
+		}
+		for _, member := range st.Union.SimpleType {
+			t.simpleTypeEdges(from, path, member, byPointer)
+		}
+	case st.Restriction.Base != "":
+		edge := t.refer(from, typeSymbol, st.Restriction.Base, path, indirection)
+		if edge != nil && indirection == byValue {
+			anySimpleType := t.xsdName("anySimpleType")
+			edge.cut = func() { st.Restriction.Base = anySimpleType }
+		}
+	}
+}
+
+// refer adds the reference of from to the type or element name, at path, to
+// the type graph, and returns it. References to types and elements which
+// aren't declared by the schemas are left out.
+func (t *traverser) refer(from *typeNode, kind symbolKind, name, path string, indirection indirection) *typeEdge {
+	to := t.graph.byKey[symbolKey{kind: kind, name: resolveQName(name, t.c.Xmlns)}]
+	if from == nil || to == nil {
+		return nil
+	}
+
+	edge := &typeEdge{to: to, path: path, indirection: indirection}
+	from.edges = append(from.edges, edge)
+	return edge
+}
+
+// xsdName returns the name the schema being traversed refers to the built-in
+// type local of XML Schema with.
+func (t *traverser) xsdName(local string) string {
+	prefixes := make([]string, 0, len(t.c.Xmlns))
+	for p, ns := range t.c.Xmlns {
+		if ns == xmlschema11 {
+			prefixes = append(prefixes, p)
+		}
+	}
+	sort.Strings(prefixes)
+	if len(prefixes) == 0 || prefixes[0] == "" {
+		return local
+	}
+	return prefixes[0] + ":" + local
+}
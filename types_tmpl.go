@@ -6,28 +6,161 @@ package gowsdl
 
 var typesTmpl = `
 {{define "SimpleType"}}
-	{{$typeName := replaceReservedWords .Name | makePublic}}
+	{{template "SimpleTypeDecl" (simpleTypeDecl (typeName .Name) .)}}
+{{end}}
+
+{{define "SimpleTypeDecl"}}
+	{{$typeName := .GoName}}
+	{{with .XSDSimpleType}}
 	{{if .Doc}} {{.Doc | comment}} {{end}}
-	{{if ne .List.ItemType ""}}
-		type {{$typeName}} []{{toGoType .List.ItemType false | removePointerFromType}}
-	{{else if ne .Union.MemberTypes ""}}
-		type {{$typeName}} string
-	{{else if .Union.SimpleType}}
-		type {{$typeName}} string
+	{{if isList .}}
+		type {{$typeName}} []{{listItemType .}}
+	{{else if isUnion .}}
+		{{template "Union" (unionOf .)}}
 	{{else if .Restriction.Base}}
 		type {{$typeName}} {{toGoType .Restriction.Base false | removePointerFromType}}
     {{else}}
 		type {{$typeName}} interface{}
 	{{end}}
 
-	{{if .Restriction.Enumeration}}
-	const (
-		{{with .Restriction}}
-			{{range .Enumeration}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{$typeName}}{{$value := replaceReservedWords .Value}}{{$value | makePublic}} {{$typeName}} = "{{goString .Value}}" {{end}}
+	{{with validateSimpleType $typeName .}}
+		{{template "Validate" .}}
+	{{end}}
+
+	{{if and soapEncoded .Name (or (isList .) (isUnion .) .Restriction.Base)}}
+		// XSDType returns the XML Schema type {{$typeName}} was generated from.
+		func ({{$typeName}}) XSDType() xml.Name {
+			return xml.Name{Space: "{{getNS}}", Local: "{{.Name}}"}
+		}
+	{{end}}
+
+	{{with enumOf $typeName .}}
+		{{template "Enumeration" .}}
+	{{end}}
+	{{if .Restriction.Base}}
+		{{with simpleTypeEncodingMethods $typeName .}}
+			{{template "EncodingMethods" .}}
 		{{end}}
+	{{end}}
+	{{with listOf $typeName .}}
+		{{template "List" .}}
+	{{end}}
+	{{end}}
+{{end}}
+
+{{define "Enumeration"}}
+	{{template "EnumValues" .}}
+
+	// Values returns the values of {{.GoName}} enumerated by its schema.
+	func ({{.GoName}}) Values() []{{.GoName}} {
+		return []{{.GoName}}{ {{range .Values}}
+			{{.Name}},{{end}}
+		}
+	}
+
+	// IsValid tells whether v is one of the values of {{.GoName}}.
+	func (v {{.GoName}}) IsValid() bool {
+		for _, value := range v.Values() {
+			if {{if .Constant}}v == value{{else}}{{.BaseType}}(v).Cmp({{.BaseType}}(value)) == 0{{end}} {
+				return true
+			}
+		}
+		return false
+	}
+	{{if .Constant}}
+
+		// String returns the lexical value of v.
+		func (v {{.GoName}}) String() string {
+			return {{if eq .BaseType "string"}}string(v){{else}}soap.FormatEnum(v){{end}}
+		}
+	{{end}}
+	{{if .Strict}}
+
+		// UnmarshalXML decodes v, failing when its value is not one of the values
+		// of {{.GoName}}.
+		func (v *{{.GoName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			var text string
+			if err := d.DecodeElement(&text, &start); err != nil {
+				return err
+			}
+			return soap.UnmarshalEnum(text, v)
+		}
+
+		// UnmarshalXMLAttr decodes v from attr, failing when its value is not one
+		// of the values of {{.GoName}}.
+		func (v *{{.GoName}}) UnmarshalXMLAttr(attr xml.Attr) error {
+			return soap.UnmarshalEnum(attr.Value, v)
+		}
+
+		// UnmarshalText decodes v from text, failing when its value is not one
+		// of the values of {{.GoName}}.
+		func (v *{{.GoName}}) UnmarshalText(text []byte) error {
+			return soap.UnmarshalEnum(string(text), v)
+		}
+
+		// UnmarshalJSON decodes v from data, failing when its value is not one
+		// of the values of {{.GoName}}.
+		func (v *{{.GoName}}) UnmarshalJSON(data []byte) error {
+			return soap.UnmarshalJSONEnum(data, v)
+		}
+	{{end}}
+{{end}}
+
+{{define "EnumValues"}}
+	{{$enum := .}}
+	{{if .Constant}}const{{else}}var{{end}} (
+		{{range .Values}}
+			{{if .Doc}} {{.Doc | comment}} {{end}}
+			{{.Name}} {{$enum.GoName}} = {{.Literal}} {{end}}
 	)
+{{end}}
+
+{{define "EncodingMethods"}}
+	{{if .Text}}
+		func (v {{.GoName}}) MarshalText() ([]byte, error) {
+			return {{.Type}}(v).MarshalText()
+		}
+
+		{{if not .Strict}}
+
+			func (v *{{.GoName}}) UnmarshalText(text []byte) error {
+				return (*{{.Type}})(v).UnmarshalText(text)
+			}
+		{{end}}
+	{{end}}
+	{{if .JSON}}
+		func (v {{.GoName}}) MarshalJSON() ([]byte, error) {
+			return {{.Type}}(v).MarshalJSON()
+		}
+
+		{{if not .Strict}}
+
+			func (v *{{.GoName}}) UnmarshalJSON(data []byte) error {
+				return (*{{.Type}})(v).UnmarshalJSON(data)
+			}
+		{{end}}
+	{{end}}
+	{{if .XML}}
+		func (v {{.GoName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			return {{.Type}}(v).MarshalXML(e, start)
+		}
+
+		func (v *{{.GoName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return (*{{.Type}})(v).UnmarshalXML(d, start)
+		}
+
+		func (v {{.GoName}}) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+			return {{.Type}}(v).MarshalXMLAttr(name)
+		}
+
+		func (v *{{.GoName}}) UnmarshalXMLAttr(attr xml.Attr) error {
+			return (*{{.Type}})(v).UnmarshalXMLAttr(attr)
+		}
+	{{end}}
+	{{if .String}}
+		func (v {{.GoName}}) String() string {
+			return {{.Type}}(v).String()
+		}
 	{{end}}
 {{end}}
 
@@ -37,78 +170,440 @@ var typesTmpl = `
 		{{$baseType}}
 	{{end}}
 
-	{{template "Elements" .Extension.Sequence}}
-	{{template "Elements" .Extension.Choice}}
-	{{template "Elements" .Extension.SequenceChoice}}
+	{{template "Elements" (particles .Extension.Particle)}}
 	{{template "Attributes" .Extension.Attributes}}
+	{{template "AnyAttribute" .Extension.AnyAttribute}}
+{{end}}
+
+{{define "ComplexContentRestriction"}}
+	{{template "Elements" (particles .Particle)}}
+	{{template "Attributes" (restrictionAttributes .)}}
+	{{template "AnyAttribute" .AnyAttribute}}
+{{end}}
+
+{{define "SimpleContentRestriction"}}
+	{{with facetsDoc .}}// Value is restricted by {{.}}.{{end}}
+	Value {{simpleContentType .Base}} ` + "`xml:\",chardata\" json:\"-,\"`" + `
+	{{template "Attributes" (restrictionAttributes .)}}
+	{{template "AnyAttribute" .AnyAttribute}}
 {{end}}
 
 {{define "Attributes"}}
     {{ $targetNamespace := getNS }}
 	{{range .}}
 		{{if .Doc}} {{.Doc | comment}} {{end}}
-		{{ if ne .Type "" }}
-			{{ normalize .Name | makeFieldPublic}} {{toGoType .Type false}} ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr,omitempty" json:"{{.Name}},omitempty"` + "`" + `
+		{{ if hoistedType . }}
+			{{fieldName .}} {{hoistedType .}} ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr{{attributeOmitEmpty .}}" json:"{{jsonName .}}{{attributeOmitEmpty .}}"` + "`" + `
+		{{ else if ne .Type "" }}
+			{{fieldName .}} {{attributeType .}} ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr{{attributeOmitEmpty .}}" json:"{{jsonName .}}{{attributeOmitEmpty .}}"` + "`" + `
+		{{ else if inlineType .SimpleType }}
+			{{fieldName .}} {{if isUnion .SimpleType}}*{{end}}{{inlineType .SimpleType}} ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr{{attributeOmitEmpty .}}" json:"{{jsonName .}}{{attributeOmitEmpty .}}"` + "`" + `
 		{{ else }}
-			{{ normalize .Name | makeFieldPublic}} string ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr,omitempty" json:"{{.Name}},omitempty"` + "`" + `
+			{{fieldName .}} string ` + "`" + `xml:"{{with $targetNamespace}}{{.}} {{end}}{{.Name}},attr{{attributeOmitEmpty .}}" json:"{{jsonName .}}{{attributeOmitEmpty .}}"` + "`" + `
 		{{ end }}
 	{{end}}
 {{end}}
 
 {{define "SimpleContent"}}
-	Value {{toGoType .Extension.Base false}} ` + "`xml:\",chardata\" json:\"-,\"`" + `
+	Value {{simpleContentType .Extension.Base}} ` + "`xml:\",chardata\" json:\"-,\"`" + `
 	{{template "Attributes" .Extension.Attributes}}
+	{{template "AnyAttribute" .Extension.AnyAttribute}}
+{{end}}
+
+{{define "AnyAttribute"}}
+	{{if .}}
+		AnyAttrs soap.AnyAttrs ` + "`" + `xml:",any,attr" json:"anyAttrs,omitempty"` + "`" + `
+	{{end}}
+{{end}}
+
+{{define "MixedField"}}
+	// Mixed holds the text of t, in document order with its elements.
+	Mixed soap.Mixed ` + "`" + `xml:"-" json:"mixed,omitempty"` + "`" + `
+{{end}}
+
+{{define "MixedMethods"}}
+	func (t *{{.}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return soap.UnmarshalMixed(d, start, t, &t.Mixed)
+	}
+
+	func (t {{.}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return soap.MarshalMixed(e, start, &t, t.Mixed)
+	}
+
+	func (t {{.}}) MarshalEncoded(enc *soap.Encoder, start xml.StartElement) error {
+		return enc.MarshalMixed(start, &t, t.Mixed)
+	}
 {{end}}
 
 {{define "ComplexTypeInline"}}
-	{{replaceReservedWords .Name | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}struct {
+	{{$fieldName := fieldName .}}
+	{{$fieldName}} {{if repeated .MaxOccurs}}[]{{end}}struct {
 	{{with .ComplexType}}
+		{{beginStruct (fieldPath $fieldName) .}}
 		{{if ne .ComplexContent.Extension.Base ""}}
 			{{template "ComplexContent" .ComplexContent}}
 		{{else if ne .SimpleContent.Extension.Base ""}}
 			{{template "SimpleContent" .SimpleContent}}
+		{{else if ne .ComplexContent.Restriction.Base ""}}
+			{{template "ComplexContentRestriction" .ComplexContent.Restriction}}
+		{{else if ne .SimpleContent.Restriction.Base ""}}
+			{{template "SimpleContentRestriction" .SimpleContent.Restriction}}
 		{{else}}
-			{{template "Elements" .Sequence}}
-			{{template "Elements" .Choice}}
-			{{template "Elements" .SequenceChoice}}
-			{{template "Elements" .All}}
+			{{template "Elements" (particles .Particle)}}
 			{{template "Attributes" .Attributes}}
+			{{template "AnyAttribute" .AnyAttribute}}
 		{{end}}
+		{{endStruct}}
+	{{end}}
+	} ` + "`" + `xml:"{{.Name}}{{omitEmpty .}}" json:"{{jsonName .}}{{omitEmpty .}}"` + "`" + `
+{{end}}
+
+{{define "HoistedType"}}
+	{{$typeName := .GoName}}
+	{{with .ComplexType}}
+		type {{$typeName}} struct { {{beginStruct $typeName .}}
+			{{if ne .ComplexContent.Extension.Base ""}}
+				{{template "ComplexContent" .ComplexContent}}
+			{{else if ne .SimpleContent.Extension.Base ""}}
+				{{template "SimpleContent" .SimpleContent}}
+			{{else if ne .ComplexContent.Restriction.Base ""}}
+				{{template "ComplexContentRestriction" .ComplexContent.Restriction}}
+			{{else if ne .SimpleContent.Restriction.Base ""}}
+				{{template "SimpleContentRestriction" .SimpleContent.Restriction}}
+			{{else}}
+				{{template "Elements" (particles .Particle)}}
+				{{template "Attributes" .Attributes}}
+				{{template "AnyAttribute" .AnyAttribute}}
+			{{end}}
+			{{if isMixed .}}
+				{{template "MixedField"}}
+			{{end}}
+		{{endStruct}}}
+		{{template "Validate" (validateType $typeName .)}}
+		{{if isMixed .}}
+			{{template "MixedMethods" $typeName}}
+		{{end}}
+		{{with choiceOf .}}
+			{{template "Choice" .}}
+		{{end}}
+		{{with contentEnumOf $typeName .SimpleContent.Restriction}}
+			{{template "EnumValues" .}}
+		{{end}}
+	{{end}}
+	{{with .SimpleType}}
+		{{template "SimpleTypeDecl" (simpleTypeDecl $typeName .)}}
 	{{end}}
-	} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
 {{end}}
 
 {{define "Elements"}}
 	{{range .}}
+		{{with .Any}}
+			{{template "Any" .}}
+		{{end}}
+		{{with .ModelGroup}}
+			{{with findChoiceType .}}
+				Choice {{if .Repeated}}[]{{end}}*{{.GoName}} ` + "`" + `xml:",any,omitempty" json:"choice,omitempty"` + "`" + `
+			{{end}}
+		{{end}}
+		{{with .Element}}
 		{{if ne .Ref ""}}
-			{{removeNS .Ref | replaceReservedWords  | makePublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{toGoType .Ref .Nillable }} ` + "`" + `xml:"{{.Ref | removeNS}},omitempty" json:"{{.Ref | removeNS}},omitempty"` + "`" + `
+			{{$groupType := toGoGroupType .Ref}}
+			{{if $groupType}}
+				{{fieldName .}} {{if repeated .MaxOccurs}}[]{{end}}{{$groupType}} ` + "`" + `xml:",any,omitempty" json:"{{jsonName .}},omitempty"` + "`" + `
+			{{else}}
+				{{fieldName .}} {{occursType (toGoElementType .Ref .Nillable) .}} ` + "`" + `xml:"{{.Ref | removeNS}}{{omitEmpty .}}" json:"{{jsonName .}}{{omitEmpty .}}"` + "`" + `
+			{{end}}
 		{{else}}
 		{{if not .Type}}
 			{{if .SimpleType}}
 				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{if ne .SimpleType.List.ItemType ""}}
-					{{ normalize .Name | makeFieldPublic}} []{{toGoType .SimpleType.List.ItemType false}} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
+				{{if inlineType .SimpleType}}
+					{{fieldName .}} {{occursType (inlineType .SimpleType) .}} ` + "`" + `xml:"{{.Name}}{{omitEmpty .}}" json:"{{jsonName .}}{{omitEmpty .}}"` + "`" + `
+				{{else if hoistedType .}}
+					{{fieldName .}} {{occursType (hoistedType .) .}} ` + "`" + `xml:"{{.Name}}{{omitEmpty .}}" json:"{{jsonName .}}{{omitEmpty .}}"` + "`" + `
 				{{else}}
-					{{ normalize .Name | makeFieldPublic}} {{toGoType .SimpleType.Restriction.Base false}} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + `
+					{{fieldName .}} {{occursType (toGoType .SimpleType.Restriction.Base false) .}} ` + "`" + `xml:"{{.Name}}{{omitEmpty .}}" json:"{{jsonName .}}{{omitEmpty .}}"` + "`" + `
 				{{end}}
+			{{else if hoistedType .}}
+				{{if .Doc}} {{.Doc | comment}} {{end}}
+				{{fieldName .}} {{occursType (hoistedType .) .}} ` + "`" + `xml:"{{.Name}}{{omitEmpty .}}" json:"{{jsonName .}}{{omitEmpty .}}"` + "`" + `
 			{{else}}
 				{{template "ComplexTypeInline" .}}
 			{{end}}
 		{{else}}
 			{{if .Doc}}{{.Doc | comment}} {{end}}
-			{{replaceAttrReservedWords .Name | makeFieldPublic}} {{if eq .MaxOccurs "unbounded"}}[]{{end}}{{toGoType .Type .Nillable }} ` + "`" + `xml:"{{.Name}},omitempty" json:"{{.Name}},omitempty"` + "`" + ` {{end}}
+			{{fieldName .}} {{elementFieldType .}} ` + "`" + `xml:"{{.Name}}{{omitEmpty .}}" json:"{{jsonName .}}{{omitEmpty .}}"` + "`" + ` {{end}}
+		{{end}}
 		{{end}}
 	{{end}}
 {{end}}
 
-{{define "Any"}}
-	{{range .}}
-		Items     []string ` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
+{{define "Polymorphic"}}
+	{{if .Interface}}
+		// {{.Interface}} is implemented by {{.GoName}} and the types derived from it.
+		type {{.Interface}} interface {
+			Get{{.GoName}}() *{{.GoName}}
+		}
+
+		// Get{{.GoName}} returns t itself.
+		func (t *{{.GoName}}) Get{{.GoName}}() *{{.GoName}} {
+			return t
+		}
+
+		// {{.Holder}} holds a {{.GoName}} or a type derived from it, chosen by the
+		// xsi:type attribute of the element it is decoded from.
+		type {{.Holder}} struct {
+			{{.Interface}}
+		}
+
+		func (a *{{.Holder}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+			return soap.UnmarshalXSIType(d, start, &a.{{.Interface}}, xml.Name{Space: "{{.Name.Space}}", Local: "{{.Name.Local}}"})
+		}
+
+		func (a {{.Holder}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+			return soap.MarshalXSIType(e, start, a.{{.Interface}}, xml.Name{Space: "{{.Name.Space}}", Local: "{{.Name.Local}}"})
+		}
+
+		func (a {{.Holder}}) MarshalEncoded(enc *soap.Encoder, start xml.StartElement) error {
+			return enc.MarshalXSIType(start, a.{{.Interface}}, xml.Name{Space: "{{.Name.Space}}", Local: "{{.Name.Local}}"})
+		}
+
+		// Validate validates the value a holds.
+		func (a *{{.Holder}}) Validate() error {
+			if a == nil {
+				return nil
+			}
+			return soap.Validate(a.{{.Interface}})
+		}
+	{{end}}
+
+	func init() {
+		soap.RegisterType(xml.Name{Space: "{{.Name.Space}}", Local: "{{.Name.Local}}"}, func() interface{} { return new({{.GoName}}) })
+	}
+{{end}}
+
+{{define "SubstitutionGroup"}}
+	// {{.Holder}} holds a {{.GoName}} element or an element of its substitution
+	// group, chosen by the name of the element it is decoded from.
+	type {{.Holder}} struct {
+		Value interface{}
+	}
+
+	func (a *{{.Holder}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return soap.UnmarshalElement(d, start, &a.Value)
+	}
+
+	func (a {{.Holder}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return soap.MarshalElement(e, start, a.Value)
+	}
+
+	func (a {{.Holder}}) MarshalEncoded(enc *soap.Encoder, start xml.StartElement) error {
+		return enc.MarshalElement(start, a.Value)
+	}
+
+	// Validate validates the element a holds.
+	func (a *{{.Holder}}) Validate() error {
+		if a == nil {
+			return nil
+		}
+		return soap.Validate(a.Value)
+	}
+{{end}}
+
+{{define "Choice"}}
+	{{$choice := .}}
+	{{range .Branches}}
+		{{if not .Element}}
+			// {{.GoName}} holds the elements of the {{.Name}} branch of {{$choice.GoName}}.
+			type {{.GoName}} struct { {{beginStruct .GoName .}}
+				{{template "Elements" .Particles}}
+			{{endStruct}}}
+
+			{{template "Validate" (validateBranch .)}}
+		{{end}}
+	{{end}}
+
+	// {{.GoName}} holds exactly one of the branches of a choice of {{.Owner}}.
+	type {{.GoName}} struct {
+		choice soap.Choice
+	}
+
+	func ({{.GoName}}) branches() []soap.ChoiceBranch {
+		return []soap.ChoiceBranch{ {{range .Branches}}
+			{Name: "{{.Name}}", {{with .ElementName}}Element: "{{.}}", {{end}}New: func() interface{} { return new({{branchType .}}) }},{{end}}
+		}
+	}
+
+	// Branch returns the name of the branch c holds, or an empty string when
+	// it holds none.
+	func (c *{{.GoName}}) Branch() string {
+		if c == nil {
+			return ""
+		}
+		return c.choice.Branch(c.branches())
+	}
+
+	{{range $i, $branch := .Branches}}
+		{{$type := branchType .}}
+		{{if .Element}}
+			// {{.Name}} returns the {{.Name}} branch of c, and whether c holds it.
+			func (c *{{$choice.GoName}}) {{.Name}}() (value {{$type}}, ok bool) {
+				if c != nil {
+					if v, isBranch := c.choice.Value({{$i}}).(*{{$type}}); isBranch {
+						return *v, true
+					}
+				}
+				return value, false
+			}
+
+			// Set{{.Name}} makes c hold the {{.Name}} branch.
+			func (c *{{$choice.GoName}}) Set{{.Name}}(value {{$type}}) {
+				c.choice.Set({{$i}}, &value)
+			}
+		{{else}}
+			// {{.Name}} returns the {{.Name}} branch of c, and whether c holds it.
+			func (c *{{$choice.GoName}}) {{.Name}}() (*{{$type}}, bool) {
+				if c != nil {
+					if v, isBranch := c.choice.Value({{$i}}).(*{{$type}}); isBranch {
+						return v, true
+					}
+				}
+				return nil, false
+			}
+
+			// Set{{.Name}} makes c hold the {{.Name}} branch.
+			func (c *{{$choice.GoName}}) Set{{.Name}}(value *{{$type}}) {
+				c.choice.Set({{$i}}, value)
+			}
+		{{end}}
+	{{end}}
+
+	func (c *{{.GoName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+		return c.choice.Unmarshal(d, start, c.branches())
+	}
+
+	func (c {{.GoName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+		return c.choice.Marshal(e, c.branches())
+	}
+
+	func (c {{.GoName}}) MarshalEncoded(enc *soap.Encoder, start xml.StartElement) error {
+		return enc.MarshalChoice(&c.choice, c.branches())
+	}
+
+	// Validate checks that c holds a branch, and validates it.
+	func (c *{{.GoName}}) Validate() error {
+		if c == nil {
+			return nil
+		}
+		return c.choice.Validate(c.branches())
+	}
+{{end}}
+
+{{define "Validate"}}
+	// Validate checks that {{if .Pointer}}t{{else}}v{{end}} satisfies the constraints of its schema, and returns
+	// a *soap.ValidationError listing the violations otherwise.
+	func ({{.Receiver}}) Validate() error {
+		{{if .Checks}}{{if .Pointer}}if t == nil {
+			return nil
+		}
+		{{end}}var errs soap.Validation{{range .Checks}}{{$check := .}}{{if .Occurs}}
+		errs.Occurs("{{.Path}}", {{.Value}}, {{.MinOccurs}}, {{.MaxOccurs}}){{end}}{{range .Facets}}
+		errs.Facet("{{$check.Path}}", {{$check.Value}}, "{{.Name}}", "{{goString .Value}}"){{end}}{{with .Enumeration}}
+		errs.Enumeration("{{$check.Path}}", {{$check.Value}}{{range .}}, "{{goString .}}"{{end}}){{end}}{{if .Nested}}
+		errs.Check("{{.Path}}", {{.Value}}){{end}}{{with .Choice}}
+		errs.OccursChoice("{{$check.Path}}", {{$check.MinOccurs}}, {{$check.MaxOccurs}}{{range .}}, {{.}}{{end}}){{end}}{{end}}
+		return errs.Err(){{else}}return nil{{end}}
+	}
+{{end}}
+
+{{define "Union"}}
+	{{$union := .}}
+	{{range .Members}}
+		{{with .Decl}}
+			{{template "SimpleTypeDecl" .}}
+		{{end}}
 	{{end}}
+
+	// {{.GoName}} holds a value of one of the member types of a union.
+	type {{.GoName}} struct {
+		union soap.Union
+	}
+
+	func ({{.GoName}}) members() []soap.UnionMember {
+		return []soap.UnionMember{ {{range .Members}}
+			{Name: "{{.Name}}", New: func() interface{} { return new({{.GoType}}) }},{{end}}
+		}
+	}
+
+	// Member returns the name of the member type the value u holds is of, or
+	// an empty string when it holds none.
+	func (u *{{.GoName}}) Member() string {
+		if u == nil {
+			return ""
+		}
+		return u.union.Member(u.members())
+	}
+
+	{{range $i, $member := .Members}}
+		// {{.Name}} returns the value of u of the member type {{.Name}}, and whether u holds one.
+		func (u *{{$union.GoName}}) {{.Name}}() (value {{.GoType}}, ok bool) {
+			if u != nil {
+				if v, isMember := u.union.Value({{$i}}).(*{{.GoType}}); isMember {
+					return *v, true
+				}
+			}
+			return value, false
+		}
+
+		// Set{{.Name}} makes u hold value, of the member type {{.Name}}.
+		func (u *{{$union.GoName}}) Set{{.Name}}(value {{.GoType}}) {
+			u.union.Set({{$i}}, &value)
+		}
+	{{end}}
+
+	func (u {{.GoName}}) MarshalText() ([]byte, error) {
+		return u.union.MarshalText()
+	}
+
+	// UnmarshalText decodes text as a value of the first member type it is
+	// valid for.
+	func (u *{{.GoName}}) UnmarshalText(text []byte) error {
+		return u.union.UnmarshalText(text, u.members())
+	}
+
+	// Validate checks that u holds a value, and validates it.
+	func (u *{{.GoName}}) Validate() error {
+		if u == nil {
+			return nil
+		}
+		return u.union.Validate(u.members())
+	}
+{{end}}
+
+{{define "List"}}
+	func (v {{.GoName}}) MarshalText() ([]byte, error) {
+		return soap.MarshalList([]{{.ItemType}}(v))
+	}
+
+	func (v *{{.GoName}}) UnmarshalText(text []byte) error {
+		return soap.UnmarshalList(text, (*[]{{.ItemType}})(v))
+	}
+
+	func (v {{.GoName}}) MarshalXMLAttr(name xml.Name) (xml.Attr, error) {
+		return soap.MarshalListAttr(name, []{{.ItemType}}(v))
+	}
+
+	func (v *{{.GoName}}) UnmarshalXMLAttr(attr xml.Attr) error {
+		return soap.UnmarshalList([]byte(attr.Value), (*[]{{.ItemType}})(v))
+	}
+{{end}}
+
+{{define "Any"}}
+	Items []{{anyElementType .}} ` + "`" + `xml:",any" json:"items,omitempty"` + "`" + `
 {{end}}
 
 {{range .Schemas}}
-	{{ $targetNamespace := setNS .TargetNamespace }}
+	{{ $targetNamespace := setSchema . }}
 
 	{{range .SimpleType}}
 		{{template "SimpleType" .}}
@@ -116,55 +611,55 @@ var typesTmpl = `
 
 	{{range .Elements}}
 		{{$name := .Name}}
-		{{$typeName := replaceReservedWords $name | makePublic}}
+		{{$typeName := elementName $name}}
 		{{if not .Type}}
 			{{/* ComplexTypeLocal */}}
 			{{with .ComplexType}}
-				type {{$typeName}} struct {
+				type {{$typeName}} struct { {{beginStruct $typeName .}}
 					XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{$name}}\"`" + `
 					{{if ne .ComplexContent.Extension.Base ""}}
 						{{template "ComplexContent" .ComplexContent}}
 					{{else if ne .SimpleContent.Extension.Base ""}}
 						{{template "SimpleContent" .SimpleContent}}
+					{{else if ne .ComplexContent.Restriction.Base ""}}
+						{{template "ComplexContentRestriction" .ComplexContent.Restriction}}
+					{{else if ne .SimpleContent.Restriction.Base ""}}
+						{{template "SimpleContentRestriction" .SimpleContent.Restriction}}
 					{{else}}
-						{{template "Elements" .Sequence}}
-						{{template "Any" .Any}}
-						{{template "Elements" .Choice}}
-						{{template "Elements" .SequenceChoice}}
-						{{template "Elements" .All}}
+						{{template "Elements" (particles .Particle)}}
 						{{template "Attributes" .Attributes}}
+						{{template "AnyAttribute" .AnyAttribute}}
 					{{end}}
-				}
+					{{if isMixed .}}
+						{{template "MixedField"}}
+					{{end}}
+				{{endStruct}}}
+				{{template "Validate" (validateType $typeName .)}}
+				{{if isMixed .}}
+					{{template "MixedMethods" $typeName}}
+				{{end}}
+				{{with choiceOf .}}
+					{{template "Choice" .}}
+				{{end}}
+				{{with contentEnumOf $typeName .SimpleContent.Restriction}}
+					{{template "EnumValues" .}}
+				{{end}}
 			{{end}}
 			{{/* SimpleTypeLocal */}}
 			{{with .SimpleType}}
-				{{if .Doc}} {{.Doc | comment}} {{end}}
-				{{if ne .List.ItemType ""}}
-					type {{$typeName}} []{{toGoType .List.ItemType false | removePointerFromType}}
-				{{else if ne .Union.MemberTypes ""}}
-					type {{$typeName}} string
-				{{else if .Union.SimpleType}}
-					type {{$typeName}} string
-				{{else if .Restriction.Base}}
-					type {{$typeName}} {{toGoType .Restriction.Base false | removePointerFromType}}
-				{{else}}
-					type {{$typeName}} interface{}
-				{{end}}
-			
-				{{if .Restriction.Enumeration}}
-				const (
-					{{with .Restriction}}
-						{{range .Enumeration}}
-							{{if .Doc}} {{.Doc | comment}} {{end}}
-							{{$typeName}}{{$value := replaceReservedWords .Value}}{{$value | makePublic}} {{$typeName}} = "{{goString .Value}}" {{end}}
-					{{end}}
-				)
-				{{end}}
+				{{template "SimpleTypeDecl" (simpleTypeDecl $typeName .)}}
 			{{end}}
 		{{else}}
 			{{$type := toGoType .Type .Nillable | removePointerFromType}}
 			{{if ne ($typeName) ($type)}}
 				type {{$typeName}} {{$type}}
+
+				{{if validateElementType .Type}}
+					// Validate validates t as a {{$type}}.
+					func (t *{{$typeName}}) Validate() error {
+						return soap.Validate((*{{$type}})(t))
+					}
+				{{end}}
 				{{if eq ($type) ("soap.XSDDateTime")}}
 					func (xdt {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 						return soap.XSDDateTime(xdt).MarshalXML(e, start)
@@ -189,20 +684,53 @@ var typesTmpl = `
 					func (xt *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 						return (*soap.XSDTime)(xt).UnmarshalXML(d, start)
 					}
+				{{else}}
+					{{with encodingMethods $typeName .Type}}
+						{{template "EncodingMethods" .}}
+					{{end}}
 				{{end}}
 			{{end}}
 		{{end}}
+		{{with findSubstitutionGroup $name}}
+			{{template "SubstitutionGroup" .}}
+		{{end}}
+		{{if registersElement .}}
+			func init() {
+				soap.RegisterElement(xml.Name{Space: "{{$targetNamespace}}", Local: "{{$name}}"}, func() interface{} { return new({{$typeName}}) })
+			}
+		{{end}}
 	{{end}}
 
 	{{range .ComplexTypes}}
 		{{/* ComplexTypeGlobal */}}
-		{{$typeName := replaceReservedWords .Name | makePublic}}
-		{{if and (eq (len .SimpleContent.Extension.Attributes) 0) (eq (toGoType .SimpleContent.Extension.Base false) "string") }}
+		{{$typeName := typeName .Name}}
+		{{if findSOAPArray .}}
+			{{with findSOAPArray .}}
+				type {{$typeName}} []{{.ItemType}}
+
+				func (a *{{$typeName}}) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+					return soap.UnmarshalArray(d, start, (*[]{{.ItemType}})(a))
+				}
+
+				func (a {{$typeName}}) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+					return soap.MarshalArray(e, start, []{{.ItemType}}(a), xml.Name{Space: "{{.ItemNamespace}}", Local: "{{.ItemName}}"})
+				}
+
+				func (a {{$typeName}}) MarshalEncoded(enc *soap.Encoder, start xml.StartElement) error {
+					return enc.MarshalArray(start, []{{.ItemType}}(a), xml.Name{Space: "{{.ItemNamespace}}", Local: "{{.ItemName}}"})
+				}
+
+				// Validate validates the items of a.
+				func (a {{$typeName}}) Validate() error {
+					return soap.Validate([]{{.ItemType}}(a))
+				}
+			{{end}}
+		{{else if and (eq (len .SimpleContent.Extension.Attributes) 0) (not .SimpleContent.Extension.AnyAttribute) (eq (toGoType .SimpleContent.Extension.Base false) "string") }}
 			type {{$typeName}} string
 		{{else}}
-			type {{$typeName}} struct {
+			type {{$typeName}} struct { {{beginStruct $typeName .}}
 				{{$type := findNameByType .Name}}
-				{{if ne .Name $type}}
+				{{if and (ne .Name $type) (not (findPolymorphicType .))}}
 					XMLName xml.Name ` + "`xml:\"{{$targetNamespace}} {{$type}}\"`" + `
 				{{end}}
 
@@ -210,16 +738,66 @@ var typesTmpl = `
 					{{template "ComplexContent" .ComplexContent}}
 				{{else if ne .SimpleContent.Extension.Base ""}}
 					{{template "SimpleContent" .SimpleContent}}
+				{{else if ne .ComplexContent.Restriction.Base ""}}
+					{{template "ComplexContentRestriction" .ComplexContent.Restriction}}
+				{{else if ne .SimpleContent.Restriction.Base ""}}
+					{{template "SimpleContentRestriction" .SimpleContent.Restriction}}
 				{{else}}
-					{{template "Elements" .Sequence}}
-					{{template "Any" .Any}}
-					{{template "Elements" .Choice}}
-					{{template "Elements" .SequenceChoice}}
-					{{template "Elements" .All}}
+					{{template "Elements" (particles .Particle)}}
 					{{template "Attributes" .Attributes}}
+					{{template "AnyAttribute" .AnyAttribute}}
 				{{end}}
+				{{if isMixed .}}
+					{{template "MixedField"}}
+				{{end}}
+			{{endStruct}}}
+			{{template "Validate" (validateType $typeName .)}}
+			{{if isMixed .}}
+				{{template "MixedMethods" $typeName}}
+			{{end}}
+			{{with contentEnumOf $typeName .SimpleContent.Restriction}}
+				{{template "EnumValues" .}}
+			{{end}}
+		{{end}}
+		{{with choiceOf .}}
+			{{template "Choice" .}}
+		{{end}}
+		{{with findPolymorphicType .}}
+			{{template "Polymorphic" .}}
+		{{end}}
+		{{range ancestorMethods .}}
+			// {{.Method}} returns the {{.Type}} t is derived from.
+			func (t *{{$typeName}}) {{.Method}}() *{{.Type}} {
+				if t.{{.Parent}} == nil {
+					t.{{.Parent}} = new({{.ParentType}})
+				}
+				return t.{{.Parent}}{{if not .Direct}}.{{.Method}}(){{end}}
+			}
+		{{end}}
+		{{if and (or soapEncoded (findPolymorphicType .)) (not (findSOAPArray .))}}
+			// XSDType returns the XML Schema type {{$typeName}} was generated from.
+			func ({{$typeName}}) XSDType() xml.Name {
+				return xml.Name{Space: "{{$targetNamespace}}", Local: "{{.Name}}"}
 			}
 		{{end}}
 	{{end}}
+
+	{{range hoistedTypes .}}
+		{{template "HoistedType" .}}
+	{{end}}
+{{end}}
+
+{{range inlineUnions}}
+	{{template "SimpleTypeDecl" .}}
+{{end}}
+
+{{range inlineLists}}
+	// {{.GoName}} is the type of the inline lists of {{.ItemType}} values.
+	type {{.GoName}} []{{.ItemType}}
+
+	{{with .Validate}}
+		{{template "Validate" .}}
+	{{end}}
+	{{template "List" .}}
 {{end}}
 `
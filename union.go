@@ -0,0 +1,165 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// unionType describes a union generated as a type holding a value of one of
+// its member types.
+type unionType struct {
+	GoName string
+	// Members are the member types of the union, in declaration order.
+	Members []*unionMember
+
+	pkg *goPackage
+}
+
+// unionMember describes a member type of a union.
+type unionMember struct {
+	// Name is the name of the accessors of the values of the member type,
+	// and GoType the Go type of the values.
+	Name   string
+	GoType string
+	// Decl is the type generated for a member type declared inline by the
+	// union.
+	Decl *simpleTypeDecl
+}
+
+// simpleTypeDecl is a simple type along with the name of the Go type it is
+// generated as, for the simple types which aren't named after themselves.
+type simpleTypeDecl struct {
+	GoName string
+	*XSDSimpleType
+}
+
+// unionMethods are the names of the methods of generated union types, which
+// accessors can't be named after.
+var unionMethods = map[string]bool{"Member": true, "MarshalText": true, "UnmarshalText": true, "Validate": true}
+
+// collectUnionTypes returns the types generated for the unions of the
+// schemas, and the ones declared inline by elements and attributes, which are
+// named after their element or attribute and generated at the end of the
+// package of their schema.
+func (g *GoWSDL) collectUnionTypes(uniqueName func(*goPackage, string) string) (map[*XSDSimpleType]*unionType, map[*goPackage][]*simpleTypeDecl) {
+	c := &unionCollector{
+		g:          g,
+		uniqueName: uniqueName,
+		unions:     make(map[*XSDSimpleType]*unionType),
+		inline:     make(map[*goPackage][]*simpleTypeDecl),
+	}
+
+	for _, schema := range g.wsdl.Types.Schemas {
+		for _, st := range schema.SimpleType {
+			_, goName, _ := g.symbols.lookup(typeSymbol, xml.Name{Space: schema.TargetNamespace, Local: st.Name})
+			c.collect(schema, goName, st)
+		}
+		for _, elm := range schema.Elements {
+			if elm.Type == "" && elm.SimpleType != nil {
+				_, goName, _ := g.symbols.lookup(elementSymbol, xml.Name{Space: schema.TargetNamespace, Local: elm.Name})
+				c.collect(schema, goName, elm.SimpleType)
+			}
+		}
+	}
+	g.forEachInlineSimpleType(func(schema *XSDSchema, name string, st *XSDSimpleType) {
+		if isUnion(st) && c.unions[st] == nil {
+			pkg := g.packageOf(schema.TargetNamespace)
+			goName := uniqueName(pkg, g.makePublicFn(normalize(name)+"Union"))
+			c.inline[pkg] = append(c.inline[pkg], &simpleTypeDecl{GoName: goName, XSDSimpleType: st})
+			c.collect(schema, goName, st)
+		}
+	})
+
+	return c.unions, c.inline
+}
+
+type unionCollector struct {
+	g          *GoWSDL
+	uniqueName func(*goPackage, string) string
+	unions     map[*XSDSimpleType]*unionType
+	inline     map[*goPackage][]*simpleTypeDecl
+}
+
+// collect describes the simple type st of schema, generated as the Go type
+// goName, when it is a union, along with the unions among its member types
+// declared inline.
+func (c *unionCollector) collect(schema *XSDSchema, goName string, st *XSDSimpleType) {
+	if !isUnion(st) || c.unions[st] != nil {
+		return
+	}
+
+	pkg := c.g.packageOf(schema.TargetNamespace)
+	union := &unionType{GoName: goName, pkg: pkg}
+	c.unions[st] = union
+
+	taken := make(map[string]bool)
+	add := func(member *unionMember) {
+		if taken[member.Name] || unionMethods[member.Name] {
+			member.Name += strconv.Itoa(len(union.Members) + 1)
+		}
+		taken[member.Name] = true
+		union.Members = append(union.Members, member)
+	}
+
+	for _, memberType := range strings.Fields(st.Union.MemberTypes) {
+		name := resolveQName(memberType, schema.Xmlns)
+		goType := c.g.goType(memberType, schema.Xmlns, false, pkg.imports)
+		accessor := capitalize(normalize(name.Local))
+		if _, memberName, ok := c.g.symbols.lookup(typeSymbol, name); ok && strings.HasPrefix(goType, "*") {
+			accessor = memberName
+		}
+		add(&unionMember{Name: accessor, GoType: removePointerFromType(goType)})
+	}
+	for _, member := range st.Union.SimpleType {
+		n := strconv.Itoa(len(union.Members) + 1)
+		decl := &simpleTypeDecl{GoName: c.uniqueName(pkg, goName+"Member"+n), XSDSimpleType: member}
+		add(&unionMember{Name: "Member" + n, GoType: decl.GoName, Decl: decl})
+		c.collect(schema, decl.GoName, member)
+	}
+}
+
+// isUnion tells whether st is a union.
+func isUnion(st *XSDSimpleType) bool {
+	return st != nil && (st.Union.MemberTypes != "" || len(st.Union.SimpleType) > 0)
+}
+
+// capitalize returns identifier with its first letter in upper case, be it
+// the name of a Go basic type.
+func capitalize(identifier string) string {
+	r := []rune(identifier)
+	if len(r) == 0 {
+		return identifier
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// unionOf returns the description of the union st.
+func (g *GoWSDL) unionOf(st *XSDSimpleType) *unionType {
+	return g.unionTypes[st]
+}
+
+// restrictsUnion tells whether the simple type xsdType, whose prefix is
+// declared by xmlns, is a union or restricts one.
+func (g *GoWSDL) restrictsUnion(xsdType string, xmlns map[string]string, depth uint8) bool {
+	schema, st := g.findSimpleType(resolveQName(xsdType, xmlns))
+	switch {
+	case st == nil || depth >= maxRecursion:
+		return false
+	case isUnion(st):
+		return true
+	}
+	return g.restrictsUnion(st.Restriction.Base, schema.Xmlns, depth+1)
+}
+
+// packageInlineUnions returns the unions declared inline by the elements and
+// the attributes of the package being generated.
+func (g *GoWSDL) packageInlineUnions() []*simpleTypeDecl {
+	return g.inlineUnions[g.currentImports.pkg]
+}
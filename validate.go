@@ -0,0 +1,396 @@
+// This Source Code Form is subject to the terms of the Mozilla Public
+// License, v. 2.0. If a copy of the MPL was not distributed with this
+// file, You can obtain one at http://mozilla.org/MPL/2.0/.
+
+package gowsdl
+
+import (
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// validation describes the Validate method generated for a type.
+type validation struct {
+	// Name is the name of the type, and Receiver the receiver of the method.
+	Name     string
+	Receiver string
+	// Pointer tells whether the receiver is a pointer, which may be nil.
+	Pointer bool
+	Checks  []*valueCheck
+}
+
+// valueCheck describes the checks of the values of a field, or of the value
+// of a simple type itself.
+type valueCheck struct {
+	// Path is the path of the values reported in violations, and Value the
+	// Go expression of the values.
+	Path  string
+	Value string
+	// Occurs tells whether the number of values is checked to be between
+	// MinOccurs and MaxOccurs, -1 standing for unbounded.
+	Occurs               bool
+	MinOccurs, MaxOccurs int
+	// Facets and Enumeration are the facets the values are restricted by.
+	Facets      []facet
+	Enumeration []string
+	// Nested tells whether the values are validated by their own Validate
+	// method.
+	Nested bool
+	// Choice lists the Go expressions of the branches of a choice whose
+	// values, in all, are checked to occur between MinOccurs and MaxOccurs
+	// times.
+	Choice []string
+}
+
+// facet is a constraining facet of a restriction.
+type facet struct {
+	Name, Value string
+}
+
+// validateType returns the Validate method of the struct name generated for
+// the complex type ct of the schema being generated.
+func (g *GoWSDL) validateType(name string, ct *XSDComplexType) *validation {
+	v := &validation{Name: name, Receiver: "t *" + name, Pointer: true}
+	g.beginStruct(name, ct)
+	defer g.endStruct()
+
+	switch {
+	case ct.ComplexContent.Extension.Base != "":
+		ext := ct.ComplexContent.Extension
+		if base := g.goType(ext.Base, g.currentSchema.Xmlns, false, g.currentImports); strings.HasPrefix(base, "*") {
+			field := base[strings.LastIndex(base, ".")+1:]
+			v.Checks = append(v.Checks, &valueCheck{Value: "t." + strings.TrimPrefix(field, "*"), Nested: true})
+		}
+		v.Checks = append(v.Checks, g.contentChecks(ext.Particle())...)
+		v.Checks = append(v.Checks, g.attributeChecks(ext.Attributes)...)
+	case ct.SimpleContent.Extension.Base != "":
+		ext := ct.SimpleContent.Extension
+		if strings.HasPrefix(g.goType(ext.Base, g.currentSchema.Xmlns, false, g.currentImports), "*") {
+			v.Checks = append(v.Checks, &valueCheck{Path: "Value", Value: "t.Value", Nested: true})
+		}
+		v.Checks = append(v.Checks, g.attributeChecks(ext.Attributes)...)
+	case ct.ComplexContent.Restriction.Base != "":
+		res := ct.ComplexContent.Restriction
+		v.Checks = append(v.Checks, g.contentChecks(res.Particle())...)
+		v.Checks = append(v.Checks, g.attributeChecks(g.restrictionAttributes(res))...)
+	case ct.SimpleContent.Restriction.Base != "":
+		res := ct.SimpleContent.Restriction
+		if check := restrictionCheck("Value", "t.Value", res); check != nil {
+			v.Checks = append(v.Checks, check)
+		}
+		v.Checks = append(v.Checks, g.attributeChecks(g.restrictionAttributes(res))...)
+	default:
+		v.Checks = append(v.Checks, g.contentChecks(ct.Particle())...)
+		v.Checks = append(v.Checks, g.attributeChecks(ct.Attributes)...)
+	}
+
+	return v
+}
+
+// validateBranch returns the Validate method of the struct holding the
+// elements of a group branch of a choice.
+func (g *GoWSDL) validateBranch(branch *choiceBranch) *validation {
+	g.beginStruct(branch.GoName, branch)
+	defer g.endStruct()
+	return &validation{
+		Name:     branch.GoName,
+		Receiver: "t *" + branch.GoName,
+		Pointer:  true,
+		Checks:   g.elementChecks(branch.Particles),
+	}
+}
+
+// validateSimpleType returns the Validate method of the type name generated
+// for the simple type st of the schema being generated, or nil when the type
+// is an interface, which can't have methods.
+func (g *GoWSDL) validateSimpleType(name string, st *XSDSimpleType) *validation {
+	v := &validation{Name: name, Receiver: "v " + name}
+
+	xmlns := g.currentSchema.Xmlns
+	switch {
+	case isList(st):
+		if item := listItem(st.List); item != "" && strings.HasPrefix(g.goType(item, xmlns, false, g.currentImports), "*") {
+			v.Checks = append(v.Checks, &valueCheck{Value: "[]" + g.listItemType(st, xmlns, 0) + "(v)", Nested: true})
+		}
+	case isUnion(st):
+		// Union types validate the value they hold.
+		return nil
+	case st.Restriction.Base != "":
+		if g.isInterface(st.Restriction.Base, xmlns, 0) {
+			return nil
+		}
+		base := g.goType(st.Restriction.Base, xmlns, false, g.currentImports)
+		if strings.HasPrefix(base, "*") {
+			v.Checks = append(v.Checks, &valueCheck{Value: base[1:] + "(v)", Nested: true})
+		}
+		if check := restrictionCheck("", "v", st.Restriction); check != nil {
+			v.Checks = append(v.Checks, check)
+		}
+	default:
+		return nil
+	}
+
+	return v
+}
+
+// validateElementType tells whether the type declared for a global element
+// of type xsdType as the Go type of xsdType can have a Validate method.
+func (g *GoWSDL) validateElementType(xsdType string) bool {
+	return !g.isInterface(xsdType, g.currentSchema.Xmlns, 0)
+}
+
+// isInterface tells whether the type xsdType, whose prefix is declared by
+// xmlns, is generated as an empty interface: a simple type that neither
+// restricts another type nor is a list or a union.
+func (g *GoWSDL) isInterface(xsdType string, xmlns map[string]string, depth uint8) bool {
+	schema, st := g.findSimpleType(resolveQName(xsdType, xmlns))
+	switch {
+	case st == nil || depth >= maxRecursion:
+		return false
+	case isList(st) || isUnion(st):
+		return false
+	case st.Restriction.Base != "":
+		return g.isInterface(st.Restriction.Base, schema.Xmlns, depth+1)
+	}
+	return true
+}
+
+// contentChecks returns the checks of the fields holding the particles of
+// the content p of the struct being generated, and of the occurrences of the
+// choices flattened into them.
+func (g *GoWSDL) contentChecks(p *XSDParticle) []*valueCheck {
+	return append(g.elementChecks(g.particles(p)), g.choiceChecks(p)...)
+}
+
+// choiceChecks returns the checks of the choices of the particle p whose
+// branches are flattened into fields of the struct being generated: a choice
+// which can't be empty must hold a branch, and a choice between single
+// elements can't hold more of them than it occurs.
+func (g *GoWSDL) choiceChecks(p *XSDParticle) []*valueCheck {
+	var checks []*valueCheck
+	appendParticles(nil, p, "", "", func(mg *XSDModelGroup, minOccurs, maxOccurs string) bool {
+		if g.choiceTypes[mg] != nil {
+			return true
+		}
+
+		check := &valueCheck{MinOccurs: occurrences(minOccurs), MaxOccurs: occurrences(maxOccurs)}
+		var paths []string
+		for _, branch := range mg.Particles {
+			if emptiable(branch) {
+				check.MinOccurs = 0
+			}
+			if branch.Element == nil || repeated(branch.Element.MaxOccurs) {
+				check.MaxOccurs = -1
+			}
+		}
+		for _, leaf := range g.particles(&XSDParticle{ModelGroup: mg}) {
+			var path string
+			switch {
+			case leaf.ModelGroup != nil:
+				path = "Choice"
+			case leaf.Any != nil:
+				path = "Items"
+			case leaf.Element.ComplexType != nil && g.hoistedType(leaf.Element) == "":
+				// Anonymous structs are held by value, and always occur.
+				return false
+			default:
+				path = g.fieldName(leaf.Element)
+			}
+			if len(paths) == 0 || paths[len(paths)-1] != path {
+				paths = append(paths, path)
+				check.Choice = append(check.Choice, "t."+path)
+			}
+		}
+		if len(paths) > 1 && (check.MinOccurs > 0 || check.MaxOccurs >= 0) {
+			check.Path = strings.Join(paths, "|")
+			checks = append(checks, check)
+		}
+		return false
+	})
+	return checks
+}
+
+// emptiable tells whether the particle p may occur without any element.
+func emptiable(p *XSDParticle) bool {
+	switch {
+	case p.Element != nil:
+		return p.Element.MinOccurs == "0"
+	case p.Any != nil:
+		return p.Any.MinOccurs == "0"
+	case p.ModelGroup != nil:
+		mg := p.ModelGroup
+		if mg.MinOccurs == "0" {
+			return true
+		}
+		// A choice is emptiable when one of its branches is, and other
+		// groups when all their particles are.
+		choice := mg.XMLName.Local == "choice"
+		for _, child := range mg.Particles {
+			if emptiable(child) == choice {
+				return choice
+			}
+		}
+		return !choice
+	}
+	return true
+}
+
+// elementChecks returns the checks of the fields holding the leaf particles
+// of the struct being generated.
+func (g *GoWSDL) elementChecks(leaves []*XSDParticle) []*valueCheck {
+	xmlns := g.currentSchema.Xmlns
+	var checks []*valueCheck
+	for _, leaf := range leaves {
+		var check *valueCheck
+		switch {
+		case leaf.ModelGroup != nil:
+			choice := g.choiceTypes[leaf.ModelGroup]
+			if choice == nil {
+				continue
+			}
+			check = &valueCheck{Path: "Choice", Nested: true}
+			check.occurs(choice.MinOccurs, choice.MaxOccurs, true)
+		case leaf.Any != nil:
+			// The elements of lax and strict wildcards are validated as
+			// the global elements they are named after.
+			check = &valueCheck{Path: "Items", Nested: anyElementType(leaf.Any) != "soap.AnyElement"}
+		case leaf.Element != nil:
+			elm := leaf.Element
+			check = &valueCheck{Path: g.fieldName(elm)}
+			switch {
+			case elm.Ref != "":
+				goType := g.goGroupType(elm.Ref, xmlns, g.currentImports)
+				if goType == "" {
+					goType = g.goElementType(elm.Ref, xmlns, false, g.currentImports)
+				}
+				check.Nested = strings.HasPrefix(goType, "*")
+				check.occurs(elm.MinOccurs, elm.MaxOccurs, check.Nested && !elm.Nillable)
+			case elm.Type != "":
+				check.Nested = strings.HasPrefix(g.goFieldType(elm.Type, xmlns, false, g.currentImports), "*")
+				// Declared simple types are held by value.
+				_, st := g.findSimpleType(resolveQName(elm.Type, xmlns))
+				check.occurs(elm.MinOccurs, elm.MaxOccurs, check.Nested && !elm.Nillable && st == nil)
+			case g.hoistedType(elm) != "":
+				check.Nested = true
+				check.occurs(elm.MinOccurs, elm.MaxOccurs, !elm.Nillable)
+			case g.inlineType(elm.SimpleType) != "":
+				check.Nested = g.validatesInline(elm.SimpleType)
+				check.occurs(elm.MinOccurs, elm.MaxOccurs, false)
+			case elm.SimpleType != nil:
+				check.Nested = strings.HasPrefix(g.goType(elm.SimpleType.Restriction.Base, xmlns, false, g.currentImports), "*")
+				check.occurs(elm.MinOccurs, elm.MaxOccurs, check.Nested)
+				if facets := restrictionCheck("", "", elm.SimpleType.Restriction); facets != nil {
+					check.Facets, check.Enumeration = facets.Facets, facets.Enumeration
+				}
+			default:
+				// Anonymous structs can't have methods, so that only their
+				// occurrences are checked.
+				check.occurs(elm.MinOccurs, elm.MaxOccurs, false)
+			}
+		}
+		if check != nil && (check.Occurs || check.Nested || check.Facets != nil || check.Enumeration != nil) {
+			check.Value = "t." + check.Path
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// attributeChecks returns the checks of the fields holding attrs, in the
+// struct being generated.
+func (g *GoWSDL) attributeChecks(attrs []*XSDAttribute) []*valueCheck {
+	var checks []*valueCheck
+	for _, attr := range attrs {
+		field := g.fieldName(attr)
+		check := &valueCheck{Path: field, Value: "t." + field}
+		switch {
+		case g.hoistedType(attr) != "":
+			check.Nested = true
+			if attr.Use == "required" {
+				check.Occurs, check.MinOccurs, check.MaxOccurs = true, 1, 1
+			}
+		case attr.Type != "":
+			// Required attributes are held by value.
+			check.Nested = strings.HasPrefix(g.goType(attr.Type, g.currentSchema.Xmlns, false, g.currentImports), "*")
+		case g.inlineType(attr.SimpleType) != "":
+			check.Nested = g.validatesInline(attr.SimpleType)
+		case attr.SimpleType != nil:
+			if facets := restrictionCheck("", "", attr.SimpleType.Restriction); facets != nil {
+				check.Facets, check.Enumeration = facets.Facets, facets.Enumeration
+			}
+		}
+		if check.Occurs || check.Nested || check.Facets != nil || check.Enumeration != nil {
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// occurs makes c check that a field occurs between minOccurs and maxOccurs
+// times, when the field is a slice, or when it is required and may be nil.
+func (c *valueCheck) occurs(minOccurs, maxOccurs string, nilable bool) {
+	min, max := occurrences(minOccurs), occurrences(maxOccurs)
+	switch {
+	case repeated(maxOccurs):
+		c.Occurs = min > 0 || max >= 0
+	case nilable:
+		c.Occurs = min > 0
+	}
+	c.MinOccurs, c.MaxOccurs = min, max
+}
+
+// occurrences returns the number of occurrences of a minOccurs or maxOccurs
+// attribute, absent ones standing for 1 and unbounded for -1.
+func occurrences(occurs string) int {
+	switch occurs {
+	case "":
+		return 1
+	case "unbounded":
+		return -1
+	}
+	n, err := strconv.Atoi(occurs)
+	if err != nil {
+		return 1
+	}
+	return n
+}
+
+// restrictionCheck returns the check of the facets and enumerations of res,
+// or nil when it has none. Patterns which Go regular expressions don't
+// support are left aside.
+func restrictionCheck(path, value string, res XSDRestriction) *valueCheck {
+	check := &valueCheck{Path: path, Value: value}
+	for _, f := range []facet{
+		{"pattern", res.Pattern.Value},
+		{"length", res.Length.Value},
+		{"minLength", res.MinLength.Value},
+		{"maxLength", res.MaxLength.Value},
+		{"minInclusive", res.MinInclusive.Value},
+		{"maxInclusive", res.MaxInclusive.Value},
+		{"minExclusive", res.MinExclusive.Value},
+		{"maxExclusive", res.MaxExclusive.Value},
+		{"totalDigits", res.TotalDigits.Value},
+		{"fractionDigits", res.FractionDigits.Value},
+	} {
+		if f.Value == "" {
+			continue
+		}
+		if f.Name == "pattern" {
+			if _, err := regexp.Compile("^(?:" + f.Value + ")$"); err != nil {
+				log.Printf("[WARN] Pattern %s is not supported and won't be validated: %v", f.Value, err)
+				continue
+			}
+		}
+		check.Facets = append(check.Facets, f)
+	}
+	for _, enum := range res.Enumeration {
+		check.Enumeration = append(check.Enumeration, enum.Value)
+	}
+
+	if check.Facets == nil && check.Enumeration == nil {
+		return nil
+	}
+	return check
+}
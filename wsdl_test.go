@@ -6,6 +6,7 @@ package gowsdl
 
 import (
 	"encoding/xml"
+	"fmt"
 	"io/ioutil"
 	"testing"
 )
@@ -22,3 +23,41 @@ func TestUnmarshal(t *testing.T) {
 		t.Errorf("incorrect result\ngot:  %#v\nwant: %#v", err, nil)
 	}
 }
+
+func TestXSDContent_Elements(t *testing.T) {
+	data := []byte(`<complexType xmlns="http://www.w3.org/2001/XMLSchema">
+	<sequence>
+		<element name="a"/>
+		<choice>
+			<element name="b"/>
+			<element name="c"/>
+		</choice>
+		<any/>
+		<element name="d"/>
+	</sequence>
+</complexType>`)
+
+	ct := new(XSDComplexType)
+	if err := xml.Unmarshal(data, ct); err != nil {
+		t.Fatal(err)
+	}
+
+	names := func(elements []*XSDElement) (names []string) {
+		for _, elm := range elements {
+			names = append(names, elm.Name)
+		}
+		return names
+	}
+	if actual := fmt.Sprint(names(ct.SequenceElements())); actual != "[a d]" {
+		t.Errorf("got sequence elements %s", actual)
+	}
+	if actual := fmt.Sprint(names(ct.SequenceChoiceElements())); actual != "[b c]" {
+		t.Errorf("got sequence choice elements %s", actual)
+	}
+	if len(ct.ChoiceElements()) != 0 || len(ct.AllElements()) != 0 {
+		t.Error("expected no choice or all elements")
+	}
+	if len(ct.SequenceAny()) != 1 {
+		t.Errorf("got %d wildcards", len(ct.SequenceAny()))
+	}
+}
@@ -12,19 +12,21 @@ const xmlschema11 = "http://www.w3.org/2001/XMLSchema"
 
 // XSDSchema represents an entire Schema structure.
 type XSDSchema struct {
-	XMLName            xml.Name          `xml:"schema"`
-	Xmlns              map[string]string `xml:"-"`
-	Tns                string            `xml:"xmlns tns,attr"`
-	Xs                 string            `xml:"xmlns xs,attr"`
-	Version            string            `xml:"version,attr"`
-	TargetNamespace    string            `xml:"targetNamespace,attr"`
-	ElementFormDefault string            `xml:"elementFormDefault,attr"`
-	Includes           []*XSDInclude     `xml:"include"`
-	Imports            []*XSDImport      `xml:"import"`
-	Elements           []*XSDElement     `xml:"element"`
-	Attributes         []*XSDAttribute   `xml:"attribute"`
-	ComplexTypes       []*XSDComplexType `xml:"complexType"` // global
-	SimpleType         []*XSDSimpleType  `xml:"simpleType"`
+	XMLName            xml.Name             `xml:"schema"`
+	Xmlns              map[string]string    `xml:"-"`
+	Tns                string               `xml:"xmlns tns,attr"`
+	Xs                 string               `xml:"xmlns xs,attr"`
+	Version            string               `xml:"version,attr"`
+	TargetNamespace    string               `xml:"targetNamespace,attr"`
+	ElementFormDefault string               `xml:"elementFormDefault,attr"`
+	Includes           []*XSDInclude        `xml:"include"`
+	Imports            []*XSDImport         `xml:"import"`
+	Elements           []*XSDElement        `xml:"element"`
+	Attributes         []*XSDAttribute      `xml:"attribute"`
+	ComplexTypes       []*XSDComplexType    `xml:"complexType"` // global
+	SimpleType         []*XSDSimpleType     `xml:"simpleType"`
+	Groups             []*XSDGroup          `xml:"group"`
+	AttributeGroups    []*XSDAttributeGroup `xml:"attributeGroup"`
 }
 
 // UnmarshalXML implements interface xml.Unmarshaler for XSDSchema.
@@ -36,6 +38,11 @@ func (s *XSDSchema) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 			s.Xmlns[attr.Name.Local] = attr.Value
 			continue
 		}
+		if attr.Name.Space == "" && attr.Name.Local == "xmlns" {
+			// default namespace, used by unprefixed references
+			s.Xmlns[""] = attr.Value
+			continue
+		}
 
 		switch attr.Name.Local {
 		case "version":
@@ -98,6 +105,18 @@ Loop:
 					return err
 				}
 				s.SimpleType = append(s.SimpleType, x)
+			case "group":
+				x := new(XSDGroup)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.Groups = append(s.Groups, x)
+			case "attributeGroup":
+				x := new(XSDAttributeGroup)
+				if err := d.DecodeElement(x, &t); err != nil {
+					return err
+				}
+				s.AttributeGroups = append(s.AttributeGroups, x)
 			default:
 				d.Skip()
 				continue Loop
@@ -124,17 +143,25 @@ type XSDImport struct {
 
 // XSDElement represents a Schema element.
 type XSDElement struct {
-	XMLName     xml.Name        `xml:"element"`
-	Name        string          `xml:"name,attr"`
-	Doc         string          `xml:"annotation>documentation"`
-	Nillable    bool            `xml:"nillable,attr"`
-	Type        string          `xml:"type,attr"`
-	Ref         string          `xml:"ref,attr"`
-	MinOccurs   string          `xml:"minOccurs,attr"`
-	MaxOccurs   string          `xml:"maxOccurs,attr"`
-	ComplexType *XSDComplexType `xml:"complexType"` // local
-	SimpleType  *XSDSimpleType  `xml:"simpleType"`
-	Groups      []*XSDGroup     `xml:"group"`
+	XMLName           xml.Name        `xml:"element"`
+	Name              string          `xml:"name,attr"`
+	Doc               string          `xml:"annotation>documentation"`
+	Nillable          bool            `xml:"nillable,attr"`
+	Type              string          `xml:"type,attr"`
+	Ref               string          `xml:"ref,attr"`
+	MinOccurs         string          `xml:"minOccurs,attr"`
+	MaxOccurs         string          `xml:"maxOccurs,attr"`
+	Abstract          bool            `xml:"abstract,attr"`
+	SubstitutionGroup string          `xml:"substitutionGroup,attr"`
+	ComplexType       *XSDComplexType `xml:"complexType"` // local
+	SimpleType        *XSDSimpleType  `xml:"simpleType"`
+	Groups            []*XSDGroup     `xml:"group"`
+}
+
+// XSDAnyAttribute represents an attribute wildcard.
+type XSDAnyAttribute struct {
+	Namespace       string `xml:"namespace,attr"`
+	ProcessContents string `xml:"processContents,attr"`
 }
 
 // XSDAny represents a Schema element.
@@ -149,51 +176,235 @@ type XSDAny struct {
 
 // XSDComplexType represents a Schema complex type.
 type XSDComplexType struct {
-	XMLName        xml.Name          `xml:"complexType"`
-	Abstract       bool              `xml:"abstract,attr"`
-	Name           string            `xml:"name,attr"`
-	Mixed          bool              `xml:"mixed,attr"`
-	Sequence       []*XSDElement     `xml:"sequence>element"`
-	Choice         []*XSDElement     `xml:"choice>element"`
-	SequenceChoice []*XSDElement     `xml:"sequence>choice>element"`
-	All            []*XSDElement     `xml:"all>element"`
-	ComplexContent XSDComplexContent `xml:"complexContent"`
-	SimpleContent  XSDSimpleContent  `xml:"simpleContent"`
-	Attributes     []*XSDAttribute   `xml:"attribute"`
-	Any            []*XSDAny         `xml:"sequence>any"`
+	XMLName  xml.Name `xml:"complexType"`
+	Abstract bool     `xml:"abstract,attr"`
+	Name     string   `xml:"name,attr"`
+	Mixed    bool     `xml:"mixed,attr"`
+	XSDContent
+	ComplexContent  XSDComplexContent    `xml:"complexContent"`
+	SimpleContent   XSDSimpleContent     `xml:"simpleContent"`
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDAnyAttribute     `xml:"anyAttribute"`
+	// inlined holds the groups inlined in the type and in its base types,
+	// once its references are resolved.
+	inlined *inlinedGroups
+}
+
+// XSDContent holds the model group a complex type, an extension, a
+// restriction or a named group is made of, or the reference to the named
+// group it is made of. At most one of its fields is set.
+type XSDContent struct {
+	Sequence *XSDModelGroup `xml:"sequence"`
+	Choice   *XSDModelGroup `xml:"choice"`
+	All      *XSDModelGroup `xml:"all"`
+	Group    *XSDGroup      `xml:"group"`
+}
+
+// Particle returns the particle the content is made of, or nil if it is
+// empty.
+func (c XSDContent) Particle() *XSDParticle {
+	switch {
+	case c.Sequence != nil:
+		return &XSDParticle{ModelGroup: c.Sequence}
+	case c.Choice != nil:
+		return &XSDParticle{ModelGroup: c.Choice}
+	case c.All != nil:
+		return &XSDParticle{ModelGroup: c.All}
+	case c.Group != nil:
+		return &XSDParticle{Group: c.Group}
+	}
+	return nil
+}
+
+// SequenceElements returns the elements of the sequence the content is made
+// of, as the Sequence field held them before model groups were kept whole.
+//
+// Deprecated: Use Sequence, or Particle, whose particles include the nested
+// model groups, group references and wildcards in document order.
+func (c XSDContent) SequenceElements() []*XSDElement {
+	return c.Sequence.elements()
+}
+
+// ChoiceElements returns the elements of the choice the content is made of,
+// as the Choice field held them before model groups were kept whole.
+//
+// Deprecated: Use Choice, or Particle.
+func (c XSDContent) ChoiceElements() []*XSDElement {
+	return c.Choice.elements()
+}
+
+// SequenceChoiceElements returns the elements of the choices nested in the
+// sequence the content is made of, as the SequenceChoice field held them
+// before model groups were kept whole.
+//
+// Deprecated: Use Sequence, or Particle.
+func (c XSDContent) SequenceChoiceElements() []*XSDElement {
+	var elements []*XSDElement
+	if c.Sequence != nil {
+		for _, p := range c.Sequence.Particles {
+			if p.ModelGroup != nil && p.ModelGroup.XMLName.Local == "choice" {
+				elements = append(elements, p.ModelGroup.elements()...)
+			}
+		}
+	}
+	return elements
+}
+
+// AllElements returns the elements of the all model group the content is
+// made of, as the All field held them before model groups were kept whole.
+//
+// Deprecated: Use All, or Particle.
+func (c XSDContent) AllElements() []*XSDElement {
+	return c.All.elements()
+}
+
+// SequenceAny returns the wildcards of the sequence the content is made of,
+// as the Any field of XSDComplexType held them before model groups were kept
+// whole.
+//
+// Deprecated: Use Sequence, or Particle.
+func (c XSDContent) SequenceAny() []*XSDAny {
+	var wildcards []*XSDAny
+	if c.Sequence != nil {
+		for _, p := range c.Sequence.Particles {
+			if p.Any != nil {
+				wildcards = append(wildcards, p.Any)
+			}
+		}
+	}
+	return wildcards
+}
+
+// XSDModelGroup represents a sequence, a choice or an all model group. Its
+// particles are kept in document order.
+type XSDModelGroup struct {
+	XMLName   xml.Name
+	MinOccurs string
+	MaxOccurs string
+	Particles []*XSDParticle
+}
+
+// UnmarshalXML implements interface xml.Unmarshaler for XSDModelGroup.
+func (mg *XSDModelGroup) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	mg.XMLName = start.Name
+	for _, attr := range start.Attr {
+		switch attr.Name.Local {
+		case "minOccurs":
+			mg.MinOccurs = attr.Value
+		case "maxOccurs":
+			mg.MaxOccurs = attr.Value
+		}
+	}
+
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			p := new(XSDParticle)
+			switch t.Name.Local {
+			case "element":
+				p.Element = new(XSDElement)
+				err = d.DecodeElement(p.Element, &t)
+			case "sequence", "choice", "all":
+				p.ModelGroup = new(XSDModelGroup)
+				err = d.DecodeElement(p.ModelGroup, &t)
+			case "group":
+				p.Group = new(XSDGroup)
+				err = d.DecodeElement(p.Group, &t)
+			case "any":
+				p.Any = new(XSDAny)
+				err = d.DecodeElement(p.Any, &t)
+			default:
+				err = d.Skip()
+				p = nil
+			}
+			if err != nil {
+				return err
+			}
+			if p != nil {
+				mg.Particles = append(mg.Particles, p)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// elements returns the elements among the particles of mg, which may be nil.
+func (mg *XSDModelGroup) elements() []*XSDElement {
+	if mg == nil {
+		return nil
+	}
+	var elements []*XSDElement
+	for _, p := range mg.Particles {
+		if p.Element != nil {
+			elements = append(elements, p.Element)
+		}
+	}
+	return elements
+}
+
+// XSDParticle is a particle of a model group: an element, a nested model
+// group, a reference to a named group or a wildcard. Exactly one of its fields
+// is set.
+type XSDParticle struct {
+	Element    *XSDElement
+	ModelGroup *XSDModelGroup
+	Group      *XSDGroup
+	Any        *XSDAny
 }
 
 // XSDGroup element is used to define a group of elements to be used in complex type definitions.
 type XSDGroup struct {
-	Name     string       `xml:"name,attr"`
-	Ref      string       `xml:"ref,attr"`
-	Sequence []XSDElement `xml:"sequence>element"`
-	Choice   []XSDElement `xml:"choice>element"`
-	All      []XSDElement `xml:"all>element"`
+	Name      string `xml:"name,attr"`
+	Ref       string `xml:"ref,attr"`
+	MinOccurs string `xml:"minOccurs,attr"`
+	MaxOccurs string `xml:"maxOccurs,attr"`
+	XSDContent
+	inlining bool
+}
+
+// XSDAttributeGroup element is used to define a group of attributes to be
+// used in complex type definitions.
+type XSDAttributeGroup struct {
+	Name            string               `xml:"name,attr"`
+	Ref             string               `xml:"ref,attr"`
+	Attributes      []*XSDAttribute      `xml:"attribute"`
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDAnyAttribute     `xml:"anyAttribute"`
+	inlining        bool
 }
 
 // XSDComplexContent element defines extensions or restrictions on a complex
 // type that contains mixed content or elements only.
 type XSDComplexContent struct {
-	XMLName   xml.Name     `xml:"complexContent"`
-	Extension XSDExtension `xml:"extension"`
+	XMLName     xml.Name       `xml:"complexContent"`
+	Mixed       bool           `xml:"mixed,attr"`
+	Extension   XSDExtension   `xml:"extension"`
+	Restriction XSDRestriction `xml:"restriction"`
 }
 
 // XSDSimpleContent element contains extensions or restrictions on a text-only
 // complex type or on a simple type as content and contains no elements.
 type XSDSimpleContent struct {
-	XMLName   xml.Name     `xml:"simpleContent"`
-	Extension XSDExtension `xml:"extension"`
+	XMLName     xml.Name       `xml:"simpleContent"`
+	Extension   XSDExtension   `xml:"extension"`
+	Restriction XSDRestriction `xml:"restriction"`
 }
 
 // XSDExtension element extends an existing simpleType or complexType element.
 type XSDExtension struct {
-	XMLName        xml.Name        `xml:"extension"`
-	Base           string          `xml:"base,attr"`
-	Attributes     []*XSDAttribute `xml:"attribute"`
-	Sequence       []*XSDElement   `xml:"sequence>element"`
-	Choice         []*XSDElement   `xml:"choice>element"`
-	SequenceChoice []*XSDElement   `xml:"sequence>choice>element"`
+	XMLName    xml.Name        `xml:"extension"`
+	Base       string          `xml:"base,attr"`
+	Attributes []*XSDAttribute `xml:"attribute"`
+	XSDContent
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDAnyAttribute     `xml:"anyAttribute"`
 }
 
 // XSDAttribute represent an element attribute. Simple elements cannot have
@@ -206,6 +417,7 @@ type XSDAttribute struct {
 	Type       string         `xml:"type,attr"`
 	Use        string         `xml:"use,attr"`
 	Fixed      string         `xml:"fixed,attr"`
+	ArrayType  string         `xml:"http://schemas.xmlsoap.org/wsdl/ arrayType,attr"`
 	SimpleType *XSDSimpleType `xml:"simpleType"`
 }
 
@@ -233,17 +445,27 @@ type XSDUnion struct {
 	MemberTypes string           `xml:"memberTypes,attr"`
 }
 
-// XSDRestriction defines restrictions on a simpleType, simpleContent, or complexContent definition.
+// XSDRestriction defines restrictions on a simpleType, simpleContent, or
+// complexContent definition. Restrictions of complex types restate the
+// particles and attributes they keep from their base type.
 type XSDRestriction struct {
-	Base         string                `xml:"base,attr"`
-	Enumeration  []XSDRestrictionValue `xml:"enumeration"`
-	Pattern      XSDRestrictionValue   `xml:"pattern"`
-	MinInclusive XSDRestrictionValue   `xml:"minInclusive"`
-	MaxInclusive XSDRestrictionValue   `xml:"maxInclusive"`
-	WhiteSpace   XSDRestrictionValue   `xml:"whitespace"`
-	Length       XSDRestrictionValue   `xml:"length"`
-	MinLength    XSDRestrictionValue   `xml:"minLength"`
-	MaxLength    XSDRestrictionValue   `xml:"maxLength"`
+	Base           string                `xml:"base,attr"`
+	Enumeration    []XSDRestrictionValue `xml:"enumeration"`
+	Pattern        XSDRestrictionValue   `xml:"pattern"`
+	MinInclusive   XSDRestrictionValue   `xml:"minInclusive"`
+	MaxInclusive   XSDRestrictionValue   `xml:"maxInclusive"`
+	MinExclusive   XSDRestrictionValue   `xml:"minExclusive"`
+	MaxExclusive   XSDRestrictionValue   `xml:"maxExclusive"`
+	TotalDigits    XSDRestrictionValue   `xml:"totalDigits"`
+	FractionDigits XSDRestrictionValue   `xml:"fractionDigits"`
+	WhiteSpace     XSDRestrictionValue   `xml:"whitespace"`
+	Length         XSDRestrictionValue   `xml:"length"`
+	MinLength      XSDRestrictionValue   `xml:"minLength"`
+	MaxLength      XSDRestrictionValue   `xml:"maxLength"`
+	Attributes     []*XSDAttribute       `xml:"attribute"`
+	XSDContent
+	AttributeGroups []*XSDAttributeGroup `xml:"attributeGroup"`
+	AnyAttribute    *XSDAnyAttribute     `xml:"anyAttribute"`
 }
 
 // XSDRestrictionValue represents a restriction value.